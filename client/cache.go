@@ -0,0 +1,226 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AudioFormatter is implemented by synthesizers to tell the format of the audio they write, which is
+// part of the cache key.
+type AudioFormatter interface {
+	AudioFormat() string
+}
+
+// AudioCache keeps synthesized audio in a directory, one file per content hash. When the files take
+// more than maxBytes the least recently used ones are removed.
+type AudioCache struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	size    int64
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// NewAudioCache opens the cache in dir, creating the directory if needed. Files left by a previous run
+// are kept, ordered by their modification time. maxBytes must be positive.
+func NewAudioCache(dir string, maxBytes int64) (*AudioCache, error) {
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("audio cache: the size limit must be positive, got %d", maxBytes)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	c := &AudioCache{dir: dir, maxBytes: maxBytes, order: list.New(), entries: make(map[string]*list.Element)}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type existing struct {
+		entry   *cacheEntry
+		modTime time.Time
+	}
+	var found []existing
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) == ".tmp" {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, existing{&cacheEntry{key: file.Name(), size: info.Size()}, info.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].modTime.After(found[j].modTime) })
+	for _, f := range found {
+		c.entries[f.entry.key] = c.order.PushBack(f.entry)
+		c.size += f.entry.size
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c, c.evict()
+}
+
+// Get copies the cached audio into dst and reports whether it was found.
+func (c *AudioCache) Get(key string, dst string) (bool, error) {
+	c.mu.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		c.order.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	path := c.path(key)
+	if err := copyFile(path, dst); err != nil {
+		if os.IsNotExist(err) {
+			c.remove(key)
+			return false, nil
+		}
+		return false, err
+	}
+	// The modification time keeps the order of use across restarts.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return true, nil
+}
+
+// Put stores a copy of src under key.
+func (c *AudioCache) Put(key string, src string) error {
+	tmp, err := os.CreateTemp(c.dir, key+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	tmp.Close()
+	if err := copyFile(src, tmpName); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	info, err := os.Stat(tmpName)
+	if err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	if err := os.Rename(tmpName, c.path(key)); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*cacheEntry)
+		c.size += info.Size() - entry.size
+		entry.size = info.Size()
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[key] = c.order.PushFront(&cacheEntry{key: key, size: info.Size()})
+		c.size += info.Size()
+	}
+	return c.evict()
+}
+
+// Size returns the total size of the cached files.
+func (c *AudioCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+func (c *AudioCache) evict() error {
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		entry := c.order.Remove(c.order.Back()).(*cacheEntry)
+		delete(c.entries, entry.key)
+		c.size -= entry.size
+		if err := os.Remove(c.path(entry.key)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *AudioCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.size -= c.order.Remove(element).(*cacheEntry).size
+		delete(c.entries, key)
+	}
+}
+
+func (c *AudioCache) path(key string) string {
+	return filepath.Join(c.dir, key)
+}
+
+// CachingSynthesizer serves repeated phrases from an AudioCache and only calls the provider on a miss.
+// The cache holds the clean audio as returned by the provider, effects are applied to the copy.
+type CachingSynthesizer struct {
+	next   SpeechSynthesizer
+	cache  *AudioCache
+	format string
+}
+
+func NewCachingSynthesizer(next SpeechSynthesizer, cache *AudioCache) *CachingSynthesizer {
+	format := fmt.Sprintf("%T", next)
+	if formatter, ok := next.(AudioFormatter); ok {
+		format = formatter.AudioFormat()
+	}
+	return &CachingSynthesizer{next: next, cache: cache, format: format}
+}
+
+func (s *CachingSynthesizer) SynthesizeSpeech(ctx context.Context, text string, fileName string, voice Voice) error {
+	key := CacheKey(s.format, text, voice)
+	if ok, err := s.cache.Get(key, fileName); ok || err != nil {
+		return err
+	}
+	if err := s.next.SynthesizeSpeech(ctx, text, fileName, voice); err != nil {
+		return err
+	}
+	return s.cache.Put(key, fileName)
+}
+
+// CacheKey is the hex SHA-256 of everything that changes the synthesized audio.
+func CacheKey(format string, text string, voice Voice) string {
+	h := sha256.New()
+	for _, part := range []string{format, text, voice.Name, voice.Language, voice.Emotion,
+		strconv.FormatFloat(voice.Speed, 'f', -1, 64)} {
+		// Length prefixes keep ("ab", "c") and ("a", "bc") apart.
+		fmt.Fprintf(h, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudioCache(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "clean.wav")
+	require.NoError(t, NewFakeSpeechClient(nil).SynthesizeSpeech(context.Background(), "Roger", clean, Voice{}))
+	info, err := os.Stat(clean)
+	require.NoError(t, err)
+
+	t.Run("least recently used audio is evicted", func(t *testing.T) {
+		cache, err := NewAudioCache(filepath.Join(dir, "small"), 2*info.Size())
+		require.NoError(t, err)
+		for _, key := range []string{"a", "b"} {
+			require.NoError(t, cache.Put(key, clean))
+		}
+		found, err := cache.Get("a", filepath.Join(dir, "a.wav"))
+		require.NoError(t, err)
+		require.True(t, found)
+
+		require.NoError(t, cache.Put("c", clean))
+
+		assert.Equal(t, 2*info.Size(), cache.Size())
+		for key, cached := range map[string]bool{"a": true, "b": false, "c": true} {
+			found, err := cache.Get(key, filepath.Join(dir, key+".wav"))
+			assert.NoError(t, err)
+			assert.Equal(t, cached, found, key)
+		}
+		reopened, err := NewAudioCache(filepath.Join(dir, "small"), 2*info.Size())
+		require.NoError(t, err)
+		assert.Equal(t, 2*info.Size(), reopened.Size())
+	})
+
+	t.Run("size limit must be positive", func(t *testing.T) {
+		for _, maxBytes := range []int64{0, -1} {
+			_, err := NewAudioCache(filepath.Join(dir, "empty"), maxBytes)
+
+			assert.Error(t, err, maxBytes)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrQuotaExceeded means the provider throttled the requests or the quota of the account is spent.
+	ErrQuotaExceeded = errors.New("speech provider quota exceeded")
+	// ErrUnauthorized means the provider rejected the credentials of the application.
+	ErrUnauthorized = errors.New("speech provider rejected the credentials")
+	// ErrProviderUnavailable means the provider is down or unreachable, or the circuit breaker is open.
+	ErrProviderUnavailable = errors.New("speech provider is unavailable")
+)
+
+// ProviderError is a failed response of a speech provider. It matches ErrQuotaExceeded, ErrUnauthorized
+// or ErrProviderUnavailable with errors.Is depending on the status.
+type ProviderError struct {
+	Provider   string
+	StatusCode int
+	Message    string
+}
+
+func (e *ProviderError) Error() string {
+	message := fmt.Sprintf("%s: %d %s", e.Provider, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	return message
+}
+
+func (e *ProviderError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrProviderUnavailable
+	}
+	return nil
+}
+
+func newProviderError(provider string, statusCode int, body []byte) *ProviderError {
+	message := strings.TrimSpace(string(body))
+	if len(message) > 200 {
+		message = message[:200]
+	}
+	return &ProviderError{Provider: provider, StatusCode: statusCode, Message: message}
+}
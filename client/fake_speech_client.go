@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"diplom/internal/ssml"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-audio/audio"
+	"github.com/go-audio/wav"
+)
+
+const (
+	fakeSampleRate   = 16000
+	fakeToneDuration = 0.2
+	fakePause        = 0.08
+)
+
+var ErrNoTranscript = errors.New("no transcript for the audio file")
+
+// FakeSpeechClient is an offline provider for development and end-to-end tests. Synthesis writes a
+// WAV file with one tone per word, recognition returns a transcript prepared in advance.
+type FakeSpeechClient struct {
+	transcripts map[string]string
+}
+
+func init() {
+	RegisterSynthesizer("fake", func(config ProviderConfig) (SpeechSynthesizer, error) {
+		return NewFakeSpeechClient(nil), nil
+	})
+	RegisterRecognizer("fake", func(config ProviderConfig) (SpeechRecognizer, error) {
+		transcripts, err := LoadTranscripts(config.TranscriptsFile)
+		if err != nil {
+			return nil, err
+		}
+		return NewFakeSpeechClient(transcripts), nil
+	})
+}
+
+// NewFakeSpeechClient creates the fake provider. transcripts maps the base name of an audio file or
+// the hex SHA-256 of its content to the text recognized in it.
+func NewFakeSpeechClient(transcripts map[string]string) *FakeSpeechClient {
+	if transcripts == nil {
+		transcripts = make(map[string]string)
+	}
+	return &FakeSpeechClient{transcripts: transcripts}
+}
+
+// LoadTranscripts reads a JSON object of transcripts for the fake recognizer. An empty path means none.
+func LoadTranscripts(path string) (map[string]string, error) {
+	transcripts := make(map[string]string)
+	if path == "" {
+		return transcripts, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &transcripts); err != nil {
+		return nil, fmt.Errorf("can't parse transcripts %s: %w", path, err)
+	}
+	return transcripts, nil
+}
+
+// SynthesizeSpeech writes a mono 16 kHz WAV file with a short tone for every word of the text, so the
+// length of the audio grows with the phrase and shrinks with the speed of the voice. The same word
+// always gets the same pitch. Only the words of an SSML document are voiced.
+func (c *FakeSpeechClient) SynthesizeSpeech(ctx context.Context, text string, fileName string, voice Voice) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ssml.IsSSML(text) {
+		speech, err := ssml.Parse(text)
+		if err != nil {
+			return err
+		}
+		text = speech.PlainText()
+	}
+	speed := voice.Speed
+	if speed <= 0 {
+		speed = 1
+	}
+	toneSamples := int(fakeToneDuration * fakeSampleRate / speed)
+	pauseSamples := int(fakePause * fakeSampleRate / speed)
+	words := strings.Fields(text)
+	samples := make([]int, 0, len(words)*(toneSamples+pauseSamples)+pauseSamples)
+	samples = append(samples, make([]int, pauseSamples)...)
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(strings.ToLower(word)))
+		frequency := 300 + float64(h.Sum32()%600)
+		for i := 0; i < toneSamples; i++ {
+			// Fade in and out so the tones don't click.
+			envelope := math.Sin(math.Pi * float64(i) / float64(toneSamples))
+			value := 0.4 * envelope * math.Sin(2*math.Pi*frequency*float64(i)/fakeSampleRate)
+			samples = append(samples, int(value*math.MaxInt16))
+		}
+		samples = append(samples, make([]int, pauseSamples)...)
+	}
+
+	f, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := wav.NewEncoder(f, fakeSampleRate, 16, 1, 1)
+	err = encoder.Write(&audio.IntBuffer{
+		Data:           samples,
+		Format:         &audio.Format{NumChannels: 1, SampleRate: fakeSampleRate},
+		SourceBitDepth: 16,
+	})
+	if err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// AudioFormat returns the format SynthesizeSpeech writes.
+func (c *FakeSpeechClient) AudioFormat() string {
+	return "fake/wav"
+}
+
+// RecognizeWords returns the transcript of RecognizeSpeech with its words placed like SynthesizeSpeech
+// voices them at normal speed, all recognized with full confidence.
+func (c *FakeSpeechClient) RecognizeWords(ctx context.Context, audioFilePath string) (*Recognition, error) {
+	text, err := c.RecognizeSpeech(ctx, audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+	recognition := &Recognition{Text: text}
+	step := time.Duration((fakeToneDuration + fakePause) * float64(time.Second))
+	start := time.Duration(fakePause * float64(time.Second))
+	for _, word := range strings.Fields(text) {
+		end := start + time.Duration(fakeToneDuration*float64(time.Second))
+		recognition.Words = append(recognition.Words, RecognizedWord{Text: word, Start: start, End: end, Confidence: 1})
+		start += step
+	}
+	return recognition, nil
+}
+
+// RecognizeSpeech returns the content of a sidecar file next to the audio (recording.wav.txt for
+// recording.wav) or the transcript registered for the file name or its content hash.
+func (c *FakeSpeechClient) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	sidecar, err := os.ReadFile(audioFilePath + ".txt")
+	if err == nil {
+		return strings.TrimSpace(string(sidecar)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if text, ok := c.transcripts[filepath.Base(audioFilePath)]; ok {
+		return text, nil
+	}
+	audioData, err := os.ReadFile(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(audioData)
+	if text, ok := c.transcripts[hex.EncodeToString(sum[:])]; ok {
+		return text, nil
+	}
+	return "", fmt.Errorf("%w %s", ErrNoTranscript, audioFilePath)
+}
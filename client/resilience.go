@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxAttempts      = 3
+	defaultRetryBaseDelay   = 200 * time.Millisecond
+	defaultRetryMaxDelay    = 5 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// RetryPolicy retries throttled and failed requests with exponential backoff and full jitter.
+type RetryPolicy struct {
+	// MaxAttempts counts the first request, 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// backoff returns the delay before the retry that follows the given attempt, starting at 1.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker fails calls fast after Threshold consecutive failures of the provider. After Cooldown
+// one trial call is let through: its success closes the breaker, its failure opens it again. A cancelled
+// trial tells nothing about the provider and the next call becomes the trial, like a trial that reports
+// nothing within Cooldown.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	trialedAt time.Time
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may go to the provider.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.Cooldown {
+			return fmt.Errorf("%w: circuit breaker is open", ErrProviderUnavailable)
+		}
+		b.state = breakerHalfOpen
+		b.trialedAt = b.now()
+		return nil
+	case breakerHalfOpen:
+		if b.now().Sub(b.trialedAt) < b.Cooldown {
+			return fmt.Errorf("%w: circuit breaker is half-open", ErrProviderUnavailable)
+		}
+		b.trialedAt = b.now()
+		return nil
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// Cancelled records a call the caller gave up on. A trial gives its slot back and the breaker stays open.
+func (b *CircuitBreaker) Cancelled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.Threshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// caller sends requests to a provider through the retry policy and the circuit breaker.
+type caller struct {
+	provider string
+	client   *http.Client
+	retry    RetryPolicy
+	breaker  *CircuitBreaker
+	sleep    func(ctx context.Context, d time.Duration) error
+}
+
+func newCaller(provider string, config ProviderConfig, defaultTimeout time.Duration) (*caller, error) {
+	client, err := config.httpClient(defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	retry := RetryPolicy{MaxAttempts: config.MaxAttempts, BaseDelay: config.RetryBaseDelay, MaxDelay: defaultRetryMaxDelay}
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = defaultMaxAttempts
+	}
+	if retry.BaseDelay == 0 {
+		retry.BaseDelay = defaultRetryBaseDelay
+	}
+	threshold, cooldown := config.BreakerThreshold, config.BreakerCooldown
+	if threshold == 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown == 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	return &caller{provider: provider, client: client, retry: retry, breaker: NewCircuitBreaker(threshold, cooldown), sleep: sleep}, nil
+}
+
+// do sends the request built by newRequest and returns the body of a successful response. The request
+// is built again for every attempt because its body can only be read once.
+func (c *caller) do(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) ([]byte, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		body, retryAfter, err := c.attempt(ctx, newRequest)
+		if err == nil {
+			c.breaker.Success()
+			return body, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil || !retryable(err) || attempt >= c.retry.MaxAttempts {
+			break
+		}
+		delay := c.retry.backoff(attempt)
+		if retryAfter > delay && retryAfter <= c.retry.MaxDelay {
+			delay = retryAfter
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			lastErr = err
+			break
+		}
+	}
+	// Only an outage counts against the provider: throttling and rejected requests are answers.
+	if ctx.Err() != nil {
+		c.breaker.Cancelled()
+	} else if errors.Is(lastErr, ErrProviderUnavailable) {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+	return nil, lastErr
+}
+
+func (c *caller) attempt(ctx context.Context, newRequest func(ctx context.Context) (*http.Request, error)) ([]byte, time.Duration, error) {
+	req, err := newRequest(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, 0, ctx.Err()
+		}
+		return nil, 0, fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, c.provider, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %s: %v", ErrProviderUnavailable, c.provider, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, time.Duration(retryAfter) * time.Second, newProviderError(c.provider, resp.StatusCode, body)
+	}
+	return body, 0, nil
+}
+
+func retryable(err error) bool {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		switch providerErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	return errors.Is(err, ErrProviderUnavailable)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreakerTrial(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	breaker.Failure()
+	assert.ErrorIs(t, breaker.Allow(), ErrProviderUnavailable)
+	now = now.Add(time.Minute)
+
+	require.NoError(t, breaker.Allow(), "the trial call")
+	assert.ErrorIs(t, breaker.Allow(), ErrProviderUnavailable, "while the trial runs")
+	now = now.Add(time.Minute)
+	// The trial never reported back, another call takes its place.
+	require.NoError(t, breaker.Allow())
+	breaker.Success()
+	assert.NoError(t, breaker.Allow())
+}
+
+func TestCircuitBreakerCancelledTrial(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	breaker.Failure()
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow(), "the trial call")
+
+	breaker.Cancelled()
+
+	require.NoError(t, breaker.Allow(), "the next call is the trial")
+	assert.ErrorIs(t, breaker.Allow(), ErrProviderUnavailable, "the breaker stays open")
+}
+
+func TestCallerCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	c, err := newCaller("test", ProviderConfig{MaxAttempts: 2, BreakerThreshold: 1, BreakerCooldown: time.Minute}, time.Second)
+	require.NoError(t, err)
+	c.breaker.now = func() time.Time { return now }
+	newRequest := func(ctx context.Context) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	}
+	_, err = c.do(context.Background(), newRequest)
+	require.ErrorIs(t, err, ErrProviderUnavailable)
+	now = now.Add(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+	_, err = c.do(ctx, newRequest)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	require.NoError(t, c.breaker.Allow(), "the cancelled trial gave its slot back")
+	assert.ErrorIs(t, c.breaker.Allow(), ErrProviderUnavailable, "the breaker stays open")
+}
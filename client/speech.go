@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Voice selects how a provider pronounces the text. Empty fields leave the provider defaults.
+type Voice struct {
+	Name     string
+	Language string
+	Emotion  string
+	// Speed is relative to the normal rate of the voice, 0 means normal.
+	Speed float64
+}
+
+// SpeechSynthesizer turns the text of a phrase into an audio file. The call is abandoned when ctx is done.
+type SpeechSynthesizer interface {
+	SynthesizeSpeech(ctx context.Context, text string, fileName string, voice Voice) error
+}
+
+// SpeechRecognizer returns the transcript of an audio file. The call is abandoned when ctx is done.
+type SpeechRecognizer interface {
+	RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error)
+}
+
+// RecognizedWord is a word of a transcript with its position in the audio and the confidence of the
+// recognizer in it, from 0 to 1.
+type RecognizedWord struct {
+	Text       string
+	Start, End time.Duration
+	Confidence float64
+}
+
+// Recognition is a transcript together with the words it is made of.
+type Recognition struct {
+	Text  string
+	Words []RecognizedWord
+}
+
+// WordRecognizer is implemented by recognizers that also tell when every word was said.
+type WordRecognizer interface {
+	RecognizeWords(ctx context.Context, audioFilePath string) (*Recognition, error)
+}
+
+// Recognize returns the transcript of the audio file with its words when the recognizer reports them
+// and without words otherwise.
+func Recognize(ctx context.Context, recognizer SpeechRecognizer, audioFilePath string) (*Recognition, error) {
+	if words, ok := recognizer.(WordRecognizer); ok {
+		return words.RecognizeWords(ctx, audioFilePath)
+	}
+	text, err := recognizer.RecognizeSpeech(ctx, audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Recognition{Text: text}, nil
+}
+
+// AsyncRecognizer is implemented by recognizers with a long-running operation for audio longer than
+// the synchronous call takes. RecognitionResult reports false until the operation is done.
+type AsyncRecognizer interface {
+	SubmitRecognition(ctx context.Context, audioFilePath string) (operationID string, err error)
+	RecognitionResult(ctx context.Context, operationID string) (*Recognition, bool, error)
+}
+
+// ProviderConfig holds the settings passed to a provider factory.
+type ProviderConfig struct {
+	APIKey   string
+	IAMToken string
+	FolderID string
+	// SynthesisURL and RecognitionURL override the endpoints of the provider, e.g. for a regional
+	// installation or a local stub.
+	SynthesisURL   string
+	RecognitionURL string
+	// AsyncRecognitionURL is the base of the long-running recognition API, OperationURL the base of the
+	// API that reports the state of the operations.
+	AsyncRecognitionURL string
+	OperationURL        string
+	// RecognitionModel and RecognitionLanguage choose the model and the language hint of recognizers
+	// that take them, like whisper.
+	RecognitionModel    string
+	RecognitionLanguage string
+	// StreamingEndpoint is the host:port of the gRPC streaming recognition API, StreamingInsecure
+	// connects to it without TLS, e.g. to a local mock server.
+	StreamingEndpoint string
+	StreamingInsecure bool
+	// Timeout limits a single request to the provider, 0 means the provider default.
+	Timeout  time.Duration
+	ProxyURL string
+	// MaxAttempts and RetryBaseDelay configure retries of throttled and failed requests,
+	// BreakerThreshold and BreakerCooldown the circuit breaker. 0 means the default.
+	MaxAttempts      int
+	RetryBaseDelay   time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// TranscriptsFile is a JSON object of transcripts for the fake recognizer.
+	TranscriptsFile string
+}
+
+// httpClient builds the HTTP client of a provider with the configured timeout and proxy.
+func (c ProviderConfig) httpClient(defaultTimeout time.Duration) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid speech proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
+
+// SynthesizerFactory creates a synthesizer of a registered provider.
+type SynthesizerFactory func(config ProviderConfig) (SpeechSynthesizer, error)
+
+// RecognizerFactory creates a recognizer of a registered provider.
+type RecognizerFactory func(config ProviderConfig) (SpeechRecognizer, error)
+
+var (
+	registryMu   sync.RWMutex
+	synthesizers = make(map[string]SynthesizerFactory)
+	recognizers  = make(map[string]RecognizerFactory)
+)
+
+// RegisterSynthesizer makes a speech synthesis provider available by name. Providers register
+// themselves from init, registering the same name twice panics.
+func RegisterSynthesizer(name string, factory SynthesizerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := synthesizers[name]; ok {
+		panic("speech synthesizer " + name + " is already registered")
+	}
+	synthesizers[name] = factory
+}
+
+// RegisterRecognizer makes a speech recognition provider available by name. Providers register
+// themselves from init, registering the same name twice panics.
+func RegisterRecognizer(name string, factory RecognizerFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := recognizers[name]; ok {
+		panic("speech recognizer " + name + " is already registered")
+	}
+	recognizers[name] = factory
+}
+
+// NewSynthesizer creates the synthesizer of the provider registered under name.
+func NewSynthesizer(name string, config ProviderConfig) (SpeechSynthesizer, error) {
+	registryMu.RLock()
+	factory, ok := synthesizers[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown speech synthesizer %q, available: %v", name, Synthesizers())
+	}
+	return factory(config)
+}
+
+// NewRecognizer creates the recognizer of the provider registered under name.
+func NewRecognizer(name string, config ProviderConfig) (SpeechRecognizer, error) {
+	registryMu.RLock()
+	factory, ok := recognizers[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown speech recognizer %q, available: %v", name, Recognizers())
+	}
+	return factory(config)
+}
+
+// Synthesizers returns the sorted names of the registered synthesis providers.
+func Synthesizers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(synthesizers))
+	for name := range synthesizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Recognizers returns the sorted names of the registered recognition providers.
+func Recognizers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(recognizers))
+	for name := range recognizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
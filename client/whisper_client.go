@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWhisperURL      = "https://api.openai.com/v1/audio/transcriptions"
+	defaultWhisperModel    = "whisper-1"
+	defaultWhisperLanguage = "en"
+	// Transcription takes about as long as the audio on a CPU server.
+	defaultWhisperTimeout = 2 * time.Minute
+)
+
+// WhisperClient recognizes speech with the OpenAI transcription API, /v1/audio/transcriptions, which
+// self-hosted Whisper servers implement too.
+type WhisperClient struct {
+	url           string
+	authorization string
+	model         string
+	language      string
+	caller        *caller
+}
+
+type whisperTranscription struct {
+	Text     string           `json:"text"`
+	Segments []whisperSegment `json:"segments"`
+	Words    []whisperWord    `json:"words"`
+}
+
+type whisperSegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Text       string  `json:"text"`
+	AvgLogprob float64 `json:"avg_logprob"`
+}
+
+type whisperWord struct {
+	Word  string  `json:"word"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// Probability is reported by some self-hosted servers, not by OpenAI.
+	Probability *float64 `json:"probability"`
+}
+
+func init() {
+	RegisterRecognizer("whisper", func(config ProviderConfig) (SpeechRecognizer, error) {
+		return NewWhisperClient(config)
+	})
+}
+
+// NewWhisperClient creates a client of config.RecognitionURL, sending config.APIKey as a bearer token
+// when it is set. The model and the language hint default to whisper-1 and English.
+func NewWhisperClient(config ProviderConfig) (*WhisperClient, error) {
+	c := &WhisperClient{
+		url:      config.RecognitionURL,
+		model:    config.RecognitionModel,
+		language: config.RecognitionLanguage,
+	}
+	if c.url == "" {
+		c.url = DefaultWhisperURL
+	}
+	if c.model == "" {
+		c.model = defaultWhisperModel
+	}
+	if c.language == "" {
+		c.language = defaultWhisperLanguage
+	}
+	if config.APIKey != "" {
+		c.authorization = "Bearer " + config.APIKey
+	}
+	caller, err := newCaller("whisper", config, defaultWhisperTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c.caller = caller
+	return c, nil
+}
+
+func (c *WhisperClient) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	recognition, err := c.RecognizeWords(ctx, audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	return recognition.Text, nil
+}
+
+// RecognizeWords asks for the verbose transcription with word timestamps. A word gets its own
+// probability when the server reports it and the average probability of its segment otherwise; a server
+// without word timestamps has the words of every segment spread evenly over it.
+func (c *WhisperClient) RecognizeWords(ctx context.Context, audioFilePath string) (*Recognition, error) {
+	body, contentType, err := c.form(audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.caller.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var transcription whisperTranscription
+	if err := json.Unmarshal(response, &transcription); err != nil {
+		return nil, err
+	}
+	return transcription.recognition(), nil
+}
+
+// form builds the multipart body once, every attempt of the request sends it again.
+func (c *WhisperClient) form(audioFilePath string) ([]byte, string, error) {
+	audio, err := os.Open(audioFilePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer audio.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	file, err := w.CreateFormFile("file", filepath.Base(audioFilePath))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := io.Copy(file, audio); err != nil {
+		return nil, "", err
+	}
+	fields := [][2]string{
+		{"model", c.model},
+		{"language", c.language},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+		{"timestamp_granularities[]", "word"},
+	}
+	for _, field := range fields {
+		if err := w.WriteField(field[0], field[1]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), w.FormDataContentType(), nil
+}
+
+func (t *whisperTranscription) recognition() *Recognition {
+	recognition := &Recognition{Text: strings.TrimSpace(t.Text)}
+	if len(t.Words) > 0 {
+		for _, word := range t.Words {
+			confidence := t.segmentConfidence(word.Start)
+			if word.Probability != nil {
+				confidence = *word.Probability
+			}
+			recognition.Words = append(recognition.Words, RecognizedWord{
+				Text:       strings.TrimSpace(word.Word),
+				Start:      seconds(word.Start),
+				End:        seconds(word.End),
+				Confidence: confidence,
+			})
+		}
+		return recognition
+	}
+	for _, segment := range t.Segments {
+		words := strings.Fields(segment.Text)
+		step := (segment.End - segment.Start) / float64(len(words))
+		for i, word := range words {
+			recognition.Words = append(recognition.Words, RecognizedWord{
+				Text:       word,
+				Start:      seconds(segment.Start + float64(i)*step),
+				End:        seconds(segment.Start + float64(i+1)*step),
+				Confidence: math.Exp(segment.AvgLogprob),
+			})
+		}
+	}
+	return recognition
+}
+
+// segmentConfidence is the average probability of the segment said at the time, 0 when there is none.
+func (t *whisperTranscription) segmentConfidence(at float64) float64 {
+	for _, segment := range t.Segments {
+		if at >= segment.Start && at < segment.End {
+			return math.Exp(segment.AvgLogprob)
+		}
+	}
+	return 0
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
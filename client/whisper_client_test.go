@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecognizeWhisper(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answer.ogg")
+	require.NoError(t, os.WriteFile(path, []byte("recording"), 0644))
+	serve := func(t *testing.T, response string) *WhisperClient {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(response))
+		}))
+		t.Cleanup(server.Close)
+		recognizer, err := NewWhisperClient(ProviderConfig{RecognitionURL: server.URL})
+		require.NoError(t, err)
+		return recognizer
+	}
+
+	t.Run("multipart upload with verbose JSON", func(t *testing.T) {
+		var form map[string][]string
+		var file, fileName, authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			form = r.MultipartForm.Value
+			f, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(f)
+			file, fileName = string(data), header.Filename
+			w.Write([]byte(`{"task": "transcribe", "language": "english", "duration": 2.1,
+				"text": " Cleared for takeoff.",
+				"segments": [{"id": 0, "start": 0.0, "end": 2.1, "text": " Cleared for takeoff.", "avg_logprob": -0.1}],
+				"words": [{"word": "Cleared", "start": 0.0, "end": 0.5}, {"word": "for", "start": 0.6, "end": 0.8},
+					{"word": "takeoff", "start": 0.9, "end": 1.5, "probability": 0.42}]}`))
+		}))
+		defer server.Close()
+		recognizer, err := NewRecognizer("whisper", ProviderConfig{APIKey: "key", RecognitionURL: server.URL,
+			RecognitionLanguage: "de"})
+		require.NoError(t, err)
+
+		recognition, err := Recognize(context.Background(), recognizer, path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer key", authorization)
+		assert.Equal(t, "recording", file)
+		assert.Equal(t, "answer.ogg", fileName)
+		assert.Equal(t, []string{"whisper-1"}, form["model"])
+		assert.Equal(t, []string{"de"}, form["language"])
+		assert.Equal(t, []string{"verbose_json"}, form["response_format"])
+		assert.Equal(t, []string{"segment", "word"}, form["timestamp_granularities[]"])
+		assert.Equal(t, "Cleared for takeoff.", recognition.Text)
+		require.Len(t, recognition.Words, 3)
+		assert.Equal(t, "for", recognition.Words[1].Text)
+		assert.Equal(t, 600*time.Millisecond, recognition.Words[1].Start)
+		assert.Equal(t, 800*time.Millisecond, recognition.Words[1].End)
+		assert.InDelta(t, math.Exp(-0.1), recognition.Words[1].Confidence, 1e-9)
+		assert.Equal(t, 0.42, recognition.Words[2].Confidence)
+	})
+
+	t.Run("segments without word timestamps", func(t *testing.T) {
+		recognizer := serve(t, `{"text": "Line up and wait", "segments": [
+			{"start": 1.0, "end": 3.0, "text": " Line up and wait", "avg_logprob": -0.5}]}`)
+
+		recognition, err := recognizer.RecognizeWords(context.Background(), path)
+
+		require.NoError(t, err)
+		require.Len(t, recognition.Words, 4)
+		assert.Equal(t, RecognizedWord{Text: "up", Start: 1500 * time.Millisecond, End: 2 * time.Second,
+			Confidence: math.Exp(-0.5)}, recognition.Words[1])
+	})
+
+	t.Run("segment with no words", func(t *testing.T) {
+		recognizer := serve(t, `{"text": "Roger", "segments": [
+			{"start": 0.0, "end": 1.0, "text": " ", "avg_logprob": -0.2},
+			{"start": 1.0, "end": 2.0, "text": " Roger", "avg_logprob": -0.3}]}`)
+
+		recognition, err := recognizer.RecognizeWords(context.Background(), path)
+
+		require.NoError(t, err)
+		assert.Equal(t, []RecognizedWord{{Text: "Roger", Start: time.Second, End: 2 * time.Second,
+			Confidence: math.Exp(-0.3)}}, recognition.Words)
+	})
+
+	t.Run("text without timestamps", func(t *testing.T) {
+		recognizer := serve(t, `{"text": " Wilco"}`)
+
+		recognition, err := recognizer.RecognizeWords(context.Background(), path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Wilco", recognition.Text)
+		assert.Empty(t, recognition.Words)
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error": {"message": "Rate limit reached"}}`, http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+		recognizer, err := NewWhisperClient(ProviderConfig{RecognitionURL: server.URL, MaxAttempts: 1})
+		require.NoError(t, err)
+
+		_, err = recognizer.RecognizeSpeech(context.Background(), path)
+
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+	})
+}
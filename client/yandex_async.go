@@ -0,0 +1,183 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultYandexAsyncRecognitionURL = "https://stt.api.cloud.yandex.net/stt/v3"
+	DefaultYandexOperationURL        = "https://operation.api.cloud.yandex.net/operations"
+)
+
+// ErrRecognitionFailed means the provider finished the operation without a result.
+var ErrRecognitionFailed = errors.New("speech recognition failed")
+
+var yandexContainerTypes = map[string]string{"wav": "WAV", "mp3": "MP3", "ogg": "OGG_OPUS"}
+
+type yandexOperation struct {
+	ID    string `json:"id"`
+	Done  bool   `json:"done"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// yandexRecognitionResult is a line of getRecognition, a StreamingResponse in the JSON mapping of
+// protobuf, where 64-bit integers are strings.
+type yandexRecognitionResult struct {
+	Result struct {
+		Final *struct {
+			Alternatives []struct {
+				Words []struct {
+					Text        string    `json:"text"`
+					StartTimeMs jsonInt64 `json:"startTimeMs"`
+					EndTimeMs   jsonInt64 `json:"endTimeMs"`
+				} `json:"words"`
+				Text       string  `json:"text"`
+				Confidence float64 `json:"confidence"`
+			} `json:"alternatives"`
+		} `json:"final"`
+	} `json:"result"`
+}
+
+// SubmitRecognition starts the long-running recognition of the audio file and returns the ID of the
+// operation. The audio is sent in the request, the container is told by the extension of the file.
+func (c *YandexSpeechClient) SubmitRecognition(ctx context.Context, audioFilePath string) (string, error) {
+	audioData, err := os.ReadFile(audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	request, err := json.Marshal(map[string]any{
+		"content": base64.StdEncoding.EncodeToString(audioData),
+		"recognitionModel": map[string]any{
+			"model": defaultYandexStreamingModel,
+			"audioFormat": map[string]any{
+				"containerAudio": map[string]string{"containerAudioType": yandexContainerTypes[containerOf(audioFilePath)]},
+			},
+			"languageRestriction": map[string]any{
+				"restrictionType": "WHITELIST",
+				"languageCode":    []string{defaultYandexRecognitionLang},
+			},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	body, err := c.caller.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.asyncRecognitionURL+"/recognizeFileAsync", bytes.NewReader(request))
+		if err != nil {
+			return nil, err
+		}
+		c.authorizeAsync(req)
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	var operation yandexOperation
+	if err := json.Unmarshal(body, &operation); err != nil {
+		return "", err
+	}
+	if operation.ID == "" {
+		return "", fmt.Errorf("%w: yandex returned no operation", ErrRecognitionFailed)
+	}
+	return operation.ID, nil
+}
+
+// RecognitionResult checks the operation and, once it is done, returns the text and the words of the
+// final results.
+func (c *YandexSpeechClient) RecognitionResult(ctx context.Context, operationID string) (*Recognition, bool, error) {
+	body, err := c.get(ctx, c.operationURL+"/"+url.PathEscape(operationID))
+	if err != nil {
+		return nil, false, err
+	}
+	var operation yandexOperation
+	if err := json.Unmarshal(body, &operation); err != nil {
+		return nil, false, err
+	}
+	if !operation.Done {
+		return nil, false, nil
+	}
+	if operation.Error != nil {
+		return nil, true, fmt.Errorf("%w: yandex: %d %s", ErrRecognitionFailed, operation.Error.Code, operation.Error.Message)
+	}
+
+	body, err = c.get(ctx, c.asyncRecognitionURL+"/getRecognition?"+url.Values{"operationId": {operationID}}.Encode())
+	if err != nil {
+		return nil, true, err
+	}
+	recognition := &Recognition{}
+	var finals []string
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	for {
+		var line yandexRecognitionResult
+		err := decoder.Decode(&line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, true, err
+		}
+		final := line.Result.Final
+		if final == nil || len(final.Alternatives) == 0 || final.Alternatives[0].Text == "" {
+			continue
+		}
+		best := final.Alternatives[0]
+		finals = append(finals, best.Text)
+		for _, word := range best.Words {
+			recognition.Words = append(recognition.Words, RecognizedWord{
+				Text:       word.Text,
+				Start:      time.Duration(word.StartTimeMs) * time.Millisecond,
+				End:        time.Duration(word.EndTimeMs) * time.Millisecond,
+				Confidence: best.Confidence,
+			})
+		}
+	}
+	recognition.Text = strings.Join(finals, " ")
+	return recognition, true, nil
+}
+
+func (c *YandexSpeechClient) get(ctx context.Context, address string) ([]byte, error) {
+	return c.caller.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.authorizeAsync(req)
+		return req, nil
+	})
+}
+
+// authorizeAsync authorizes a request to the v3 API, which takes the folder in a header.
+func (c *YandexSpeechClient) authorizeAsync(req *http.Request) {
+	c.authorize(req)
+	if c.folderID != "" {
+		req.Header.Set("x-folder-id", c.folderID)
+	}
+}
+
+// jsonInt64 reads a 64-bit integer written as a string or as a number.
+type jsonInt64 int64
+
+func (i *jsonInt64) UnmarshalJSON(data []byte) error {
+	value, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = jsonInt64(value)
+	return nil
+}
@@ -2,99 +2,166 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"diplom/internal/ssml"
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
+	"errors"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultYandexSynthesisURL    = "https://tts.api.cloud.yandex.net/speech/v1/tts:synthesize"
+	DefaultYandexRecognitionURL  = "https://stt.api.cloud.yandex.net/speech/v1/stt:recognize"
+	defaultYandexTimeout         = 30 * time.Second
+	defaultYandexRecognitionLang = "en-US"
 )
 
 type YandexSpeechClient struct {
-	apiKey string
-	client *http.Client
+	authorization  string
+	folderID       string
+	synthesisURL   string
+	recognitionURL string
+	// asyncRecognitionURL and operationURL are used by the long-running recognition, see
+	// SubmitRecognition.
+	asyncRecognitionURL string
+	operationURL        string
+	caller              *caller
 }
 
 type Answer struct {
 	Result string `json:"result"`
 }
 
-func NewYandexSpeechClient() *YandexSpeechClient {
-	return &YandexSpeechClient{
-		apiKey: "",
-		client: &http.Client{},
-	}
+func init() {
+	RegisterSynthesizer("yandex", func(config ProviderConfig) (SpeechSynthesizer, error) {
+		return NewYandexSpeechClient(config)
+	})
+	RegisterRecognizer("yandex", func(config ProviderConfig) (SpeechRecognizer, error) {
+		return NewYandexSpeechClient(config)
+	})
 }
 
-func (c *YandexSpeechClient) SynthesizeSpeech(text string, fileName string, accent string) error {
-	synURL := "https://tts.api.cloud.yandex.net/speech/v1/tts:synthesize"
-	headers := map[string]string{
-		"Authorization": "Api-Key " + c.apiKey,
-		"Content-Type":  "application/x-www-form-urlencoded",
+// NewYandexSpeechClient creates a SpeechKit client. It authenticates with the IAM token when one is
+// set and with the API key otherwise; an IAM token also needs the folder ID. Without credentials the
+// client is still created, SpeechKit rejects its requests.
+func NewYandexSpeechClient(config ProviderConfig) (*YandexSpeechClient, error) {
+	c := &YandexSpeechClient{
+		folderID:            config.FolderID,
+		synthesisURL:        config.SynthesisURL,
+		recognitionURL:      config.RecognitionURL,
+		asyncRecognitionURL: strings.TrimSuffix(config.AsyncRecognitionURL, "/"),
+		operationURL:        strings.TrimSuffix(config.OperationURL, "/"),
+	}
+	switch {
+	case config.IAMToken != "":
+		if config.FolderID == "" {
+			return nil, errors.New("yandex speech: a folder ID is required with an IAM token")
+		}
+		c.authorization = "Bearer " + config.IAMToken
+	case config.APIKey != "":
+		c.authorization = "Api-Key " + config.APIKey
+	}
+	if c.synthesisURL == "" {
+		c.synthesisURL = DefaultYandexSynthesisURL
+	}
+	if c.recognitionURL == "" {
+		c.recognitionURL = DefaultYandexRecognitionURL
+	}
+	if c.asyncRecognitionURL == "" {
+		c.asyncRecognitionURL = DefaultYandexAsyncRecognitionURL
+	}
+	if c.operationURL == "" {
+		c.operationURL = DefaultYandexOperationURL
+	}
+	caller, err := newCaller("yandex", config, defaultYandexTimeout)
+	if err != nil {
+		return nil, err
 	}
+	c.caller = caller
+	return c, nil
+}
 
+// SynthesizeSpeech sends an SSML document, one starting with <speak>, as SSML and any other text as is.
+func (c *YandexSpeechClient) SynthesizeSpeech(ctx context.Context, text string, fileName string, voice Voice) error {
 	data := url.Values{}
-	data.Set("text", text)
-	data.Set("lang", "en-En")
+	if ssml.IsSSML(text) {
+		data.Set("ssml", text)
+	} else {
+		data.Set("text", text)
+	}
+	data.Set("lang", "en-US")
 	data.Set("format", "mp3")
-
-	req, err := http.NewRequest("POST", synURL, bytes.NewBufferString(data.Encode()))
-	if err != nil {
-		return err
+	if voice.Language != "" {
+		data.Set("lang", voice.Language)
 	}
-
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	if voice.Name != "" {
+		data.Set("voice", voice.Name)
 	}
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
+	if voice.Emotion != "" {
+		data.Set("emotion", voice.Emotion)
+	}
+	if voice.Speed != 0 {
+		data.Set("speed", strconv.FormatFloat(voice.Speed, 'f', -1, 64))
+	}
+	if c.folderID != "" {
+		data.Set("folderId", c.folderID)
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := c.caller.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.synthesisURL, strings.NewReader(data.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		c.authorize(req)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
 	if err != nil {
 		return err
 	}
-
-	if resp.StatusCode == 200 {
-		return ioutil.WriteFile(fileName, body, 0644)
-	}
-	return fmt.Errorf("Error: %s", resp.Status)
+	return os.WriteFile(fileName, body, 0644)
 }
 
-func (c *YandexSpeechClient) RecognizeSpeech(audioFilePath string) (string, error) {
-	audioData, err := ioutil.ReadFile(audioFilePath)
+func (c *YandexSpeechClient) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	audioData, err := os.ReadFile(audioFilePath)
 	if err != nil {
 		return "", err
 	}
 
-	urlRec := "https://stt.api.cloud.yandex.net/speech/v1/stt:recognize?lang=en-US"
-	req, err := http.NewRequest("POST", urlRec, bytes.NewBuffer(audioData))
-	if err != nil {
-		return "", err
+	query := url.Values{"lang": {defaultYandexRecognitionLang}}
+	if c.folderID != "" {
+		query.Set("folderId", c.folderID)
 	}
-
-	req.Header.Set("Authorization", "Api-Key "+c.apiKey)
-
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := c.caller.do(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.recognitionURL+"?"+query.Encode(), bytes.NewReader(audioData))
+		if err != nil {
+			return nil, err
+		}
+		c.authorize(req)
+		return req, nil
+	})
 	if err != nil {
 		return "", err
 	}
 	var answer Answer
-	err = json.Unmarshal(body, &answer)
-	if err != nil {
+	if err := json.Unmarshal(body, &answer); err != nil {
 		return "", err
 	}
+	return answer.Result, nil
+}
+
+// AudioFormat returns the format SynthesizeSpeech writes.
+func (c *YandexSpeechClient) AudioFormat() string {
+	return "yandex/mp3"
+}
 
-	if resp.StatusCode == 200 {
-		return answer.Result, nil
+func (c *YandexSpeechClient) authorize(req *http.Request) {
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
 	}
-	return "", fmt.Errorf("Error: %s", resp.Status)
 }
@@ -0,0 +1,371 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	stt "github.com/yandex-cloud/go-genproto/yandex/cloud/ai/stt/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	DefaultYandexStreamingEndpoint = "stt.api.cloud.yandex.net:443"
+	defaultYandexStreamingModel    = "general"
+	// streamingChunkSize is the size of the audio chunks RecognizeSpeech sends.
+	streamingChunkSize = 32 * 1024
+)
+
+// Hypothesis is a recognition result of a streaming session. Partial hypotheses change while the audio
+// arrives, a final one is not changed any more and the next hypotheses are about the audio after it.
+type Hypothesis struct {
+	Text       string
+	Final      bool
+	Words      []RecognizedWord
+	Confidence float64
+	Start, End time.Duration
+}
+
+// StreamingOptions describe the audio of a streaming session. Container is "wav", "mp3" or "ogg"; when
+// it is empty the audio is raw 16-bit mono PCM at SampleRate.
+type StreamingOptions struct {
+	Container  string
+	SampleRate int
+	Language   string
+	Model      string
+}
+
+// RecognitionStream delivers the hypotheses of a streaming session.
+type RecognitionStream struct {
+	hypotheses chan Hypothesis
+	err        error
+}
+
+// Hypotheses returns the channel of the session results. It is closed when the session ends; the
+// caller must read it to the end or cancel the context of the session.
+func (s *RecognitionStream) Hypotheses() <-chan Hypothesis {
+	return s.hypotheses
+}
+
+// Err returns the error that ended the session, nil if it ended normally. It is valid after the channel
+// of hypotheses is closed.
+func (s *RecognitionStream) Err() error {
+	return s.err
+}
+
+// YandexStreamingClient recognizes speech with the streaming gRPC API of SpeechKit v3, which has none
+// of the length and size limits of the synchronous REST call and reports partial results.
+type YandexStreamingClient struct {
+	conn          *grpc.ClientConn
+	recognizer    stt.RecognizerClient
+	authorization string
+	folderID      string
+	breaker       *CircuitBreaker
+}
+
+func init() {
+	RegisterRecognizer("yandex-streaming", func(config ProviderConfig) (SpeechRecognizer, error) {
+		return NewYandexStreamingClient(config)
+	})
+}
+
+// NewYandexStreamingClient creates a client of config.StreamingEndpoint, authenticating like
+// NewYandexSpeechClient. The connection is made on the first session; config.StreamingInsecure turns off
+// TLS for a local server.
+func NewYandexStreamingClient(config ProviderConfig) (*YandexStreamingClient, error) {
+	c := &YandexStreamingClient{folderID: config.FolderID}
+	switch {
+	case config.IAMToken != "":
+		if config.FolderID == "" {
+			return nil, errors.New("yandex speech: a folder ID is required with an IAM token")
+		}
+		c.authorization = "Bearer " + config.IAMToken
+	case config.APIKey != "":
+		c.authorization = "Api-Key " + config.APIKey
+	}
+	endpoint := config.StreamingEndpoint
+	if endpoint == "" {
+		endpoint = DefaultYandexStreamingEndpoint
+	}
+	transport := credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	if config.StreamingInsecure {
+		transport = insecure.NewCredentials()
+	}
+	conn, err := grpc.NewClient(endpoint, grpc.WithTransportCredentials(transport))
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	c.recognizer = stt.NewRecognizerClient(conn)
+	threshold, cooldown := config.BreakerThreshold, config.BreakerCooldown
+	if threshold == 0 {
+		threshold = defaultBreakerThreshold
+	}
+	if cooldown == 0 {
+		cooldown = defaultBreakerCooldown
+	}
+	c.breaker = NewCircuitBreaker(threshold, cooldown)
+	return c, nil
+}
+
+// RecognizeStreaming starts a session and sends the chunks of audio as they come until the channel is
+// closed. The session ends when the server has recognized all the audio or ctx is done.
+func (c *YandexStreamingClient) RecognizeStreaming(ctx context.Context, options StreamingOptions, audio <-chan []byte) (*RecognitionStream, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+	if c.authorization != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", c.authorization)
+	}
+	if c.folderID != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-folder-id", c.folderID)
+	}
+	stream, err := c.recognizer.RecognizeStreaming(ctx)
+	if err != nil {
+		return nil, c.fail(ctx, err)
+	}
+	if err := stream.Send(&stt.StreamingRequest{Event: &stt.StreamingRequest_SessionOptions{SessionOptions: sessionOptions(options)}}); err != nil {
+		// The reason is in the status returned by Recv.
+		_, err = stream.Recv()
+		return nil, c.fail(ctx, err)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case chunk, ok := <-audio:
+				if !ok {
+					stream.CloseSend()
+					return
+				}
+				if err := stream.Send(&stt.StreamingRequest{Event: &stt.StreamingRequest_Chunk{Chunk: &stt.AudioChunk{Data: chunk}}}); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	result := &RecognitionStream{hypotheses: make(chan Hypothesis)}
+	go func() {
+		defer close(result.hypotheses)
+		for {
+			response, err := stream.Recv()
+			if err == io.EOF {
+				c.breaker.Success()
+				return
+			}
+			if err != nil {
+				result.err = c.fail(ctx, err)
+				return
+			}
+			hypothesis, ok := newHypothesis(response)
+			if !ok {
+				continue
+			}
+			select {
+			case result.hypotheses <- hypothesis:
+			case <-ctx.Done():
+				c.breaker.Cancelled()
+				result.err = ctx.Err()
+				return
+			}
+		}
+	}()
+	return result, nil
+}
+
+// RecognizeSpeech streams the audio file and returns the text of the final hypotheses. The container is
+// told by the extension of the file, OGG Opus is assumed like the REST API does.
+func (c *YandexStreamingClient) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	recognition, err := c.RecognizeWords(ctx, audioFilePath)
+	if err != nil {
+		return "", err
+	}
+	return recognition.Text, nil
+}
+
+// RecognizeWords streams the audio file like RecognizeSpeech and also returns the words of the final
+// hypotheses. SpeechKit rates whole hypotheses, every word gets the confidence of its hypothesis.
+func (c *YandexStreamingClient) RecognizeWords(ctx context.Context, audioFilePath string) (*Recognition, error) {
+	f, err := os.Open(audioFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	chunks := make(chan []byte)
+	readErr := make(chan error, 1)
+	options := StreamingOptions{Container: containerOf(audioFilePath), Language: defaultYandexRecognitionLang}
+	stream, err := c.RecognizeStreaming(ctx, options, chunks)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(chunks)
+		for {
+			chunk := make([]byte, streamingChunkSize)
+			n, err := f.Read(chunk)
+			if n > 0 {
+				select {
+				case chunks <- chunk[:n]:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr <- err
+				cancel()
+				return
+			}
+		}
+	}()
+
+	var finals []string
+	recognition := &Recognition{}
+	for hypothesis := range stream.Hypotheses() {
+		if !hypothesis.Final || hypothesis.Text == "" {
+			continue
+		}
+		finals = append(finals, hypothesis.Text)
+		for _, word := range hypothesis.Words {
+			word.Confidence = hypothesis.Confidence
+			recognition.Words = append(recognition.Words, word)
+		}
+	}
+	select {
+	case err := <-readErr:
+		return nil, err
+	default:
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	recognition.Text = strings.Join(finals, " ")
+	return recognition, nil
+}
+
+// Close closes the connection to the server.
+func (c *YandexStreamingClient) Close() error {
+	return c.conn.Close()
+}
+
+// fail turns the status of a failed call into the errors of the other providers and records an outage
+// in the circuit breaker. Every call ends in Success, Failure or Cancelled, or a trial call would keep the
+// breaker half-open until it expires.
+func (c *YandexStreamingClient) fail(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		c.breaker.Cancelled()
+		return ctx.Err()
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		c.breaker.Failure()
+		return err
+	}
+	statusCode := http.StatusBadRequest
+	switch st.Code() {
+	case codes.ResourceExhausted:
+		statusCode = http.StatusTooManyRequests
+	case codes.Unauthenticated:
+		statusCode = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		statusCode = http.StatusForbidden
+	case codes.Unavailable:
+		statusCode = http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		statusCode = http.StatusGatewayTimeout
+	case codes.Internal, codes.Unknown, codes.DataLoss:
+		statusCode = http.StatusInternalServerError
+	}
+	providerErr := newProviderError("yandex", statusCode, []byte(st.Message()))
+	if errors.Is(providerErr, ErrProviderUnavailable) {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+	return providerErr
+}
+
+func sessionOptions(options StreamingOptions) *stt.StreamingOptions {
+	model := &stt.RecognitionModelOptions{Model: options.Model, AudioFormat: &stt.AudioFormatOptions{}}
+	if model.Model == "" {
+		model.Model = defaultYandexStreamingModel
+	}
+	container := func(t stt.ContainerAudio_ContainerAudioType) *stt.AudioFormatOptions_ContainerAudio {
+		return &stt.AudioFormatOptions_ContainerAudio{ContainerAudio: &stt.ContainerAudio{ContainerAudioType: t}}
+	}
+	switch options.Container {
+	case "wav":
+		model.AudioFormat.AudioFormat = container(stt.ContainerAudio_WAV)
+	case "mp3":
+		model.AudioFormat.AudioFormat = container(stt.ContainerAudio_MP3)
+	case "ogg":
+		model.AudioFormat.AudioFormat = container(stt.ContainerAudio_OGG_OPUS)
+	default:
+		model.AudioFormat.AudioFormat = &stt.AudioFormatOptions_RawAudio{RawAudio: &stt.RawAudio{
+			AudioEncoding: stt.RawAudio_LINEAR16_PCM, SampleRateHertz: int64(options.SampleRate), AudioChannelCount: 1}}
+	}
+	if options.Language != "" {
+		model.LanguageRestriction = &stt.LanguageRestrictionOptions{
+			RestrictionType: stt.LanguageRestrictionOptions_WHITELIST,
+			LanguageCode:    []string{options.Language},
+		}
+	}
+	return &stt.StreamingOptions{RecognitionModel: model}
+}
+
+// newHypothesis takes the best alternative of a partial or final response. Other responses, like the end
+// of an utterance, carry no hypothesis.
+func newHypothesis(response *stt.StreamingResponse) (Hypothesis, bool) {
+	update, final := response.GetPartial(), false
+	if response.GetFinal() != nil {
+		update, final = response.GetFinal(), true
+	}
+	if len(update.GetAlternatives()) == 0 {
+		return Hypothesis{}, false
+	}
+	best := update.Alternatives[0]
+	hypothesis := Hypothesis{
+		Text:       best.Text,
+		Final:      final,
+		Confidence: best.Confidence,
+		Start:      time.Duration(best.StartTimeMs) * time.Millisecond,
+		End:        time.Duration(best.EndTimeMs) * time.Millisecond,
+	}
+	for _, word := range best.Words {
+		hypothesis.Words = append(hypothesis.Words, RecognizedWord{
+			Text:  word.Text,
+			Start: time.Duration(word.StartTimeMs) * time.Millisecond,
+			End:   time.Duration(word.EndTimeMs) * time.Millisecond,
+		})
+	}
+	return hypothesis, true
+}
+
+func containerOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return "wav"
+	case ".mp3":
+		return "mp3"
+	}
+	return "ogg"
+}
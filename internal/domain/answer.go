@@ -3,9 +3,19 @@ package domain
 import "github.com/google/uuid"
 
 type Answer struct {
-	ID            uuid.UUID `json:"id"`
-	UserID        uuid.UUID `json:"user_id"`
-	AudioAnswerID uuid.UUID `json:"audio_answer_id"`
-	Text          string    `json:"text"`
-	IsCorrect     bool      `json:"is_correct"`
+	ID            uuid.UUID    `json:"id"`
+	UserID        uuid.UUID    `json:"user_id"`
+	AudioAnswerID uuid.UUID    `json:"audio_answer_id"`
+	Text          string       `json:"text"`
+	IsCorrect     bool         `json:"is_correct"`
+	Words         []AnswerWord `json:"words"`
+}
+
+// AnswerWord is a recognized word of an answer: when it was said, in milliseconds from the start of the
+// recording, and how sure the recognizer was of it, from 0 to 1.
+type AnswerWord struct {
+	Word       string  `json:"word"`
+	StartMs    int64   `json:"start_ms"`
+	EndMs      int64   `json:"end_ms"`
+	Confidence float64 `json:"confidence"`
 }
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// APIKey lets integrations call the API on behalf of a user. A key only carries the scopes
+// it was created with, and never more than the owner's role currently grants.
+type APIKey struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	KeyHash    string       `json:"-"`
+	Scopes     []Permission `json:"scopes"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time   `json:"revoked_at,omitempty"`
+}
+
+func (k *APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
@@ -6,7 +6,10 @@ import (
 )
 
 type AudioAnswer struct {
-	ID          uuid.UUID `json:"id"`
-	PathToAudio string    `json:"path_to_audio"`
-	RecordTime  time.Time `json:"record_time"`
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	PathToAudio    string    `json:"path_to_audio"`
+	RecordTime     time.Time `json:"record_time"`
+	// RecognitionJobID is the job that recognized the recording, nil for answers saved before the jobs.
+	RecognitionJobID *uuid.UUID `json:"recognition_job_id,omitempty"`
 }
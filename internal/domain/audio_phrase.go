@@ -3,9 +3,10 @@ package domain
 import "github.com/google/uuid"
 
 type AudioPhrase struct {
-	ID          uuid.UUID `json:"id"`
-	PathToAudio string    `json:"path_to_audio"`
-	PhraseID    uuid.UUID `json:"phrase_id"`
-	Accent      string    `json:"accent"`
-	Noise       float64   `json:"noise"`
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	PathToAudio    string    `json:"path_to_audio"`
+	PhraseID       uuid.UUID `json:"phrase_id"`
+	Accent         string    `json:"accent"`
+	Noise          float64   `json:"noise"`
 }
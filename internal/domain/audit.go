@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AuditEntry records one mutating administrative call. Entries are never updated or deleted.
+// ActorLogin is kept next to ActorID so the entry stays readable after the actor is removed.
+type AuditEntry struct {
+	ID             uuid.UUID       `json:"id"`
+	OrganizationID uuid.UUID       `json:"organization_id"`
+	ActorID        uuid.UUID       `json:"actor_id"`
+	ActorLogin     string          `json:"actor_login"`
+	Action         string          `json:"action"`
+	EntityType     string          `json:"entity_type"`
+	EntityID       *uuid.UUID      `json:"entity_id,omitempty"`
+	Before         json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After          json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	IP             string          `json:"ip"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type AuditFilter struct {
+	OrganizationID uuid.UUID
+	ActorID        *uuid.UUID
+	Action         string
+	EntityType     string
+	EntityID       *uuid.UUID
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+	Offset         int
+}
+
+type AuditPage struct {
+	Entries  []AuditEntry
+	Total    int
+	Page     int
+	PageSize int
+}
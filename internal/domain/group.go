@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type Group struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	TeacherID uuid.UUID `json:"teacher_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GroupMember struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Name       string    `json:"name"`
+	Login      string    `json:"login"`
+	EnrolledAt time.Time `json:"enrolled_at"`
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// LTILaunch remembers the last launch of a user from an activity of an LTI platform. LineItem is
+// the gradebook column of the activity, empty if the platform doesn't accept scores for it.
+type LTILaunch struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Issuer         string    `json:"issuer"`
+	ResourceLinkID string    `json:"resource_link_id"`
+	DeploymentID   string    `json:"deployment_id"`
+	ContextID      string    `json:"context_id"`
+	Subject        string    `json:"subject"`
+	LineItem       string    `json:"line_item"`
+	LaunchedAt     time.Time `json:"launched_at"`
+}
@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// DefaultOrganizationID is the tenant created by the migration. Data from before multi-tenancy belongs to it.
+var DefaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// Organization is a tenant, e.g. one flight school. Users and content never cross organization boundaries.
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PasswordReset is a single-use token that lets a user set a new password without knowing the old one.
+type PasswordReset struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+func (r *PasswordReset) Usable(now time.Time) bool {
+	return r.UsedAt == nil && now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,13 @@
+package domain
+
+// PersonalData is what is stored about a user: the profile and everything the user produced
+// while practicing. AudioAnswers point to the recordings on disk, RecognitionJobs are the recordings
+// that have no answer yet because they are still recognized or their recognition failed.
+type PersonalData struct {
+	User            *User
+	Scenarios       []Scenario
+	PhraseStreams   []PhraseStream
+	Answers         []Answer
+	AudioAnswers    []AudioAnswer
+	RecognitionJobs []RecognitionJob
+}
@@ -3,8 +3,12 @@ package domain
 import "github.com/google/uuid"
 
 type Phrase struct {
-	ID         uuid.UUID `json:"id"`
-	Text       string    `json:"text"`
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Text           string    `json:"text"`
+	// Markup is the speech markup of the phrase, SSML or the simple markup of package ssml. Text then
+	// holds its plain words, which answers are graded against.
+	Markup     string    `json:"markup"`
 	TypeID     uuid.UUID `json:"type_id"`
 	PhraseType string    `json:"phrase_type"`
 }
@@ -3,6 +3,7 @@ package domain
 import "github.com/google/uuid"
 
 type PhraseType struct {
-	ID    uuid.UUID `json:"id"`
-	Title string    `json:"title"`
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Title          string    `json:"title"`
 }
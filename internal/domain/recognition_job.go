@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	RecognitionJobPending = "pending"
+	RecognitionJobDone    = "done"
+	RecognitionJobFailed  = "failed"
+)
+
+// RecognitionJob recognizes and grades a recorded answer in the background. A pending job is picked up
+// again at NextRunAt; OperationID is the long-running operation of the provider once it was submitted.
+type RecognitionJob struct {
+	ID             uuid.UUID
+	OrganizationID uuid.UUID
+	UserID         uuid.UUID
+	PhraseStreamID uuid.UUID
+	PathToAudio    string
+	RecordTime     time.Time
+	Status         string
+	OperationID    string
+	Attempts       int
+	AnswerID       *uuid.UUID
+	Error          string
+	NextRunAt      time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
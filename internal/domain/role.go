@@ -0,0 +1,37 @@
+package domain
+
+type Role string
+
+const (
+	RoleStudent    Role = "student"
+	RoleTeacher    Role = "teacher"
+	RoleEditor     Role = "editor"
+	RoleAdmin      Role = "admin"
+	RoleSuperAdmin Role = "superadmin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleStudent, RoleTeacher, RoleEditor, RoleAdmin, RoleSuperAdmin:
+		return true
+	}
+	return false
+}
+
+type Permission string
+
+const (
+	PermissionPhrasesRead         Permission = "phrases:read"
+	PermissionPhrasesWrite        Permission = "phrases:write"
+	PermissionPhraseTypesRead     Permission = "phrase_types:read"
+	PermissionPhraseTypesWrite    Permission = "phrase_types:write"
+	PermissionAnswersReadAll      Permission = "answers:read_all"
+	PermissionAnswersReadOwn      Permission = "answers:read_students"
+	PermissionAnswersDelete       Permission = "answers:delete"
+	PermissionUsersManage         Permission = "users:manage"
+	PermissionScenariosPractice   Permission = "scenarios:practice"
+	PermissionGroupsManage        Permission = "groups:manage"
+	PermissionAuditRead           Permission = "audit:read"
+	PermissionOrganizationsManage Permission = "organizations:manage"
+	PermissionVoicesManage        Permission = "voices:manage"
+)
@@ -6,10 +6,27 @@ import (
 )
 
 type Scenario struct {
-	ID        uuid.UUID  `json:"id"`
-	Title     string     `json:"title"`
-	Status    string     `json:"status"`
-	StartDate *time.Time `json:"start_date"`
-	EndDate   *time.Time `json:"end_date"`
-	UserID    uuid.UUID  `json:"user_id"`
+	ID             uuid.UUID  `json:"id"`
+	OrganizationID uuid.UUID  `json:"organization_id"`
+	Title          string     `json:"title"`
+	Status         string     `json:"status"`
+	StartDate      *time.Time `json:"start_date"`
+	EndDate        *time.Time `json:"end_date"`
+	UserID         uuid.UUID  `json:"user_id"`
+}
+
+// ScenarioResult counts the phrases of a scenario the student answered correctly.
+type ScenarioResult struct {
+	ScenarioID uuid.UUID `json:"scenario_id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Total      int       `json:"total"`
+	Passed     int       `json:"passed"`
+}
+
+// Score is the share of correctly answered phrases, from 0 to 1.
+func (r ScenarioResult) Score() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Passed) / float64(r.Total)
 }
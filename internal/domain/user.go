@@ -1,11 +1,45 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type User struct {
-	ID       uuid.UUID `json:"id"`
-	Name     string    `json:"name"`
-	Login    string    `json:"login"`
-	Password string    `json:"password"`
-	Role     bool      `json:"role"`
+	ID             uuid.UUID    `json:"id"`
+	OrganizationID uuid.UUID    `json:"organization_id"`
+	Name           string       `json:"name"`
+	Login          string       `json:"login"`
+	Password       string       `json:"password"`
+	Role           Role         `json:"role"`
+	Permissions    []Permission `json:"permissions"`
+	DeactivatedAt  *time.Time   `json:"deactivated_at,omitempty"`
+}
+
+type UserFilter struct {
+	OrganizationID uuid.UUID
+	Search         string
+	Role           Role
+	Limit          int
+	Offset         int
+}
+
+type UserPage struct {
+	Users    []User
+	Total    int
+	Page     int
+	PageSize int
+}
+
+func (u *User) Active() bool {
+	return u.DeactivatedAt == nil
+}
+
+func (u *User) HasPermission(permission Permission) bool {
+	for _, p := range u.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,15 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Voice is an entry of the voice catalog of an organization. Students pick a phrase accent by name,
+// the catalog maps it to the voice, language, emotion and speed of the speech provider.
+type Voice struct {
+	ID             uuid.UUID `json:"id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+	Accent         string    `json:"accent"`
+	Voice          string    `json:"voice"`
+	Language       string    `json:"language"`
+	Emotion        string    `json:"emotion"`
+	Speed          float64   `json:"speed"`
+}
@@ -4,6 +4,8 @@ import (
 	"diplom/internal/domain"
 	"diplom/internal/gateways/http/models"
 	"diplom/internal/services"
+	"diplom/internal/storage"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -12,23 +14,29 @@ import (
 
 type StudentAnswerHandler struct {
 	studentAnswerService *services.StudentAnswerService
+	jobs                 *services.RecognitionJobService
 	user                 *services.UserService
+	audit                *services.AuditService
 }
 
-func NewStudentAnswerHandler(s *services.StudentAnswerService, u *services.UserService) *StudentAnswerHandler {
-	return &StudentAnswerHandler{studentAnswerService: s, user: u}
+func NewStudentAnswerHandler(s *services.StudentAnswerService, j *services.RecognitionJobService, u *services.UserService,
+	a *services.AuditService) *StudentAnswerHandler {
+	return &StudentAnswerHandler{studentAnswerService: s, jobs: j, user: u, audit: a}
 }
 
 // CreateAnswer godoc
 // @Summary      Create a student answer
-// @Description  Saves a student's audio answer to a phrase stream
+// @Description  Queues the recognition of a student's audio answer to a phrase stream. The path must be a recording the student has uploaded before, other paths are rejected. The answer is graded when the job is done, poll the job at the Location header
 // @Tags         scenarios
 // @Accept       json
 // @Produce      json
-// @Param        answer  body      models.CreateAnswerRequest  true  "Student audio answer data"
-// @Success      201     {object}  string                      "Created answer ID"
-// @Failure      400     {object}  map[string]string           "Invalid request"
-// @Failure      500     {object}  map[string]string           "Internal server error"
+// @Param        answer  body      models.CreateAnswerRequest     true  "Student audio answer data"
+// @Success      202     {object}  models.RecognitionJobResponse  "Pending recognition job"
+// @Failure      400     {object}  map[string]string              "Invalid request"
+// @Failure      401     {object}  map[string]string              "Unauthorized"
+// @Failure      404     {object}  map[string]string              "Phrase stream not found"
+// @Failure      500     {object}  map[string]string              "Internal server error"
+// @Security     BearerAuth
 // @Router       /student/scenarios/answer [post]
 func (h *StudentAnswerHandler) CreateAnswer(c *gin.Context) {
 	var newAnswer models.CreateAnswerRequest
@@ -37,21 +45,106 @@ func (h *StudentAnswerHandler) CreateAnswer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	userID, err := uuid.Parse(newAnswer.UserID)
 	phraseStreamID, err := uuid.Parse(newAnswer.PhraseStreamID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phrase stream ID"})
+		return
+	}
 	recordTime := time.Now()
 
-	id, isCorrect, answerText, err := h.studentAnswerService.CreateAnswer(&domain.Answer{
-		UserID: userID,
-	}, &domain.AudioAnswer{
-		PathToAudio: newAnswer.Path,
-		RecordTime:  recordTime,
-	}, phraseStreamID)
+	job, err := h.jobs.Submit(CurrentOrganizationID(c), CurrentUser(c).ID, phraseStreamID, newAnswer.Path, recordTime)
+	h.writeSubmitted(c, job, err)
+}
+
+// UploadAnswer godoc
+// @Summary      Upload a student answer
+// @Description  Saves the uploaded recording under a generated name in the audio directory of the server and queues its recognition like CreateAnswer
+// @Tags         scenarios
+// @Accept       mpfd
+// @Produce      json
+// @Param        audio             formData  file    true  "Recording: WAV, MP3 or OGG Opus"
+// @Param        phrase_stream_id  formData  string  true  "Phrase stream ID" Format(uuid)
+// @Success      202               {object}  models.RecognitionJobResponse  "Pending recognition job"
+// @Failure      400               {object}  map[string]string              "Invalid request"
+// @Failure      401               {object}  map[string]string              "Unauthorized"
+// @Failure      404               {object}  map[string]string              "Phrase stream not found"
+// @Failure      500               {object}  map[string]string              "Internal server error"
+// @Security     BearerAuth
+// @Router       /student/scenarios/answer/upload [post]
+func (h *StudentAnswerHandler) UploadAnswer(c *gin.Context) {
+	phraseStreamID, err := uuid.Parse(c.PostForm("phrase_stream_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phrase stream ID"})
+		return
+	}
+	header, err := c.FormFile("audio")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio file is required"})
+		return
+	}
+	recording, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer recording.Close()
+
+	job, err := h.jobs.SubmitRecording(CurrentOrganizationID(c), CurrentUser(c).ID, phraseStreamID, header.Filename, recording, time.Now())
+	h.writeSubmitted(c, job, err)
+}
+
+func (h *StudentAnswerHandler) writeSubmitted(c *gin.Context, job *domain.RecognitionJob, err error) {
+	if errors.Is(err, services.ErrPhraseStreamNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, storage.ErrOutsideRoot) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audio path is outside the audio directory"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusCreated, models.CreateAnswerResponse{AnswerID: id, IsCorrect: isCorrect, Text: answerText})
+	c.Header("Location", "/api/v1/student/scenarios/answer/jobs/"+job.ID.String())
+	c.JSON(http.StatusAccepted, models.RecognitionJobResponse{JobID: job.ID, Status: job.Status})
+}
+
+// GetRecognitionJob godoc
+// @Summary      Get a recognition job
+// @Description  Returns the state of the recognition of a student answer and the graded answer once it is done
+// @Tags         scenarios
+// @Produce      json
+// @Param        id   path      string                         true  "Job ID" Format(uuid)
+// @Success      200  {object}  models.RecognitionJobResponse  "Job state"
+// @Failure      400  {object}  map[string]string              "Invalid ID"
+// @Failure      401  {object}  map[string]string              "Unauthorized"
+// @Failure      404  {object}  map[string]string              "Job not found"
+// @Failure      500  {object}  map[string]string              "Internal server error"
+// @Security     BearerAuth
+// @Router       /student/scenarios/answer/jobs/{id} [get]
+func (h *StudentAnswerHandler) GetRecognitionJob(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	job, err := h.jobs.GetJob(CurrentOrganizationID(c), CurrentUser(c).ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	response := models.RecognitionJobResponse{JobID: job.ID, Status: job.Status, Error: job.Error}
+	if job.Status == domain.RecognitionJobDone && job.AnswerID != nil {
+		answer, err := h.studentAnswerService.GetAnswerByID(CurrentOrganizationID(c), *job.AnswerID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response.Result = &models.CreateAnswerResponse{AnswerID: answer.ID, IsCorrect: answer.IsCorrect, Text: answer.Text,
+			Words: answer.Words}
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *StudentAnswerHandler) GetAnswer(c *gin.Context) {
@@ -61,7 +154,7 @@ func (h *StudentAnswerHandler) GetAnswer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	answer, audio, err := h.studentAnswerService.GetAnswer(id)
+	answer, audio, err := h.studentAnswerService.GetAnswer(CurrentOrganizationID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found"})
 		return
@@ -82,7 +175,7 @@ func (h *StudentAnswerHandler) UpdateAnswer(c *gin.Context) {
 		return
 	}
 	updatedAnswer.ID = id
-	if err := h.studentAnswerService.UpdateAnswer(&updatedAnswer); err != nil {
+	if err := h.studentAnswerService.UpdateAnswer(CurrentOrganizationID(c), &updatedAnswer); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -98,6 +191,10 @@ func (h *StudentAnswerHandler) UpdateAnswer(c *gin.Context) {
 // @Success      204  {string}  string  "No Content"
 // @Failure      400  {object}  map[string]string  "Invalid ID"
 // @Failure      500  {object}  map[string]string  "Internal server error"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Answer not found"
+// @Security     BearerAuth
 // @Router       /admin/answers/{id} [delete]
 func (h *StudentAnswerHandler) DeleteAnswer(c *gin.Context) {
 	answerID := c.Param("id")
@@ -106,23 +203,32 @@ func (h *StudentAnswerHandler) DeleteAnswer(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	if err := h.studentAnswerService.DeleteAnswer(id); err != nil {
+	if _, err := h.studentAnswerService.GetAnswerByID(CurrentOrganizationID(c), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found"})
+		return
+	}
+	if err := h.studentAnswerService.DeleteAnswer(CurrentOrganizationID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// The transcript is personal data, only the ID is logged.
+	recordAudit(c, h.audit, "answer.delete", auditEntityAnswer, id, nil, nil)
 	c.JSON(http.StatusNoContent, nil)
 }
 
 // GetAllAnswers godoc
 // @Summary      Get all student answers
-// @Description  Returns a list of all student answers
+// @Description  Returns a list of all student answers with the recognized words, their timing and confidence
 // @Tags         answers
 // @Produce      json
 // @Success      200  {array}   domain.Answer
 // @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/answers [get]
 func (h *StudentAnswerHandler) GetAllAnswers(c *gin.Context) {
-	answers, err := h.studentAnswerService.GetAllAnswers()
+	answers, err := h.studentAnswerService.GetAllAnswers(CurrentOrganizationID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
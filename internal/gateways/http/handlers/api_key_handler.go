@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+type APIKeyHandler struct {
+	user  *services.UserService
+	audit *services.AuditService
+}
+
+func NewAPIKeyHandler(u *services.UserService, a *services.AuditService) *APIKeyHandler {
+	return &APIKeyHandler{user: u, audit: a}
+}
+
+// CreateOwnAPIKey godoc
+// @Summary Создание API-ключа
+// @Description Создает персональный API-ключ текущего пользователя. Ключ показывается только в этом ответе. Scopes должны входить в права роли пользователя
+// @Tags api_keys
+// @Accept json
+// @Produce json
+// @Param input body models.CreateAPIKeyRequest true "Название, scopes и срок действия"
+// @Success 201 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string "Неверный формат запроса или недоступный scope"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Требуется вход по паролю"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/api_keys [post]
+func (h *APIKeyHandler) CreateOwnAPIKey(c *gin.Context) {
+	h.createAPIKey(c, CurrentUser(c))
+}
+
+// GetOwnAPIKeys godoc
+// @Summary Список API-ключей
+// @Description Возвращает API-ключи текущего пользователя, включая отозванные
+// @Tags api_keys
+// @Produce json
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Требуется вход по паролю"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/api_keys [get]
+func (h *APIKeyHandler) GetOwnAPIKeys(c *gin.Context) {
+	h.listAPIKeys(c, CurrentUser(c).ID)
+}
+
+// RevokeOwnAPIKey godoc
+// @Summary Отзыв API-ключа
+// @Description Отзывает API-ключ текущего пользователя
+// @Tags api_keys
+// @Produce json
+// @Param id path string true "ID ключа" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Требуется вход по паролю"
+// @Failure 404 {object} map[string]string "Ключ не найден"
+// @Security BearerAuth
+// @Router /auth/api_keys/{id} [delete]
+func (h *APIKeyHandler) RevokeOwnAPIKey(c *gin.Context) {
+	h.revokeAPIKey(c, CurrentUser(c).ID, c.Param("id"))
+}
+
+// CreateUserAPIKey godoc
+// @Summary Создание API-ключа пользователю
+// @Description Создает API-ключ от имени указанного пользователя. Ключ показывается только в этом ответе
+// @Tags api_keys
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Param input body models.CreateAPIKeyRequest true "Название, scopes и срок действия"
+// @Success 201 {object} models.CreateAPIKeyResponse
+// @Failure 400 {object} map[string]string "Неверный формат запроса или недоступный scope"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/api_keys [post]
+func (h *APIKeyHandler) CreateUserAPIKey(c *gin.Context) {
+	owner, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	if key := h.createAPIKey(c, owner); key != nil {
+		recordAudit(c, h.audit, "api_key.create", auditEntityAPIKey, key.ID, nil, key)
+	}
+}
+
+// GetUserAPIKeys godoc
+// @Summary Список API-ключей пользователя
+// @Description Возвращает API-ключи указанного пользователя
+// @Tags api_keys
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 200 {array} domain.APIKey
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Security BearerAuth
+// @Router /admin/users/{id}/api_keys [get]
+func (h *APIKeyHandler) GetUserAPIKeys(c *gin.Context) {
+	owner, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	h.listAPIKeys(c, owner.ID)
+}
+
+// RevokeUserAPIKey godoc
+// @Summary Отзыв API-ключа пользователя
+// @Description Отзывает API-ключ указанного пользователя
+// @Tags api_keys
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Param key_id path string true "ID ключа" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 404 {object} map[string]string "Ключ не найден"
+// @Security BearerAuth
+// @Router /admin/users/{id}/api_keys/{key_id} [delete]
+func (h *APIKeyHandler) RevokeUserAPIKey(c *gin.Context) {
+	owner, ok := h.findUser(c)
+	if !ok {
+		return
+	}
+	if keyID, ok := h.revokeAPIKey(c, owner.ID, c.Param("key_id")); ok {
+		recordAudit(c, h.audit, "api_key.revoke", auditEntityAPIKey, keyID, nil, nil)
+	}
+}
+
+// createAPIKey writes the response and returns the created key, or nil if creation failed.
+func (h *APIKeyHandler) createAPIKey(c *gin.Context, owner *domain.User) *domain.APIKey {
+	var request models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return nil
+	}
+	scopes := make([]domain.Permission, len(request.Scopes))
+	for i, s := range request.Scopes {
+		scopes[i] = domain.Permission(s)
+	}
+	key, rawKey, err := h.user.CreateAPIKey(owner, request.Name, scopes, request.ExpiresAt)
+	if errors.Is(err, services.ErrAPIKeyNoScopes) || errors.Is(err, services.ErrScopeNotGranted) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{Key: rawKey, APIKey: *key})
+	return key
+}
+
+func (h *APIKeyHandler) listAPIKeys(c *gin.Context, userID uuid.UUID) {
+	keys, err := h.user.ListAPIKeys(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) revokeAPIKey(c *gin.Context, userID uuid.UUID, rawKeyID string) (uuid.UUID, bool) {
+	keyID, err := uuid.Parse(rawKeyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return uuid.Nil, false
+	}
+	err = h.user.RevokeAPIKey(userID, keyID)
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return uuid.Nil, false
+	}
+	c.JSON(http.StatusNoContent, nil)
+	return keyID, true
+}
+
+// findUser loads the user from the :id path parameter of admin routes.
+func (h *APIKeyHandler) findUser(c *gin.Context) (*domain.User, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return nil, false
+	}
+	return findManagedUser(c, h.user, id)
+}
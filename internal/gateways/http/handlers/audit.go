@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"diplom/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log"
+)
+
+const (
+	auditEntityPhrase     = "phrase"
+	auditEntityPhraseType = "phrase_type"
+	auditEntityAnswer     = "answer"
+	auditEntityUser       = "user"
+	auditEntityAPIKey     = "api_key"
+	auditEntityOrg        = "organization"
+	auditEntityVoice      = "voice"
+)
+
+// recordAudit writes an audit entry for the current caller. The action has already been applied
+// at this point, so a failure to record it is logged rather than returned to the client.
+func recordAudit(c *gin.Context, audit *services.AuditService, action string, entityType string, entityID uuid.UUID,
+	before interface{}, after interface{}) {
+	if err := audit.Record(CurrentUser(c), c.ClientIP(), action, entityType, entityID, before, after); err != nil {
+		log.Printf("can't record audit entry %s for %s %s: %v", action, entityType, entityID, err)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	audit *services.AuditService
+}
+
+func NewAuditHandler(a *services.AuditService) *AuditHandler {
+	return &AuditHandler{audit: a}
+}
+
+// GetAuditLog godoc
+// @Summary Журнал аудита
+// @Description Возвращает действия администраторов организации над фразами, типами фраз, ответами и пользователями, новые сверху
+// @Tags audit
+// @Produce json
+// @Param actor_id query string false "ID пользователя, выполнившего действие" Format(uuid)
+// @Param action query string false "Действие, например phrase.delete"
+// @Param entity_type query string false "Тип сущности: phrase, phrase_type, answer, user, api_key"
+// @Param entity_id query string false "ID сущности" Format(uuid)
+// @Param from query string false "Начало периода (RFC 3339)"
+// @Param to query string false "Конец периода, не включительно (RFC 3339)"
+// @Param page query int false "Номер страницы, начиная с 1"
+// @Param page_size query int false "Размер страницы (не больше 100)"
+// @Success 200 {object} models.AuditLogResponse
+// @Failure 400 {object} map[string]string "Неверный фильтр"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	filter := domain.AuditFilter{OrganizationID: CurrentOrganizationID(c), Action: c.Query("action"), EntityType: c.Query("entity_type")}
+	var ok bool
+	if filter.ActorID, ok = optionalUUID(c, "actor_id"); !ok {
+		return
+	}
+	if filter.EntityID, ok = optionalUUID(c, "entity_id"); !ok {
+		return
+	}
+	if filter.From, ok = optionalTime(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = optionalTime(c, "to"); !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	result, err := h.audit.Query(filter, page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, models.AuditLogResponse{Items: result.Entries, Total: result.Total, Page: result.Page, PageSize: result.PageSize})
+}
+
+func optionalUUID(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return nil, false
+	}
+	return &id, true
+}
+
+func optionalTime(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an RFC 3339 timestamp"})
+		return nil, false
+	}
+	return &t, true
+}
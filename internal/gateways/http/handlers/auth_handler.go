@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type AuthHandler struct {
+	auth  *services.AuthService
+	user  *services.UserService
+	audit *services.AuditService
+}
+
+func NewAuthHandler(a *services.AuthService, u *services.UserService, audit *services.AuditService) *AuthHandler {
+	return &AuthHandler{auth: a, user: u, audit: audit}
+}
+
+// RefreshToken godoc
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен на новую пару токенов. Повторное использование старого refresh-токена завершает сессию
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.RefreshTokenRequest true "Refresh-токен"
+// @Success 200 {object} models.TokenResponse
+// @Failure 400 {object} map[string]string "Неверный формат запроса"
+// @Failure 401 {object} map[string]string "Токен недействителен"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var request models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := h.auth.Refresh(request.RefreshToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, newTokenResponse(tokens))
+}
+
+// Logout godoc
+// @Summary Выход из системы
+// @Description Завершает текущую сессию пользователя
+// @Tags auth
+// @Produce json
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if err := h.auth.RevokeSession(CurrentUser(c).ID, CurrentSessionID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetSessions godoc
+// @Summary Активные сессии
+// @Description Возвращает список активных сессий текущего пользователя
+// @Tags auth
+// @Produce json
+// @Success 200 {array} models.SessionResponse
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/sessions [get]
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	sessions, err := h.auth.GetActiveSessions(CurrentUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	currentID := CurrentSessionID(c)
+	result := make([]models.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		result = append(result, models.SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IP:         s.IP,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentID,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// RevokeSession godoc
+// @Summary Завершение сессии
+// @Description Завершает одну из сессий текущего пользователя
+// @Tags auth
+// @Produce json
+// @Param id path string true "ID сессии" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 404 {object} map[string]string "Сессия не найдена"
+// @Security BearerAuth
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	err = h.auth.RevokeSession(CurrentUser(c).ID, id)
+	if errors.Is(err, services.ErrSessionNotFound) || errors.Is(err, services.ErrSessionOwnerInvalid) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RevokeAllSessions godoc
+// @Summary Завершение всех сессий
+// @Description Завершает все сессии текущего пользователя, включая текущую
+// @Tags auth
+// @Produce json
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/sessions [delete]
+func (h *AuthHandler) RevokeAllSessions(c *gin.Context) {
+	if err := h.auth.RevokeAllSessions(CurrentUser(c).ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RevokeUserSessions godoc
+// @Summary Завершение всех сессий пользователя
+// @Description Завершает все сессии указанного пользователя, например после работы за общим компьютером
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Forbidden"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Router /admin/users/{id}/sessions [delete]
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	if _, ok := findManagedUser(c, h.user, id); !ok {
+		return
+	}
+	if err := h.auth.RevokeAllSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.revoke_sessions", auditEntityUser, id, nil, nil)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func newTokenResponse(tokens *services.Tokens) models.TokenResponse {
+	return models.TokenResponse{
+		AccessToken:      tokens.AccessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
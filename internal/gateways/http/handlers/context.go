@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"diplom/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	currentUserKey    = "current_user"
+	currentSessionKey = "current_session"
+)
+
+func SetCurrentUser(c *gin.Context, user *domain.User) {
+	c.Set(currentUserKey, user)
+}
+
+// CurrentUser returns the user resolved by the auth middleware, or nil on public routes.
+func CurrentUser(c *gin.Context) *domain.User {
+	value, ok := c.Get(currentUserKey)
+	if !ok {
+		return nil
+	}
+	user, _ := value.(*domain.User)
+	return user
+}
+
+// CurrentOrganizationID returns the tenant of the caller. Every content query is scoped to it.
+func CurrentOrganizationID(c *gin.Context) uuid.UUID {
+	user := CurrentUser(c)
+	if user == nil {
+		return uuid.Nil
+	}
+	return user.OrganizationID
+}
+
+func SetCurrentSession(c *gin.Context, sessionID uuid.UUID) {
+	c.Set(currentSessionKey, sessionID)
+}
+
+func CurrentSessionID(c *gin.Context) uuid.UUID {
+	value, ok := c.Get(currentSessionKey)
+	if !ok {
+		return uuid.Nil
+	}
+	sessionID, _ := value.(uuid.UUID)
+	return sessionID
+}
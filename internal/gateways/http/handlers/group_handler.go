@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type GroupHandler struct {
+	groupService *services.GroupService
+}
+
+func NewGroupHandler(s *services.GroupService) *GroupHandler {
+	return &GroupHandler{groupService: s}
+}
+
+// CreateGroup godoc
+// @Summary      Create a group
+// @Description  Creates a class owned by the authenticated teacher
+// @Tags         groups
+// @Accept       json
+// @Produce      json
+// @Param        group  body      models.CreateGroupRequest  true  "Group data"
+// @Success      201    {object}  string                     "Created group ID"
+// @Failure      400    {object}  map[string]string          "Invalid input"
+// @Failure      401    {object}  map[string]string          "Unauthorized"
+// @Failure      403    {object}  map[string]string          "Forbidden"
+// @Failure      500    {object}  map[string]string          "Internal server error"
+// @Security     BearerAuth
+// @Router       /teacher/groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var newGroup models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&newGroup); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := h.groupService.CreateGroup(CurrentUser(c), newGroup.Title)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, id)
+}
+
+// GetGroups godoc
+// @Summary      Get teacher groups
+// @Description  Returns the groups owned by the authenticated teacher
+// @Tags         groups
+// @Produce      json
+// @Success      200  {array}   domain.Group
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /teacher/groups [get]
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	groups, err := h.groupService.GetTeacherGroups(CurrentUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetGroup godoc
+// @Summary      Get a group
+// @Description  Returns a group with its enrolled students
+// @Tags         groups
+// @Produce      json
+// @Param        id   path      string  true  "Group ID" Format(uuid)
+// @Success      200  {object}  models.GroupResponse
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id} [get]
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	group, err := h.groupService.GetGroup(CurrentUser(c), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	members, err := h.groupService.GetMembers(CurrentUser(c), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.GroupResponse{Group: *group, Members: members})
+}
+
+// DeleteGroup godoc
+// @Summary      Delete a group
+// @Description  Deletes a group. Students stay in the system
+// @Tags         groups
+// @Produce      json
+// @Param        id   path      string  true  "Group ID" Format(uuid)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id} [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	if err := h.groupService.DeleteGroup(CurrentUser(c), id); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// EnrollStudent godoc
+// @Summary      Enroll a student
+// @Description  Adds a student to the group
+// @Tags         groups
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                       true  "Group ID" Format(uuid)
+// @Param        student  body      models.EnrollStudentRequest  true  "Student to enroll"
+// @Success      204      {string}  string  "No Content"
+// @Failure      400      {object}  map[string]string  "Invalid input"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Group or student not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id}/students [post]
+func (h *GroupHandler) EnrollStudent(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	var request models.EnrollStudentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	studentID, err := uuid.Parse(request.UserID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if err := h.groupService.EnrollStudent(CurrentUser(c), id, studentID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// RemoveStudent godoc
+// @Summary      Remove a student
+// @Description  Removes a student from the group
+// @Tags         groups
+// @Produce      json
+// @Param        id       path      string  true  "Group ID" Format(uuid)
+// @Param        user_id  path      string  true  "Student ID" Format(uuid)
+// @Success      204      {string}  string  "No Content"
+// @Failure      400      {object}  map[string]string  "Invalid ID"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
+// @Failure      403      {object}  map[string]string  "Forbidden"
+// @Failure      404      {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id}/students/{user_id} [delete]
+func (h *GroupHandler) RemoveStudent(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	studentID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if err := h.groupService.RemoveStudent(CurrentUser(c), id, studentID); err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetGroupPhrases godoc
+// @Summary      Get group phrases
+// @Description  Returns the phrases of every student in the group
+// @Tags         groups
+// @Produce      json
+// @Param        id   path      string  true  "Group ID" Format(uuid)
+// @Success      200  {array}   models.StudentPhrases
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id}/phrases [get]
+func (h *GroupHandler) GetGroupPhrases(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	rows, err := h.groupService.GetGroupPhrases(CurrentUser(c), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	result := []*models.StudentPhrases{}
+	byStudent := map[string]*models.StudentPhrases{}
+	for _, row := range rows {
+		student, ok := byStudent[row[0]]
+		if !ok {
+			student = &models.StudentPhrases{UserID: uuid.MustParse(row[0]), Name: row[1]}
+			byStudent[row[0]] = student
+			result = append(result, student)
+		}
+		student.Phrases = append(student.Phrases, row[2])
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGroupProgress godoc
+// @Summary      Get group progress
+// @Description  Returns phrase practice progress of every student in the group
+// @Tags         groups
+// @Produce      json
+// @Param        id   path      string  true  "Group ID" Format(uuid)
+// @Success      200  {array}   models.StudentProgress
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id}/progress [get]
+func (h *GroupHandler) GetGroupProgress(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	rows, err := h.groupService.GetGroupProgress(CurrentUser(c), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	result := []*models.StudentProgress{}
+	byStudent := map[string]*models.StudentProgress{}
+	for _, row := range rows {
+		student, ok := byStudent[row[0]]
+		if !ok {
+			student = &models.StudentProgress{UserID: uuid.MustParse(row[0]), Name: row[1]}
+			byStudent[row[0]] = student
+			result = append(result, student)
+		}
+		student.Progress = append(student.Progress, &models.PhraseProgress{
+			Phrase:             row[2],
+			PhraseStreamStatus: row[3],
+			ScenarioStatus:     row[4],
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGroupAnswers godoc
+// @Summary      Get group answers
+// @Description  Returns the answers of the students in the group
+// @Tags         groups
+// @Produce      json
+// @Param        id   path      string  true  "Group ID" Format(uuid)
+// @Success      200  {array}   domain.Answer
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Group not found"
+// @Security     BearerAuth
+// @Router       /teacher/groups/{id}/answers [get]
+func (h *GroupHandler) GetGroupAnswers(c *gin.Context) {
+	id, ok := parseGroupID(c)
+	if !ok {
+		return
+	}
+	answers, err := h.groupService.GetGroupAnswers(CurrentUser(c), id)
+	if err != nil {
+		writeGroupError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, answers)
+}
+
+func parseGroupID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeGroupError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrGroupNotFound), errors.Is(err, services.ErrStudentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrGroupAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotStudent):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
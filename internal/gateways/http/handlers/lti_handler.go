@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/lti"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+type LTIHandler struct {
+	lti  *services.LTIService
+	auth *services.AuthService
+}
+
+func NewLTIHandler(l *services.LTIService, a *services.AuthService) *LTIHandler {
+	return &LTIHandler{lti: l, auth: a}
+}
+
+// Login godoc
+// @Summary Вход через LTI 1.3
+// @Description Обрабатывает вход, инициированный платформой (Moodle), и перенаправляет на ее страницу аутентификации. Параметры принимаются в запросе или в форме
+// @Tags lti
+// @Param iss query string true "Issuer платформы"
+// @Param login_hint query string true "Подсказка для входа"
+// @Param target_link_uri query string false "Адрес запуска"
+// @Param lti_message_hint query string false "Подсказка сообщения LTI"
+// @Param client_id query string false "Client ID инструмента"
+// @Param lti_deployment_id query string false "ID развертывания"
+// @Success 302 {string} string "Редирект на платформу"
+// @Failure 400 {object} map[string]string "Неизвестная платформа или неверный запрос"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /lti/login [get]
+// @Router /lti/login [post]
+func (h *LTIHandler) Login(c *gin.Context) {
+	url, err := h.lti.BeginLogin(lti.LoginRequest{
+		Issuer:          c.Request.FormValue("iss"),
+		LoginHint:       c.Request.FormValue("login_hint"),
+		TargetLinkURI:   c.Request.FormValue("target_link_uri"),
+		LTIMessageHint:  c.Request.FormValue("lti_message_hint"),
+		ClientID:        c.Request.FormValue("client_id"),
+		LTIDeploymentID: c.Request.FormValue("lti_deployment_id"),
+	})
+	switch {
+	case errors.Is(err, lti.ErrUnknownIssuer), errors.Is(err, lti.ErrInvalidLaunch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// Launch godoc
+// @Summary Запуск LTI 1.3
+// @Description Проверяет токен запуска, выданный платформой, и выдает токены сессии. При первом запуске создается пользователь, студенты зачисляются в группу курса
+// @Tags lti
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param id_token formData string true "Токен запуска"
+// @Param state formData string true "State из запроса аутентификации"
+// @Success 200 {object} models.LoginUserResponse
+// @Failure 400 {object} map[string]string "Неизвестный или просроченный запуск"
+// @Failure 401 {object} map[string]string "Платформа отклонила вход или токен запуска недействителен"
+// @Failure 403 {object} map[string]string "Учетная запись отключена"
+// @Failure 409 {object} map[string]string "Локальная учетная запись с таким логином уже существует"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /lti/launch [post]
+func (h *LTIHandler) Launch(c *gin.Context) {
+	if platformError := c.PostForm("error"); platformError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": platformError, "description": c.PostForm("error_description")})
+		return
+	}
+	user, err := h.lti.CompleteLaunch(c.PostForm("state"), c.PostForm("id_token"))
+	switch {
+	case errors.Is(err, services.ErrLTIStateInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, lti.ErrInvalidLaunch):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrUserInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrLTILoginConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("LTI launch failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "LTI launch failed"})
+		return
+	}
+	tokens, err := h.auth.StartSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	c.JSON(http.StatusOK, models.LoginUserResponse{
+		TokenResponse: newTokenResponse(tokens),
+		User:          newUserResponse(user),
+	})
+}
+
+// KeySet godoc
+// @Summary Ключи инструмента LTI
+// @Description Публичный набор ключей (JWKS), которым платформа проверяет запросы инструмента к сервису оценок
+// @Tags lti
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /lti/jwks [get]
+func (h *LTIHandler) KeySet(c *gin.Context) {
+	c.JSON(http.StatusOK, h.lti.KeySet())
+}
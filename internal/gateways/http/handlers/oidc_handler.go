@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"net/http"
+)
+
+type OIDCHandler struct {
+	oidc *services.OIDCService
+	auth *services.AuthService
+}
+
+func NewOIDCHandler(o *services.OIDCService, a *services.AuthService) *OIDCHandler {
+	return &OIDCHandler{oidc: o, auth: a}
+}
+
+// Login godoc
+// @Summary Вход через OpenID Connect
+// @Description Перенаправляет на страницу входа провайдера удостоверений (authorization code flow с PKCE)
+// @Tags auth
+// @Success 302 {string} string "Редирект на провайдера"
+// @Failure 502 {object} map[string]string "Провайдер недоступен"
+// @Router /auth/oidc/login [get]
+func (h *OIDCHandler) Login(c *gin.Context) {
+	url, err := h.oidc.BeginLogin()
+	if err != nil {
+		log.Printf("can't start OIDC login: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider is unavailable"})
+		return
+	}
+	c.Redirect(http.StatusFound, url)
+}
+
+// Callback godoc
+// @Summary Завершение входа через OpenID Connect
+// @Description Обменивает код авторизации на ID-токен, проверяет его и выдает токены сессии. Пользователь создается при первом входе
+// @Tags auth
+// @Produce json
+// @Param state query string true "State из запроса авторизации"
+// @Param code query string true "Код авторизации"
+// @Success 200 {object} models.LoginUserResponse
+// @Failure 400 {object} map[string]string "Неизвестный или просроченный запрос входа"
+// @Failure 401 {object} map[string]string "Провайдер отклонил вход или ID-токен недействителен"
+// @Failure 403 {object} map[string]string "Учетная запись отключена"
+// @Failure 409 {object} map[string]string "Локальная учетная запись с таким логином уже существует"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /auth/oidc/callback [get]
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": providerError, "description": c.Query("error_description")})
+		return
+	}
+	user, err := h.oidc.CompleteLogin(c.Query("state"), c.Query("code"))
+	switch {
+	case errors.Is(err, services.ErrOIDCStateInvalid):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrUserInactive):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrOIDCLoginConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("OIDC login failed: %v", err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login with the identity provider failed"})
+		return
+	}
+	tokens, err := h.auth.StartSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	c.JSON(http.StatusOK, models.LoginUserResponse{
+		TokenResponse: newTokenResponse(tokens),
+		User:          newUserResponse(user),
+	})
+}
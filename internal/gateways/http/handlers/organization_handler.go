@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/repository"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type OrganizationHandler struct {
+	orgs  *services.OrganizationService
+	user  *services.UserService
+	audit *services.AuditService
+}
+
+func NewOrganizationHandler(o *services.OrganizationService, u *services.UserService, a *services.AuditService) *OrganizationHandler {
+	return &OrganizationHandler{orgs: o, user: u, audit: a}
+}
+
+// CreateOrganization godoc
+// @Summary Создание организации
+// @Description Создает новую организацию (арендатора). Slug используется при регистрации пользователей
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param input body models.CreateOrganizationRequest true "Название и slug"
+// @Success 201 {object} domain.Organization
+// @Failure 400 {object} map[string]string "Неверный формат данных или slug"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 409 {object} map[string]string "Slug уже занят"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/organizations [post]
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var request models.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	org, err := h.orgs.CreateOrganization(request.Name, request.Slug)
+	switch {
+	case errors.Is(err, services.ErrInvalidSlug):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, repository.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "organization.create", auditEntityOrg, org.ID, nil, org)
+	c.JSON(http.StatusCreated, org)
+}
+
+// GetOrganizations godoc
+// @Summary Список организаций
+// @Description Возвращает все организации
+// @Tags organizations
+// @Produce json
+// @Success 200 {array} domain.Organization
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/organizations [get]
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	orgs, err := h.orgs.ListOrganizations()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganization godoc
+// @Summary Получение организации
+// @Description Возвращает организацию по ID
+// @Tags organizations
+// @Produce json
+// @Param id path string true "ID организации" Format(uuid)
+// @Success 200 {object} domain.Organization
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Организация не найдена"
+// @Security BearerAuth
+// @Router /admin/organizations/{id} [get]
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	id, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	org, err := h.orgs.GetOrganization(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// UpdateOrganization godoc
+// @Summary Переименование организации
+// @Description Изменяет название организации. Slug не изменяется
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "ID организации" Format(uuid)
+// @Param input body models.UpdateOrganizationRequest true "Новое название"
+// @Success 200 {object} domain.Organization
+// @Failure 400 {object} map[string]string "Неверный формат данных"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Организация не найдена"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/organizations/{id} [patch]
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	id, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	var request models.UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before, err := h.orgs.GetOrganization(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	org, err := h.orgs.RenameOrganization(id, request.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "organization.update", auditEntityOrg, id, before, org)
+	c.JSON(http.StatusOK, org)
+}
+
+// DeleteOrganization godoc
+// @Summary Удаление организации
+// @Description Удаляет пустую организацию. Организацию default удалить нельзя
+// @Tags organizations
+// @Produce json
+// @Param id path string true "ID организации" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID или организация default"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Организация не найдена"
+// @Failure 409 {object} map[string]string "В организации остались пользователи или контент"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/organizations/{id} [delete]
+func (h *OrganizationHandler) DeleteOrganization(c *gin.Context) {
+	id, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	before, err := h.orgs.GetOrganization(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	err = h.orgs.DeleteOrganization(id)
+	switch {
+	case errors.Is(err, services.ErrDefaultOrganization):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, services.ErrOrganizationNotEmpty):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "organization.delete", auditEntityOrg, id, before, nil)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// CreateOrganizationAdmin godoc
+// @Summary Создание администратора организации
+// @Description Создает пользователя с ролью admin в указанной организации, например первого администратора новой школы
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Param id path string true "ID организации" Format(uuid)
+// @Param input body models.CreateOrganizationAdminRequest true "Данные администратора"
+// @Success 201 {string} string "ID созданного пользователя"
+// @Failure 400 {object} map[string]string "Неверный формат данных или слабый пароль"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Организация не найдена"
+// @Failure 409 {object} map[string]string "Логин уже занят"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/organizations/{id}/admins [post]
+func (h *OrganizationHandler) CreateOrganizationAdmin(c *gin.Context) {
+	id, ok := parseOrganizationID(c)
+	if !ok {
+		return
+	}
+	var request models.CreateOrganizationAdminRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := h.orgs.GetOrganization(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := h.user.CreateOrganizationAdmin(id, request.Name, request.Login, request.Password)
+	var policyErr *services.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Error(), "violations": policyErr.Violations})
+		return
+	case errors.Is(err, repository.ErrLoginTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if user, err := h.user.GetUserByID(userID); err == nil {
+		recordAudit(c, h.audit, "organization.create_admin", auditEntityUser, userID, nil, newUserResponse(user))
+	}
+	c.JSON(http.StatusCreated, userID)
+}
+
+func parseOrganizationID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+type PasswordHandler struct {
+	password *services.PasswordService
+	throttle *services.LoginThrottler
+}
+
+// NewPasswordHandler creates the handler. Reset requests are counted by t like failed logins, so it must
+// not be the throttler of the login endpoint.
+func NewPasswordHandler(p *services.PasswordService, t *services.LoginThrottler) *PasswordHandler {
+	return &PasswordHandler{password: p, throttle: t}
+}
+
+// ChangePassword godoc
+// @Summary Смена пароля
+// @Description Меняет пароль текущего пользователя. Требует текущий пароль
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный текущий пароль или слабый новый пароль"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/password [post]
+func (h *PasswordHandler) ChangePassword(c *gin.Context) {
+	var request models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := h.password.ChangePassword(CurrentUser(c).ID, request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		writePasswordError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ForgotPassword godoc
+// @Summary Запрос сброса пароля
+// @Description Отправляет ссылку для сброса пароля на адрес, указанный в логине. Ответ не зависит от того, существует ли пользователь
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ForgotPasswordRequest true "Логин"
+// @Success 202 {string} string "Accepted"
+// @Failure 400 {object} map[string]string "Неверный формат запроса"
+// @Failure 429 {object} map[string]string "Слишком много запросов сброса пароля"
+// @Router /auth/password/forgot [post]
+func (h *PasswordHandler) ForgotPassword(c *gin.Context) {
+	var request models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if wait, ok := h.throttle.Allow(request.Login, c.ClientIP()); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Too many password reset requests, try again later"})
+		return
+	}
+	h.throttle.Failure(request.Login, c.ClientIP())
+	// The reset is requested after responding and its errors are only logged, so neither the response nor its
+	// timing depends on whether the login exists.
+	go func(login string) {
+		if err := h.password.RequestReset(login); err != nil {
+			log.Printf("can't request password reset: %v", err)
+		}
+	}(request.Login)
+	c.JSON(http.StatusAccepted, nil)
+}
+
+// ResetPassword godoc
+// @Summary Сброс пароля
+// @Description Устанавливает новый пароль по одноразовому токену из письма и завершает все сессии пользователя
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body models.ResetPasswordRequest true "Токен и новый пароль"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Токен недействителен или пароль слишком слабый"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Router /auth/password/reset [post]
+func (h *PasswordHandler) ResetPassword(c *gin.Context) {
+	var request models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.password.ResetPassword(request.Token, request.NewPassword); err != nil {
+		writePasswordError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func writePasswordError(c *gin.Context, err error) {
+	var policyErr *services.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Error(), "violations": policyErr.Violations})
+	case errors.Is(err, services.ErrWrongPassword), errors.Is(err, services.ErrInvalidResetToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -3,7 +3,10 @@ package handlers
 import (
 	"diplom/internal/domain"
 	"diplom/internal/gateways/http/models"
+	"diplom/internal/repository"
 	"diplom/internal/services"
+	"diplom/internal/ssml"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -12,22 +15,28 @@ import (
 type PhraseHandler struct {
 	phraseService *services.PhraseService
 	user          *services.UserService
+	audit         *services.AuditService
 }
 
-func NewPhraseHandler(s *services.PhraseService, u *services.UserService) *PhraseHandler {
-	return &PhraseHandler{phraseService: s, user: u}
+func NewPhraseHandler(s *services.PhraseService, u *services.UserService, a *services.AuditService) *PhraseHandler {
+	return &PhraseHandler{phraseService: s, user: u, audit: a}
 }
 
 // CreatePhrase godoc
 // @Summary      Create a new phrase
-// @Description  Adds a new phrase to the system
+// @Description  Adds a new phrase to the system. The optional markup is SSML wrapped in <speak> or the
+// @Description  simple markup with [pause 500ms], *emphasis* and [rate 0.8]...[/rate]; the text is then
+// @Description  taken from the words of the markup.
 // @Tags         phrases
 // @Accept       json
 // @Produce      json
 // @Param        phrase  body      models.CreatePhraseRequest  true  "New Phrase"
 // @Success      201     {object}  string                       "Created ID"
-// @Failure      400     {object}  map[string]string
+// @Failure      400     {object}  map[string]string            "Invalid input, unknown phrase type or invalid markup"
 // @Failure      500     {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/phrases [post]
 func (h *PhraseHandler) CreatePhrase(c *gin.Context) {
 	var newPhrase models.CreatePhraseRequest
@@ -35,14 +44,23 @@ func (h *PhraseHandler) CreatePhrase(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	id, err := h.phraseService.CreatePhrase(&domain.Phrase{
-		Text:   newPhrase.Text,
-		TypeID: newPhrase.TypeID,
-	})
+	phrase := &domain.Phrase{
+		OrganizationID: CurrentOrganizationID(c),
+		Text:           newPhrase.Text,
+		Markup:         newPhrase.Markup,
+		TypeID:         newPhrase.TypeID,
+	}
+	id, err := h.phraseService.CreatePhrase(phrase)
+	if errors.Is(err, repository.ErrPhraseTypeNotFound) || errors.Is(err, ssml.ErrInvalidMarkup) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	phrase.ID = id
+	recordAudit(c, h.audit, "phrase.create", auditEntityPhrase, id, nil, phrase)
 	c.JSON(http.StatusCreated, id)
 }
 
@@ -55,6 +73,9 @@ func (h *PhraseHandler) CreatePhrase(c *gin.Context) {
 // @Success      200  {object}  domain.Phrase
 // @Failure      400  {object}  map[string]string
 // @Failure      404  {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/phrases/{id} [get]
 func (h *PhraseHandler) GetPhrase(c *gin.Context) {
 	phraseID := c.Param("id")
@@ -63,7 +84,7 @@ func (h *PhraseHandler) GetPhrase(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err})
 		return
 	}
-	phrase, err := h.phraseService.GetPhraseByID(id)
+	phrase, err := h.phraseService.GetPhraseByID(CurrentOrganizationID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase not found"})
 		return
@@ -80,8 +101,12 @@ func (h *PhraseHandler) GetPhrase(c *gin.Context) {
 // @Param        id      path      string          true  "Phrase ID" Format(uuid)
 // @Param        phrase  body      domain.Phrase   true  "Updated Phrase"
 // @Success      200     {object}  domain.Phrase
-// @Failure      400     {object}  map[string]string
+// @Failure      400     {object}  map[string]string  "Invalid input, unknown phrase type or invalid markup"
 // @Failure      500     {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Phrase not found"
+// @Security     BearerAuth
 // @Router       /admin/phrases/{id} [put]
 func (h *PhraseHandler) UpdatePhrase(c *gin.Context) {
 	phraseID := c.Param("id")
@@ -96,10 +121,22 @@ func (h *PhraseHandler) UpdatePhrase(c *gin.Context) {
 		return
 	}
 	updatedPhrase.ID = id
-	if err := h.phraseService.UpdatePhrase(&updatedPhrase); err != nil {
+	updatedPhrase.OrganizationID = CurrentOrganizationID(c)
+	before, err := h.phraseService.GetPhraseByID(CurrentOrganizationID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase not found"})
+		return
+	}
+	err = h.phraseService.UpdatePhrase(&updatedPhrase)
+	if errors.Is(err, repository.ErrPhraseTypeNotFound) || errors.Is(err, ssml.ErrInvalidMarkup) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, h.audit, "phrase.update", auditEntityPhrase, id, before, updatedPhrase)
 	c.JSON(http.StatusOK, updatedPhrase)
 }
 
@@ -112,6 +149,10 @@ func (h *PhraseHandler) UpdatePhrase(c *gin.Context) {
 // @Success      204  {string}  string  "No Content"
 // @Failure      400  {object}  map[string]string
 // @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Phrase not found"
+// @Security     BearerAuth
 // @Router       /admin/phrases/{id} [delete]
 func (h *PhraseHandler) DeletePhrase(c *gin.Context) {
 	phraseID := c.Param("id")
@@ -120,10 +161,16 @@ func (h *PhraseHandler) DeletePhrase(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	if err := h.phraseService.DeletePhrase(id); err != nil {
+	before, err := h.phraseService.GetPhraseByID(CurrentOrganizationID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase not found"})
+		return
+	}
+	if err := h.phraseService.DeletePhrase(CurrentOrganizationID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	recordAudit(c, h.audit, "phrase.delete", auditEntityPhrase, id, before, nil)
 	c.JSON(http.StatusNoContent, nil)
 }
 
@@ -136,10 +183,13 @@ func (h *PhraseHandler) DeletePhrase(c *gin.Context) {
 // @Param        text  query     string  false  "Поисковый текст"
 // @Success      200  {array}   domain.Phrase
 // @Failure      500  {object}  map[string]string
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/phrases [get]
 func (h *PhraseHandler) GetAllPhrases(c *gin.Context) {
 	text := c.Query("text")
-	phrases, err := h.phraseService.GetAllPhrases(text)
+	phrases, err := h.phraseService.GetAllPhrases(CurrentOrganizationID(c), text)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -4,6 +4,7 @@ import (
 	"diplom/internal/domain"
 	"diplom/internal/gateways/http/models"
 	"diplom/internal/services"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"net/http"
@@ -11,22 +12,29 @@ import (
 
 type PhraseStreamHandler struct {
 	phraseStreamService *services.PhraseStreamService
+	scenarioService     *services.ScenarioService
 }
 
-func NewPhraseStreamHandler(s *services.PhraseStreamService) *PhraseStreamHandler {
-	return &PhraseStreamHandler{phraseStreamService: s}
+func NewPhraseStreamHandler(s *services.PhraseStreamService, sc *services.ScenarioService) *PhraseStreamHandler {
+	return &PhraseStreamHandler{phraseStreamService: s, scenarioService: sc}
 }
 
 // CreatePhraseStream godoc
 // @Summary      Create a phrase stream
-// @Description  Initializes a new phrase stream and stores associated audio
+// @Description  Initializes a new phrase stream and synthesizes its audio into a new file of the server
 // @Tags         scenarios
 // @Accept       json
 // @Produce      json
 // @Param        phraseStream  body      models.CreatePhraseStreamRequest  true  "Phrase stream data"
 // @Success      201           {object}  string                             "Created phrase stream ID"
-// @Failure      400           {object}  map[string]string                  "Invalid input"
+// @Failure      400           {object}  map[string]string                  "Invalid input or unknown accent"
+// @Failure      401           {object}  map[string]string                  "Unauthorized"
+// @Failure      404           {object}  map[string]string                  "Scenario not found"
+// @Failure      429           {object}  map[string]string                  "Speech provider quota exceeded"
 // @Failure      500           {object}  map[string]string                  "Internal server error"
+// @Failure      502           {object}  map[string]string                  "Speech provider rejected the request"
+// @Failure      503           {object}  map[string]string                  "Speech provider is unavailable"
+// @Security     BearerAuth
 // @Router       /student/scenarios/phrase/listen [post]
 func (h *PhraseStreamHandler) CreatePhraseStream(c *gin.Context) {
 	var newPhraseStream models.CreatePhraseStreamRequest
@@ -35,17 +43,37 @@ func (h *PhraseStreamHandler) CreatePhraseStream(c *gin.Context) {
 		return
 	}
 	scenarioID, err := uuid.Parse(newPhraseStream.ScenarioID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid scenario ID"})
+		return
+	}
 	phraseID, err := uuid.Parse(newPhraseStream.PhraseID)
-	id, err := h.phraseStreamService.CreatePhraseStream(&domain.PhraseStream{
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phrase ID"})
+		return
+	}
+	// Answers to the stream count towards the scenario result, so only its owner may add phrases to it.
+	scenario, err := h.scenarioService.GetScenario(CurrentOrganizationID(c), scenarioID)
+	if err != nil || scenario.UserID != CurrentUser(c).ID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scenario not found"})
+		return
+	}
+	id, err := h.phraseStreamService.CreatePhraseStream(c.Request.Context(), CurrentOrganizationID(c), &domain.PhraseStream{
 		ScenarioID: scenarioID,
 		PhraseID:   phraseID,
 		Status:     "initialized",
 	}, &domain.AudioPhrase{
-		PathToAudio: newPhraseStream.Path,
-		PhraseID:    phraseID,
-		Accent:      newPhraseStream.Accent,
-		Noise:       newPhraseStream.Noise,
+		PhraseID: phraseID,
+		Accent:   newPhraseStream.Accent,
+		Noise:    newPhraseStream.Noise,
 	})
+	if errors.Is(err, services.ErrUnknownAccent) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if writeSpeechError(c, err) {
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -53,28 +81,18 @@ func (h *PhraseStreamHandler) CreatePhraseStream(c *gin.Context) {
 	c.JSON(http.StatusCreated, id)
 }
 
-func (h *PhraseStreamHandler) AddAccent(c *gin.Context) {
-
-}
-
 // GetPhrases godoc
 // @Summary      Get student phrases
-// @Description  Returns a list of phrases associated with the given user
+// @Description  Returns a list of phrases associated with the authenticated user
 // @Tags         progress
 // @Produce      json
-// @Param        user_id  path      string  true  "User ID" Format(uuid)
 // @Success      200      {array}   domain.Phrase
-// @Failure      400      {object}  map[string]string  "Invalid user ID"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
 // @Failure      500      {object}  map[string]string  "Internal server error"
-// @Router       /student/{user_id}/get_phrases [get]
+// @Security     BearerAuth
+// @Router       /student/get_phrases [get]
 func (h *PhraseStreamHandler) GetPhrases(c *gin.Context) {
-	userID := c.Param("user_id")
-	id, err := uuid.Parse(userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
-		return
-	}
-	phrases, err := h.phraseStreamService.GetStudentPhrases(id)
+	phrases, err := h.phraseStreamService.GetStudentPhrases(CurrentOrganizationID(c), CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -84,22 +102,16 @@ func (h *PhraseStreamHandler) GetPhrases(c *gin.Context) {
 
 // GetProgress godoc
 // @Summary      Get phrase progress
-// @Description  Returns progress data for the user's phrase practice
+// @Description  Returns progress data for the authenticated user's phrase practice
 // @Tags         progress
 // @Produce      json
-// @Param        user_id  path      string  true  "User ID" Format(uuid)
 // @Success      200      {array}   models.PhraseProgress
-// @Failure      400      {object}  map[string]string  "Invalid user ID"
+// @Failure      401      {object}  map[string]string  "Unauthorized"
 // @Failure      500      {object}  map[string]string  "Internal server error"
-// @Router       /student/{user_id}/phrase/get_progress [get]
+// @Security     BearerAuth
+// @Router       /student/phrase/get_progress [get]
 func (h *PhraseStreamHandler) GetProgress(c *gin.Context) {
-	userID := c.Param("user_id")
-	id, err := uuid.Parse(userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
-		return
-	}
-	phrases, err := h.phraseStreamService.GetStudentProgress(id)
+	phrases, err := h.phraseStreamService.GetStudentProgress(CurrentOrganizationID(c), CurrentUser(c).ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
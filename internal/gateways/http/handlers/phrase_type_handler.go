@@ -11,10 +11,11 @@ import (
 type PhraseTypeHandler struct {
 	phraseTypeService *services.PhraseTypeService
 	user              *services.UserService
+	audit             *services.AuditService
 }
 
-func NewPhraseTypeHandler(s *services.PhraseTypeService, u *services.UserService) *PhraseTypeHandler {
-	return &PhraseTypeHandler{phraseTypeService: s, user: u}
+func NewPhraseTypeHandler(s *services.PhraseTypeService, u *services.UserService, a *services.AuditService) *PhraseTypeHandler {
+	return &PhraseTypeHandler{phraseTypeService: s, user: u, audit: a}
 }
 
 // CreatePhraseType godoc
@@ -27,6 +28,9 @@ func NewPhraseTypeHandler(s *services.PhraseTypeService, u *services.UserService
 // @Success      201          {object}  int                 "ID of created phrase type"
 // @Failure      400          {object}  map[string]string   "Invalid input"
 // @Failure      500          {object}  map[string]string   "Internal error"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/phrase_types [post]
 func (h *PhraseTypeHandler) CreatePhraseType(c *gin.Context) {
 	var newPhraseType domain.PhraseType
@@ -34,11 +38,14 @@ func (h *PhraseTypeHandler) CreatePhraseType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	newPhraseType.OrganizationID = CurrentOrganizationID(c)
 	id, err := h.phraseTypeService.CreatePhraseType(&newPhraseType)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	newPhraseType.ID = id
+	recordAudit(c, h.audit, "phrase_type.create", auditEntityPhraseType, id, nil, newPhraseType)
 	c.JSON(http.StatusCreated, id)
 }
 
@@ -49,9 +56,12 @@ func (h *PhraseTypeHandler) CreatePhraseType(c *gin.Context) {
 // @Produce      json
 // @Success      200  {array}   domain.PhraseType
 // @Failure      500  {object}  map[string]string  "Internal error"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Security     BearerAuth
 // @Router       /admin/phrase_types [get]
 func (h *PhraseTypeHandler) GetAllPhraseTypes(c *gin.Context) {
-	phraseTypes, err := h.phraseTypeService.GetAllPhraseTypes()
+	phraseTypes, err := h.phraseTypeService.GetAllPhraseTypes(CurrentOrganizationID(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -66,7 +76,7 @@ func (h *PhraseTypeHandler) GetPhraseType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	phraseType, err := h.phraseTypeService.GetPhraseTypeByID(id)
+	phraseType, err := h.phraseTypeService.GetPhraseTypeByID(CurrentOrganizationID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Phrase type not found"})
 		return
@@ -87,6 +97,7 @@ func (h *PhraseTypeHandler) UpdatePhraseType(c *gin.Context) {
 		return
 	}
 	updatedPhraseType.ID = id
+	updatedPhraseType.OrganizationID = CurrentOrganizationID(c)
 	if err := h.phraseTypeService.UpdatePhraseType(&updatedPhraseType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -101,7 +112,7 @@ func (h *PhraseTypeHandler) DeletePhraseType(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	if err := h.phraseTypeService.DeletePhraseType(id); err != nil {
+	if err := h.phraseTypeService.DeletePhraseType(CurrentOrganizationID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
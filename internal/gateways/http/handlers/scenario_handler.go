@@ -27,7 +27,9 @@ func NewScenarioHandler(s *services.ScenarioService) *ScenarioHandler {
 // @Param        scenario  body      models.CreateScenarioRequest  true  "Scenario data"
 // @Success      201       {object}  string                        "Created scenario ID"
 // @Failure      400       {object}  map[string]string             "Invalid input"
+// @Failure      401       {object}  map[string]string             "Unauthorized"
 // @Failure      500       {object}  map[string]string             "Internal server error"
+// @Security     BearerAuth
 // @Router       /student/scenarios/create [post]
 func (h *ScenarioHandler) CreateScenario(c *gin.Context) {
 	var newScenario models.CreateScenarioRequest
@@ -35,16 +37,13 @@ func (h *ScenarioHandler) CreateScenario(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	userID, err := uuid.Parse(newScenario.UserID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-	}
 	startDate := time.Now()
 	id, err := h.scenarioService.CreateScenario(&domain.Scenario{
-		Title:     newScenario.Title,
-		UserID:    userID,
-		StartDate: &startDate,
-		Status:    "in_progress",
+		OrganizationID: CurrentOrganizationID(c),
+		Title:          newScenario.Title,
+		UserID:         CurrentUser(c).ID,
+		StartDate:      &startDate,
+		Status:         "in_progress",
 	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -60,7 +59,7 @@ func (h *ScenarioHandler) GetScenario(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	scenario, err := h.scenarioService.GetScenario(id)
+	scenario, err := h.scenarioService.GetScenario(CurrentOrganizationID(c), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Scenario not found"})
 		return
@@ -75,7 +74,7 @@ func (h *ScenarioHandler) DeleteScenario(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
 		return
 	}
-	if err := h.scenarioService.DeleteScenario(id); err != nil {
+	if err := h.scenarioService.DeleteScenario(CurrentOrganizationID(c), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
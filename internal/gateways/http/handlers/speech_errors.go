@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"diplom/client"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// writeSpeechError answers a failure of the speech provider and reports whether err was one. Throttling
+// is passed on to the client, a rejected request or credentials are a bad gateway, an outage makes the
+// service unavailable.
+func writeSpeechError(c *gin.Context, err error) bool {
+	var providerErr *client.ProviderError
+	switch {
+	case errors.Is(err, client.ErrQuotaExceeded):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, client.ErrProviderUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, client.ErrUnauthorized), errors.As(err, &providerErr):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
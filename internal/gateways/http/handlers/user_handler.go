@@ -1,31 +1,43 @@
 package handlers
 
 import (
+	"bytes"
 	"diplom/internal/domain"
 	"diplom/internal/gateways/http/models"
+	"diplom/internal/repository"
 	"diplom/internal/services"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/google/uuid"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type UserHandler struct {
-	user *services.UserService
+	user     *services.UserService
+	auth     *services.AuthService
+	throttle *services.LoginThrottler
+	orgs     *services.OrganizationService
+	audit    *services.AuditService
 }
 
-func NewUserHandler(u *services.UserService) *UserHandler {
-	return &UserHandler{u}
+func NewUserHandler(u *services.UserService, a *services.AuthService, t *services.LoginThrottler, o *services.OrganizationService,
+	audit *services.AuditService) *UserHandler {
+	return &UserHandler{user: u, auth: a, throttle: t, orgs: o, audit: audit}
 }
 
 // RegisterUser godoc
 // @Summary Регистрация нового пользователя
-// @Description Создает нового пользователя в системе
+// @Description Создает нового пользователя-студента в организации с указанным slug (по умолчанию — в организации default)
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param input body models.CreateUserRequest true "Данные для регистрации"
 // @Success 201 {integer} integer "ID созданного пользователя"
-// @Failure 400 {object} object "Неверный формат данных"
+// @Failure 400 {object} object "Неверный формат данных, слабый пароль или неизвестная организация"
+// @Failure 409 {object} object "Логин уже занят"
 // @Failure 500 {object} object "Внутренняя ошибка сервера"
 // @Router /users/register [post]
 func (h *UserHandler) RegisterUser(c *gin.Context) {
@@ -34,40 +46,47 @@ func (h *UserHandler) RegisterUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newUser.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
-		return
+	orgID := domain.DefaultOrganizationID
+	if newUser.Organization != "" {
+		org, err := h.orgs.GetOrganizationBySlug(newUser.Organization)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		orgID = org.ID
 	}
-	userID, err := h.user.CreateUser(&domain.User{
-		Name:     newUser.Name,
-		Login:    newUser.Login,
-		Password: string(hashedPassword),
-		Role:     newUser.IsAdmin,
-	})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err})
+	userID, err := h.user.Register(orgID, newUser.Name, newUser.Login, newUser.Password)
+	var policyErr *services.PasswordPolicyError
+	switch {
+	case errors.As(err, &policyErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Error(), "violations": policyErr.Violations})
+		return
+	case errors.Is(err, repository.ErrLoginTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 	c.JSON(http.StatusCreated, userID)
 }
 
 // LoginUser godoc
 // @Summary Аутентификация пользователя
-// @Description Выполняет вход пользователя в систему и возвращает информацию об успешной аутентификации
+// @Description Выполняет вход пользователя в систему и возвращает подписанный токен доступа
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param credentials body models.LoginUserRequest true "Данные для входа (логин и пароль)"
-//
-//	@Success 200 {object} object "Успешная аутентификация" {
-//	   "message"="Login successful",
-//	   "user"="string"
-//	}
+// @Success 200 {object} models.LoginUserResponse "Успешная аутентификация"
 //
 //	@Failure 400 {object} object "Неверный формат запроса" {
 //	   "error"="string"
 //	}
 //
+// @Failure 403 {object} map[string]string "Учетная запись отключена"
+// @Failure 429 {object} map[string]string "Слишком много неудачных попыток входа"
+//
 //	@Failure 401 {object} object "Неверные учетные данные" {
 //	   "message"="Invalid credentials"
 //	}
@@ -79,12 +98,405 @@ func (h *UserHandler) LoginUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if wait, ok := h.throttle.Allow(loginUser.Login, c.ClientIP()); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"message": "Too many failed login attempts, try again later"})
+		return
+	}
 	user, err := h.user.Login(loginUser.Login, loginUser.Password)
-
+	if errors.Is(err, services.ErrUserInactive) {
+		c.JSON(http.StatusForbidden, gin.H{"message": err.Error()})
+		return
+	}
 	if err != nil {
+		h.throttle.Failure(loginUser.Login, c.ClientIP())
 		c.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid credentials"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Login successful", "user": user.Name})
+	h.throttle.Success(loginUser.Login)
+	tokens, err := h.auth.StartSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start session"})
+		return
+	}
+	c.JSON(http.StatusOK, models.LoginUserResponse{
+		TokenResponse: newTokenResponse(tokens),
+		User:          newUserResponse(user),
+	})
+}
+
+// SetUserRole godoc
+// @Summary Назначение роли пользователю
+// @Description Назначает пользователю своей организации одну из ролей: student, teacher, editor или admin. Роль superadmin может назначить только суперадминистратор
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Param input body models.SetRoleRequest true "Новая роль"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный формат данных"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/role [put]
+func (h *UserHandler) SetUserRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	var request models.SetRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !request.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrUnknownRole.Error()})
+		return
+	}
+	if id == CurrentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own role"})
+		return
+	}
+	if request.Role == domain.RoleSuperAdmin && !CurrentUser(c).HasPermission(domain.PermissionOrganizationsManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only a super-admin can grant the superadmin role"})
+		return
+	}
+	before, ok := findManagedUser(c, h.user, id)
+	if !ok {
+		return
+	}
+	if err := h.user.SetRole(id, request.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.set_role", auditEntityUser, id, newUserResponse(before), h.userSnapshot(id))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ListUsers godoc
+// @Summary Список пользователей
+// @Description Возвращает страницу пользователей своей организации с поиском по имени или логину и фильтром по роли
+// @Tags users
+// @Produce json
+// @Param search query string false "Поиск по имени или логину"
+// @Param role query string false "Роль"
+// @Param page query int false "Номер страницы, начиная с 1"
+// @Param page_size query int false "Размер страницы (не больше 100)"
+// @Success 200 {object} models.UserListResponse
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	result, err := h.user.ListUsers(CurrentOrganizationID(c), c.Query("search"), domain.Role(c.Query("role")), page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	items := make([]models.UserResponse, 0, len(result.Users))
+	for i := range result.Users {
+		items = append(items, newUserResponse(&result.Users[i]))
+	}
+	c.JSON(http.StatusOK, models.UserListResponse{Items: items, Total: result.Total, Page: result.Page, PageSize: result.PageSize})
+}
+
+// GetUser godoc
+// @Summary Получение пользователя
+// @Description Возвращает пользователя по ID
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Security BearerAuth
+// @Router /admin/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	user, err := h.user.GetOrganizationUser(CurrentOrganizationID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
 
+// UpdateUser godoc
+// @Summary Изменение пользователя
+// @Description Изменяет имя и/или логин пользователя. Пустые поля не изменяются
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Param input body models.UpdateUserRequest true "Новые данные"
+// @Success 200 {object} models.UserResponse
+// @Failure 400 {object} map[string]string "Неверный формат данных"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 409 {object} map[string]string "Логин уже занят"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id} [patch]
+func (h *UserHandler) UpdateUser(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	var request models.UpdateUserRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before, ok := findManagedUser(c, h.user, id)
+	if !ok {
+		return
+	}
+	user, err := h.user.UpdateProfile(id, request.Name, request.Login)
+	if errors.Is(err, repository.ErrLoginTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.update", auditEntityUser, id, newUserResponse(before), newUserResponse(user))
+	c.JSON(http.StatusOK, newUserResponse(user))
+}
+
+// DeactivateUser godoc
+// @Summary Отключение пользователя
+// @Description Отключает учетную запись и завершает все ее сессии
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/deactivate [post]
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	before, ok := h.parseManagedUser(c)
+	if !ok {
+		return
+	}
+	id := before.ID
+	if err := h.user.Deactivate(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.auth.RevokeAllSessions(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.deactivate", auditEntityUser, id, newUserResponse(before), h.userSnapshot(id))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ReactivateUser godoc
+// @Summary Включение пользователя
+// @Description Снова разрешает вход для отключенной учетной записи
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/reactivate [post]
+func (h *UserHandler) ReactivateUser(c *gin.Context) {
+	before, ok := h.parseManagedUser(c)
+	if !ok {
+		return
+	}
+	id := before.ID
+	if err := h.user.Reactivate(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.reactivate", auditEntityUser, id, newUserResponse(before), h.userSnapshot(id))
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// DeleteUser godoc
+// @Summary Удаление пользователя
+// @Description Удаляет пользователя вместе с его сценариями, ответами и аудиофайлами
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id} [delete]
+func (h *UserHandler) DeleteUser(c *gin.Context) {
+	before, ok := h.parseManagedUser(c)
+	if !ok {
+		return
+	}
+	id := before.ID
+	if err := h.user.DeleteUser(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// Only the ID is logged, a snapshot would keep the data of the deleted user in the log.
+	recordAudit(c, h.audit, "user.delete", auditEntityUser, id, nil, nil)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// ExportPersonalData godoc
+// @Summary Выгрузка персональных данных
+// @Description Возвращает ZIP-архив с профилем пользователя, его сценариями, потоками фраз, распознанными ответами и аудиозаписями ответов
+// @Tags users
+// @Produce application/zip
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 200 {file} file "ZIP-архив"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/personal_data [get]
+func (h *UserHandler) ExportPersonalData(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	user, ok := findManagedUser(c, h.user, id)
+	if !ok {
+		return
+	}
+	h.writePersonalData(c, user.ID)
+}
+
+// ExportOwnPersonalData godoc
+// @Summary Выгрузка своих персональных данных
+// @Description Возвращает ZIP-архив с персональными данными текущего пользователя, как и выгрузка администратором
+// @Tags users
+// @Produce application/zip
+// @Success 200 {file} file "ZIP-архив"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Требуется вход по паролю"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /auth/personal_data [get]
+func (h *UserHandler) ExportOwnPersonalData(c *gin.Context) {
+	h.writePersonalData(c, CurrentUser(c).ID)
+}
+
+func (h *UserHandler) writePersonalData(c *gin.Context, userID uuid.UUID) {
+	// The archive is built in memory so a failure can still be reported as an error response.
+	var archive bytes.Buffer
+	if err := h.user.ExportPersonalData(userID, &archive); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.export", auditEntityUser, userID, nil, nil)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="personal-data-%s.zip"`, userID))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// ErasePersonalData godoc
+// @Summary Удаление персональных данных
+// @Description Удаляет учетную запись пользователя вместе со сценариями, потоками фраз, ответами и аудиофайлами на диске. В отличие от удаления пользователя, также стирает его данные из журнала аудита: снимки пользователя и его ответов и его логин как автора действий
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя" Format(uuid)
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} map[string]string "Неверный ID"
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 403 {object} map[string]string "Недостаточно прав"
+// @Failure 404 {object} map[string]string "Пользователь не найден"
+// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Security BearerAuth
+// @Router /admin/users/{id}/personal_data [delete]
+func (h *UserHandler) ErasePersonalData(c *gin.Context) {
+	before, ok := h.parseManagedUser(c)
+	if !ok {
+		return
+	}
+	if err := h.user.DeleteUser(before.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.audit.RedactUser(before.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "user.erase", auditEntityUser, before.ID, nil, nil)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// parseManagedUser loads the target user and makes sure it is not the caller.
+func (h *UserHandler) parseManagedUser(c *gin.Context) (*domain.User, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return nil, false
+	}
+	if id == CurrentUser(c).ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't change your own account status"})
+		return nil, false
+	}
+	return findManagedUser(c, h.user, id)
+}
+
+// findManagedUser loads a user of the caller's organization for an administrative action.
+// Only super-admins may manage other super-admins.
+func findManagedUser(c *gin.Context, users *services.UserService, id uuid.UUID) (*domain.User, bool) {
+	user, err := users.GetOrganizationUser(CurrentOrganizationID(c), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if user.Role == domain.RoleSuperAdmin && !CurrentUser(c).HasPermission(domain.PermissionOrganizationsManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only a super-admin can manage a super-admin"})
+		return nil, false
+	}
+	return user, true
+}
+
+// userSnapshot returns the current state of the user for the audit log.
+func (h *UserHandler) userSnapshot(id uuid.UUID) interface{} {
+	user, err := h.user.GetUserByID(id)
+	if err != nil {
+		return nil
+	}
+	return newUserResponse(user)
+}
+
+func newUserResponse(user *domain.User) models.UserResponse {
+	return models.UserResponse{
+		ID:             user.ID,
+		OrganizationID: user.OrganizationID,
+		Name:           user.Name,
+		Login:          user.Login,
+		Role:           user.Role,
+		Permissions:    user.Permissions,
+		DeactivatedAt:  user.DeactivatedAt,
+	}
 }
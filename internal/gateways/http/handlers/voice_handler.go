@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/gateways/http/models"
+	"diplom/internal/repository"
+	"diplom/internal/services"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+)
+
+type VoiceHandler struct {
+	voiceService *services.VoiceService
+	audit        *services.AuditService
+}
+
+func NewVoiceHandler(s *services.VoiceService, a *services.AuditService) *VoiceHandler {
+	return &VoiceHandler{voiceService: s, audit: a}
+}
+
+// CreateVoice godoc
+// @Summary      Add a voice to the catalog
+// @Description  Maps an accent name to the voice, language, emotion and speed of the speech provider
+// @Tags         voices
+// @Accept       json
+// @Produce      json
+// @Param        voice  body      models.VoiceRequest  true  "New voice"
+// @Success      201    {object}  domain.Voice
+// @Failure      400    {object}  map[string]string  "Invalid input"
+// @Failure      401    {object}  map[string]string  "Unauthorized"
+// @Failure      403    {object}  map[string]string  "Forbidden"
+// @Failure      409    {object}  map[string]string  "Accent is already in the catalog"
+// @Failure      500    {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/voices [post]
+func (h *VoiceHandler) CreateVoice(c *gin.Context) {
+	var request models.VoiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	voice := newVoice(request)
+	voice.OrganizationID = CurrentOrganizationID(c)
+	id, err := h.voiceService.CreateVoice(voice)
+	if !h.handleError(c, err) {
+		return
+	}
+	voice.ID = id
+	recordAudit(c, h.audit, "voice.create", auditEntityVoice, id, nil, voice)
+	c.JSON(http.StatusCreated, voice)
+}
+
+// GetAllVoices godoc
+// @Summary      Get the voice catalog
+// @Description  Returns all voices of the organization ordered by accent
+// @Tags         voices
+// @Produce      json
+// @Success      200  {array}   domain.Voice
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/voices [get]
+func (h *VoiceHandler) GetAllVoices(c *gin.Context) {
+	voices, err := h.voiceService.ListVoices(CurrentOrganizationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, voices)
+}
+
+// UpdateVoice godoc
+// @Summary      Update a voice
+// @Description  Changes the provider settings of a voice or renames its accent
+// @Tags         voices
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string               true  "Voice ID" Format(uuid)
+// @Param        voice  body      models.VoiceRequest  true  "Updated voice"
+// @Success      200    {object}  domain.Voice
+// @Failure      400    {object}  map[string]string  "Invalid input"
+// @Failure      401    {object}  map[string]string  "Unauthorized"
+// @Failure      403    {object}  map[string]string  "Forbidden"
+// @Failure      404    {object}  map[string]string  "Voice not found"
+// @Failure      409    {object}  map[string]string  "Accent is already in the catalog"
+// @Failure      500    {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/voices/{id} [put]
+func (h *VoiceHandler) UpdateVoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	var request models.VoiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before, err := h.voiceService.GetVoice(CurrentOrganizationID(c), id)
+	if !h.handleError(c, err) {
+		return
+	}
+	voice := newVoice(request)
+	voice.ID = id
+	voice.OrganizationID = CurrentOrganizationID(c)
+	if !h.handleError(c, h.voiceService.UpdateVoice(voice)) {
+		return
+	}
+	recordAudit(c, h.audit, "voice.update", auditEntityVoice, id, before, voice)
+	c.JSON(http.StatusOK, voice)
+}
+
+// DeleteVoice godoc
+// @Summary      Delete a voice
+// @Description  Removes a voice from the catalog. Phrases already synthesized with it are kept
+// @Tags         voices
+// @Produce      json
+// @Param        id   path      string  true  "Voice ID" Format(uuid)
+// @Success      204  {string}  string  "No Content"
+// @Failure      400  {object}  map[string]string  "Invalid ID"
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      403  {object}  map[string]string  "Forbidden"
+// @Failure      404  {object}  map[string]string  "Voice not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /admin/voices/{id} [delete]
+func (h *VoiceHandler) DeleteVoice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ID"})
+		return
+	}
+	before, err := h.voiceService.GetVoice(CurrentOrganizationID(c), id)
+	if !h.handleError(c, err) {
+		return
+	}
+	if err := h.voiceService.DeleteVoice(CurrentOrganizationID(c), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	recordAudit(c, h.audit, "voice.delete", auditEntityVoice, id, before, nil)
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetAccents godoc
+// @Summary      Get available accents
+// @Description  Returns the accents a student can choose when listening to a phrase
+// @Tags         scenarios
+// @Produce      json
+// @Success      200  {array}   models.AccentResponse
+// @Failure      401  {object}  map[string]string  "Unauthorized"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Security     BearerAuth
+// @Router       /student/accents [get]
+func (h *VoiceHandler) GetAccents(c *gin.Context) {
+	voices, err := h.voiceService.ListVoices(CurrentOrganizationID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	accents := make([]models.AccentResponse, 0, len(voices))
+	for _, voice := range voices {
+		accents = append(accents, models.AccentResponse{Accent: voice.Accent, Language: voice.Language})
+	}
+	c.JSON(http.StatusOK, accents)
+}
+
+// handleError writes the response for a failed catalog operation and reports whether err was nil.
+func (h *VoiceHandler) handleError(c *gin.Context, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, services.ErrInvalidVoice):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrVoiceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Voice not found"})
+	case errors.Is(err, repository.ErrAccentTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return false
+}
+
+func newVoice(request models.VoiceRequest) *domain.Voice {
+	return &domain.Voice{
+		Accent:   request.Accent,
+		Voice:    request.Voice,
+		Language: request.Language,
+		Emotion:  request.Emotion,
+		Speed:    request.Speed,
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"diplom/internal/domain"
+	"time"
+)
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey domain.APIKey `json:"api_key"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "diplom/internal/domain"
+
+type AuditLogResponse struct {
+	Items    []domain.AuditEntry `json:"items"`
+	Total    int                 `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
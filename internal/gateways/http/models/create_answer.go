@@ -1,7 +1,7 @@
 package models
 
 type CreateAnswerRequest struct {
+	// Path of a recording the student has uploaded before, in the audio directory of the server.
 	Path           string `json:"path"`
-	UserID         string `json:"user_id"`
 	PhraseStreamID string `json:"phrase_stream_id"`
 }
@@ -1,9 +1,13 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+)
 
 type CreateAnswerResponse struct {
-	AnswerID  uuid.UUID `json:"answer_id"`
-	IsCorrect bool      `json:"is_correct"`
-	Text      string    `json:"text"`
+	AnswerID  uuid.UUID           `json:"answer_id"`
+	IsCorrect bool                `json:"is_correct"`
+	Text      string              `json:"text"`
+	Words     []domain.AnswerWord `json:"words"`
 }
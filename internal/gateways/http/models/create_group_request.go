@@ -0,0 +1,9 @@
+package models
+
+type CreateGroupRequest struct {
+	Title string `json:"title" binding:"required"`
+}
+
+type EnrollStudentRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
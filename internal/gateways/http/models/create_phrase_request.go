@@ -4,5 +4,6 @@ import "github.com/google/uuid"
 
 type CreatePhraseRequest struct {
 	Text   string    `json:"text"`
+	Markup string    `json:"markup"`
 	TypeID uuid.UUID `json:"type_id"`
 }
@@ -1,6 +1,5 @@
 package models
 
 type CreateScenarioRequest struct {
-	Title  string `json:"title"`
-	UserID string `json:"user_id"`
+	Title string `json:"title"`
 }
@@ -4,5 +4,6 @@ type CreateUserRequest struct {
 	Name     string `json:"name"`
 	Login    string `json:"login"`
 	Password string `json:"password"`
-	IsAdmin  bool   `json:"is_admin"`
+	// Organization is the slug of the organization to join, the default organization if empty.
+	Organization string `json:"organization"`
 }
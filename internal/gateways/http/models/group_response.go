@@ -0,0 +1,23 @@
+package models
+
+import (
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+)
+
+type GroupResponse struct {
+	domain.Group
+	Members []domain.GroupMember `json:"members"`
+}
+
+type StudentPhrases struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Name    string    `json:"name"`
+	Phrases []string  `json:"phrases"`
+}
+
+type StudentProgress struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Progress Progress  `json:"progress"`
+}
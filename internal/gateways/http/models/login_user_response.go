@@ -0,0 +1,30 @@
+package models
+
+import (
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+type LoginUserResponse struct {
+	TokenResponse
+	User UserResponse `json:"user"`
+}
+
+type TokenResponse struct {
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type UserResponse struct {
+	ID             uuid.UUID           `json:"id"`
+	OrganizationID uuid.UUID           `json:"organization_id"`
+	Name           string              `json:"name"`
+	Login          string              `json:"login"`
+	Role           domain.Role         `json:"role"`
+	Permissions    []domain.Permission `json:"permissions"`
+	DeactivatedAt  *time.Time          `json:"deactivated_at,omitempty"`
+}
@@ -0,0 +1,16 @@
+package models
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type CreateOrganizationAdminRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import "github.com/google/uuid"
+
+// RecognitionJobResponse is the state of the recognition of an answer: pending, done or failed. A done
+// job has the graded answer in Result, a failed one the reason in Error.
+type RecognitionJobResponse struct {
+	JobID  uuid.UUID             `json:"job_id"`
+	Status string                `json:"status"`
+	Error  string                `json:"error,omitempty"`
+	Result *CreateAnswerResponse `json:"result,omitempty"`
+}
@@ -0,0 +1,7 @@
+package models
+
+import "diplom/internal/domain"
+
+type SetRoleRequest struct {
+	Role domain.Role `json:"role" binding:"required"`
+}
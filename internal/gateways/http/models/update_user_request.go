@@ -0,0 +1,6 @@
+package models
+
+type UpdateUserRequest struct {
+	Name  string `json:"name"`
+	Login string `json:"login"`
+}
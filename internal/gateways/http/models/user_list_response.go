@@ -0,0 +1,8 @@
+package models
+
+type UserListResponse struct {
+	Items    []UserResponse `json:"items"`
+	Total    int            `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type VoiceRequest struct {
+	Accent   string  `json:"accent" binding:"required"`
+	Voice    string  `json:"voice" binding:"required"`
+	Language string  `json:"language" binding:"required"`
+	Emotion  string  `json:"emotion"`
+	Speed    float64 `json:"speed"`
+}
+
+type AccentResponse struct {
+	Accent   string `json:"accent"`
+	Language string `json:"language"`
+}
@@ -0,0 +1,64 @@
+package gateways
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/gateways/http/handlers"
+	"diplom/internal/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"strings"
+)
+
+// authMiddleware resolves the caller from the bearer token and stores it in the gin context.
+// The token is either a session access token or a personal API key.
+func authMiddleware(auth *services.AuthService, users *services.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+		if strings.HasPrefix(token, services.APIKeyPrefix) {
+			user, err := users.AuthenticateAPIKey(token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			handlers.SetCurrentUser(c, user)
+			c.Next()
+			return
+		}
+		user, sessionID, err := auth.Authenticate(token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		handlers.SetCurrentUser(c, user)
+		handlers.SetCurrentSession(c, sessionID)
+		c.Next()
+	}
+}
+
+// requirePermission rejects callers whose role does not grant the permission. It must run after authMiddleware.
+func requirePermission(permission domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := handlers.CurrentUser(c)
+		if user == nil || !user.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "permission " + string(permission) + " required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireSession rejects API keys on routes that manage the caller's own credentials.
+func requireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handlers.CurrentSessionID(c) == uuid.Nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this action requires a login session"})
+			return
+		}
+		c.Next()
+	}
+}
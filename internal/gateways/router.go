@@ -1,6 +1,7 @@
 package gateways
 
 import (
+	"diplom/internal/domain"
 	"diplom/internal/gateways/http/handlers"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,12 +15,19 @@ func setupRouter(r *gin.Engine, services Services) {
 	r.StaticFile("/swag/swagger.json", "./docs/swagger.json")
 	url := ginSwagger.URL("http://localhost:8080/swag/swagger.json")
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
-	userHandler := handlers.NewUserHandler(services.User)
-	phraseTypeHandler := handlers.NewPhraseTypeHandler(services.PhraseType, services.User)
-	phraseHandler := handlers.NewPhraseHandler(services.Phrase, services.User)
-	answerHandler := handlers.NewStudentAnswerHandler(services.Answer, services.User)
+	userHandler := handlers.NewUserHandler(services.User, services.Auth, services.Throttler, services.Organization, services.Audit)
+	authHandler := handlers.NewAuthHandler(services.Auth, services.User, services.Audit)
+	passwordHandler := handlers.NewPasswordHandler(services.Password, services.ResetThrottler)
+	apiKeyHandler := handlers.NewAPIKeyHandler(services.User, services.Audit)
+	phraseTypeHandler := handlers.NewPhraseTypeHandler(services.PhraseType, services.User, services.Audit)
+	phraseHandler := handlers.NewPhraseHandler(services.Phrase, services.User, services.Audit)
+	answerHandler := handlers.NewStudentAnswerHandler(services.Answer, services.RecognitionJobs, services.User, services.Audit)
 	scenarioHandler := handlers.NewScenarioHandler(services.Scenario)
-	phraseStreamHandler := handlers.NewPhraseStreamHandler(services.PhraseStream)
+	phraseStreamHandler := handlers.NewPhraseStreamHandler(services.PhraseStream, services.Scenario)
+	groupHandler := handlers.NewGroupHandler(services.Group)
+	auditHandler := handlers.NewAuditHandler(services.Audit)
+	organizationHandler := handlers.NewOrganizationHandler(services.Organization, services.User, services.Audit)
+	voiceHandler := handlers.NewVoiceHandler(services.Voice, services.Audit)
 
 	r.POST("/api/v1/users/register", func(c *gin.Context) {
 		userHandler.RegisterUser(c)
@@ -27,50 +35,228 @@ func setupRouter(r *gin.Engine, services Services) {
 	r.POST("/api/v1/auth/login", func(c *gin.Context) {
 		userHandler.LoginUser(c)
 	})
+	r.POST("/api/v1/auth/refresh", func(c *gin.Context) {
+		authHandler.RefreshToken(c)
+	})
+	if services.OIDC != nil {
+		oidcHandler := handlers.NewOIDCHandler(services.OIDC, services.Auth)
+		r.GET("/api/v1/auth/oidc/login", func(c *gin.Context) {
+			oidcHandler.Login(c)
+		})
+		r.GET("/api/v1/auth/oidc/callback", func(c *gin.Context) {
+			oidcHandler.Callback(c)
+		})
+	}
+	if services.LTI != nil {
+		ltiHandler := handlers.NewLTIHandler(services.LTI, services.Auth)
+		r.GET("/api/v1/lti/login", func(c *gin.Context) {
+			ltiHandler.Login(c)
+		})
+		r.POST("/api/v1/lti/login", func(c *gin.Context) {
+			ltiHandler.Login(c)
+		})
+		r.POST("/api/v1/lti/launch", func(c *gin.Context) {
+			ltiHandler.Launch(c)
+		})
+		r.GET("/api/v1/lti/jwks", func(c *gin.Context) {
+			ltiHandler.KeySet(c)
+		})
+	}
+	r.POST("/api/v1/auth/password/forgot", func(c *gin.Context) {
+		passwordHandler.ForgotPassword(c)
+	})
+	r.POST("/api/v1/auth/password/reset", func(c *gin.Context) {
+		passwordHandler.ResetPassword(c)
+	})
+
+	auth := r.Group("/api/v1/auth", authMiddleware(services.Auth, services.User), requireSession())
+	auth.POST("/logout", func(c *gin.Context) {
+		authHandler.Logout(c)
+	})
+	auth.POST("/password", func(c *gin.Context) {
+		passwordHandler.ChangePassword(c)
+	})
+	auth.POST("/api_keys", func(c *gin.Context) {
+		apiKeyHandler.CreateOwnAPIKey(c)
+	})
+	auth.GET("/api_keys", func(c *gin.Context) {
+		apiKeyHandler.GetOwnAPIKeys(c)
+	})
+	auth.DELETE("/api_keys/:id", func(c *gin.Context) {
+		apiKeyHandler.RevokeOwnAPIKey(c)
+	})
+	auth.GET("/personal_data", func(c *gin.Context) {
+		userHandler.ExportOwnPersonalData(c)
+	})
+	auth.GET("/sessions", func(c *gin.Context) {
+		authHandler.GetSessions(c)
+	})
+	auth.DELETE("/sessions", func(c *gin.Context) {
+		authHandler.RevokeAllSessions(c)
+	})
+	auth.DELETE("/sessions/:id", func(c *gin.Context) {
+		authHandler.RevokeSession(c)
+	})
 
-	r.POST("/api/v1/admin/phrases", func(c *gin.Context) {
+	admin := r.Group("/api/v1/admin", authMiddleware(services.Auth, services.User))
+	admin.POST("/phrases", requirePermission(domain.PermissionPhrasesWrite), func(c *gin.Context) {
 		phraseHandler.CreatePhrase(c)
 	})
-	r.GET("/api/v1/admin/phrases/:id", func(c *gin.Context) {
+	admin.GET("/phrases/:id", requirePermission(domain.PermissionPhrasesRead), func(c *gin.Context) {
 		phraseHandler.GetPhrase(c)
 	})
-	r.PUT("/api/v1/admin/phrases/:id", func(c *gin.Context) {
+	admin.PUT("/phrases/:id", requirePermission(domain.PermissionPhrasesWrite), func(c *gin.Context) {
 		phraseHandler.UpdatePhrase(c)
 	})
-	r.DELETE("/api/v1/admin/phrases/:id", func(c *gin.Context) {
+	admin.DELETE("/phrases/:id", requirePermission(domain.PermissionPhrasesWrite), func(c *gin.Context) {
 		phraseHandler.DeletePhrase(c)
 	})
-	r.GET("/api/v1/admin/phrases", func(c *gin.Context) {
+	admin.GET("/phrases", requirePermission(domain.PermissionPhrasesRead), func(c *gin.Context) {
 		phraseHandler.GetAllPhrases(c)
 	})
 
-	r.POST("/api/v1/admin/phrase_types", func(c *gin.Context) {
+	admin.POST("/phrase_types", requirePermission(domain.PermissionPhraseTypesWrite), func(c *gin.Context) {
 		phraseTypeHandler.CreatePhraseType(c)
 	})
-	r.GET("/api/v1/admin/phrase_types", func(c *gin.Context) {
+	admin.GET("/phrase_types", requirePermission(domain.PermissionPhraseTypesRead), func(c *gin.Context) {
 		phraseTypeHandler.GetAllPhraseTypes(c)
 	})
 
-	r.GET("/api/v1/admin/answers", func(c *gin.Context) {
+	admin.POST("/voices", requirePermission(domain.PermissionVoicesManage), func(c *gin.Context) {
+		voiceHandler.CreateVoice(c)
+	})
+	admin.GET("/voices", requirePermission(domain.PermissionVoicesManage), func(c *gin.Context) {
+		voiceHandler.GetAllVoices(c)
+	})
+	admin.PUT("/voices/:id", requirePermission(domain.PermissionVoicesManage), func(c *gin.Context) {
+		voiceHandler.UpdateVoice(c)
+	})
+	admin.DELETE("/voices/:id", requirePermission(domain.PermissionVoicesManage), func(c *gin.Context) {
+		voiceHandler.DeleteVoice(c)
+	})
+
+	admin.GET("/answers", requirePermission(domain.PermissionAnswersReadAll), func(c *gin.Context) {
 		answerHandler.GetAllAnswers(c)
 	})
-	r.DELETE("/api/v1/admin/answers/:id", func(c *gin.Context) {
+	admin.DELETE("/answers/:id", requirePermission(domain.PermissionAnswersDelete), func(c *gin.Context) {
 		answerHandler.DeleteAnswer(c)
 	})
 
-	r.POST("/api/v1/student/scenarios/create", func(c *gin.Context) {
+	admin.GET("/users", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.ListUsers(c)
+	})
+	admin.GET("/users/:id", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.GetUser(c)
+	})
+	admin.PATCH("/users/:id", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.UpdateUser(c)
+	})
+	admin.DELETE("/users/:id", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.DeleteUser(c)
+	})
+	admin.GET("/users/:id/personal_data", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.ExportPersonalData(c)
+	})
+	admin.DELETE("/users/:id/personal_data", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.ErasePersonalData(c)
+	})
+	admin.POST("/users/:id/deactivate", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.DeactivateUser(c)
+	})
+	admin.POST("/users/:id/reactivate", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.ReactivateUser(c)
+	})
+	admin.PUT("/users/:id/role", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		userHandler.SetUserRole(c)
+	})
+	admin.DELETE("/users/:id/sessions", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		authHandler.RevokeUserSessions(c)
+	})
+	admin.POST("/users/:id/api_keys", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		apiKeyHandler.CreateUserAPIKey(c)
+	})
+	admin.GET("/users/:id/api_keys", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		apiKeyHandler.GetUserAPIKeys(c)
+	})
+	admin.DELETE("/users/:id/api_keys/:key_id", requirePermission(domain.PermissionUsersManage), func(c *gin.Context) {
+		apiKeyHandler.RevokeUserAPIKey(c)
+	})
+
+	admin.GET("/audit", requirePermission(domain.PermissionAuditRead), func(c *gin.Context) {
+		auditHandler.GetAuditLog(c)
+	})
+
+	admin.POST("/organizations", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.CreateOrganization(c)
+	})
+	admin.GET("/organizations", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.GetOrganizations(c)
+	})
+	admin.GET("/organizations/:id", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.GetOrganization(c)
+	})
+	admin.PATCH("/organizations/:id", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.UpdateOrganization(c)
+	})
+	admin.DELETE("/organizations/:id", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.DeleteOrganization(c)
+	})
+	admin.POST("/organizations/:id/admins", requirePermission(domain.PermissionOrganizationsManage), func(c *gin.Context) {
+		organizationHandler.CreateOrganizationAdmin(c)
+	})
+
+	student := r.Group("/api/v1/student", authMiddleware(services.Auth, services.User), requirePermission(domain.PermissionScenariosPractice))
+	student.POST("/scenarios/create", func(c *gin.Context) {
 		scenarioHandler.CreateScenario(c)
 	})
-	r.POST("/api/v1/student/scenarios/answer", func(c *gin.Context) {
+	student.POST("/scenarios/answer", func(c *gin.Context) {
 		answerHandler.CreateAnswer(c)
 	})
-	r.POST("/api/v1/student/scenarios/phrase/listen", func(c *gin.Context) {
+	student.POST("/scenarios/answer/upload", func(c *gin.Context) {
+		answerHandler.UploadAnswer(c)
+	})
+	student.GET("/scenarios/answer/jobs/:id", func(c *gin.Context) {
+		answerHandler.GetRecognitionJob(c)
+	})
+	student.POST("/scenarios/phrase/listen", func(c *gin.Context) {
 		phraseStreamHandler.CreatePhraseStream(c)
 	})
-	r.GET("/api/v1/student/:user_id/get_phrases", func(c *gin.Context) {
+	student.GET("/accents", func(c *gin.Context) {
+		voiceHandler.GetAccents(c)
+	})
+	student.GET("/get_phrases", func(c *gin.Context) {
 		phraseStreamHandler.GetPhrases(c)
 	})
-	r.GET("/api/v1/student/:user_id/phrase/get_progress", func(c *gin.Context) {
+	student.GET("/phrase/get_progress", func(c *gin.Context) {
 		phraseStreamHandler.GetProgress(c)
 	})
+
+	teacher := r.Group("/api/v1/teacher", authMiddleware(services.Auth, services.User), requirePermission(domain.PermissionGroupsManage))
+	teacher.POST("/groups", func(c *gin.Context) {
+		groupHandler.CreateGroup(c)
+	})
+	teacher.GET("/groups", func(c *gin.Context) {
+		groupHandler.GetGroups(c)
+	})
+	teacher.GET("/groups/:id", func(c *gin.Context) {
+		groupHandler.GetGroup(c)
+	})
+	teacher.DELETE("/groups/:id", func(c *gin.Context) {
+		groupHandler.DeleteGroup(c)
+	})
+	teacher.POST("/groups/:id/students", func(c *gin.Context) {
+		groupHandler.EnrollStudent(c)
+	})
+	teacher.DELETE("/groups/:id/students/:user_id", func(c *gin.Context) {
+		groupHandler.RemoveStudent(c)
+	})
+	teacher.GET("/groups/:id/phrases", func(c *gin.Context) {
+		groupHandler.GetGroupPhrases(c)
+	})
+	teacher.GET("/groups/:id/progress", func(c *gin.Context) {
+		groupHandler.GetGroupProgress(c)
+	})
+	teacher.GET("/groups/:id/answers", requirePermission(domain.PermissionAnswersReadOwn), func(c *gin.Context) {
+		groupHandler.GetGroupAnswers(c)
+	})
 }
@@ -19,12 +19,23 @@ func (s *Server) ServeHTTP(_ http.ResponseWriter, _ *http.Request) {
 }
 
 type Services struct {
-	User         *services.UserService
-	Phrase       *services.PhraseService
-	PhraseType   *services.PhraseTypeService
-	Answer       *services.StudentAnswerService
-	Scenario     *services.ScenarioService
-	PhraseStream *services.PhraseStreamService
+	User            *services.UserService
+	Auth            *services.AuthService
+	Password        *services.PasswordService
+	OIDC            *services.OIDCService
+	LTI             *services.LTIService
+	Phrase          *services.PhraseService
+	PhraseType      *services.PhraseTypeService
+	Answer          *services.StudentAnswerService
+	RecognitionJobs *services.RecognitionJobService
+	Scenario        *services.ScenarioService
+	PhraseStream    *services.PhraseStreamService
+	Voice           *services.VoiceService
+	Group           *services.GroupService
+	Organization    *services.OrganizationService
+	Audit           *services.AuditService
+	Throttler       *services.LoginThrottler
+	ResetThrottler  *services.LoginThrottler
 }
 
 func NewServer(services Services, options ...func(*Server)) *Server {
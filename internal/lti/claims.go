@@ -0,0 +1,81 @@
+package lti
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// MessageTypeResourceLink is the only launch message the tool accepts.
+	MessageTypeResourceLink = "LtiResourceLinkRequest"
+	Version                 = "1.3.0"
+
+	// ScoreScope allows the tool to post scores to a line item of the platform's gradebook.
+	ScoreScope = "https://purl.imsglobal.org/spec/lti-ags/scope/score"
+
+	roleInstructor    = "http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"
+	roleAdministrator = "http://purl.imsglobal.org/vocab/lis/v2/institution/person#Administrator"
+	// instructorSubRole prefixes sub-roles such as teaching assistants.
+	instructorSubRole = "http://purl.imsglobal.org/vocab/lis/v2/membership/Instructor#"
+)
+
+type ResourceLink struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Context is the course the tool was launched from.
+type Context struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Title string `json:"title"`
+}
+
+// GradeEndpoint is the Assignment and Grade Services claim. LineItem is set when the
+// resource link has a gradebook column of its own.
+type GradeEndpoint struct {
+	Scope     []string `json:"scope"`
+	LineItems string   `json:"lineitems"`
+	LineItem  string   `json:"lineitem"`
+}
+
+// LaunchClaims are the claims of the launch ID token sent by the platform.
+type LaunchClaims struct {
+	Nonce         string         `json:"nonce"`
+	Email         string         `json:"email"`
+	Name          string         `json:"name"`
+	MessageType   string         `json:"https://purl.imsglobal.org/spec/lti/claim/message_type"`
+	Version       string         `json:"https://purl.imsglobal.org/spec/lti/claim/version"`
+	DeploymentID  string         `json:"https://purl.imsglobal.org/spec/lti/claim/deployment_id"`
+	TargetLinkURI string         `json:"https://purl.imsglobal.org/spec/lti/claim/target_link_uri"`
+	ResourceLink  ResourceLink   `json:"https://purl.imsglobal.org/spec/lti/claim/resource_link"`
+	Context       Context        `json:"https://purl.imsglobal.org/spec/lti/claim/context"`
+	Roles         []string       `json:"https://purl.imsglobal.org/spec/lti/claim/roles"`
+	Endpoint      *GradeEndpoint `json:"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint"`
+	jwt.RegisteredClaims
+}
+
+// IsInstructor reports whether the user teaches the course or administers the platform.
+func (c *LaunchClaims) IsInstructor() bool {
+	for _, role := range c.Roles {
+		if role == roleInstructor || role == roleAdministrator || strings.HasPrefix(role, instructorSubRole) {
+			return true
+		}
+	}
+	return false
+}
+
+// LineItem returns the gradebook column scores can be posted to, or "" if the platform
+// didn't grant the score scope for this launch.
+func (c *LaunchClaims) LineItem() string {
+	if c.Endpoint == nil {
+		return ""
+	}
+	for _, scope := range c.Endpoint.Scope {
+		if scope == ScoreScope {
+			return c.Endpoint.LineItem
+		}
+	}
+	return ""
+}
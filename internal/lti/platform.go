@@ -0,0 +1,180 @@
+package lti
+
+import (
+	"crypto/rsa"
+	"diplom/internal/oidc"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrInvalidLaunch = errors.New("invalid LTI launch")
+	ErrUnknownIssuer = errors.New("login initiated by an unknown platform")
+	ErrGradeService  = errors.New("assignment and grade service request failed")
+)
+
+// Config describes the platform (e.g. a Moodle site) the tool is registered with. The
+// values come from the tool registration on the platform side.
+type Config struct {
+	Issuer   string
+	ClientID string
+	// DeploymentID restricts launches to one deployment of the tool. Empty allows any.
+	DeploymentID string
+	AuthLoginURL string
+	AuthTokenURL string
+	KeySetURL    string
+	// LaunchURL is the redirect URI of the tool the platform posts the launch to.
+	LaunchURL string
+	// KeyID identifies the tool key in the key set published to the platform.
+	KeyID string
+}
+
+// LoginRequest is a third-party initiated login sent by the platform before a launch.
+type LoginRequest struct {
+	Issuer          string
+	LoginHint       string
+	TargetLinkURI   string
+	LTIMessageHint  string
+	ClientID        string
+	LTIDeploymentID string
+}
+
+// Platform verifies launches from an LTI 1.3 platform and calls its Assignment and Grade
+// Services. The tool signs its token requests with key, whose public part is served by KeySet.
+type Platform struct {
+	config Config
+	key    *rsa.PrivateKey
+	client *http.Client
+	now    func() time.Time
+
+	mu             sync.Mutex
+	keys           map[string]interface{}
+	token          string
+	tokenExpiresAt time.Time
+}
+
+func NewPlatform(config Config, key *rsa.PrivateKey, client *http.Client) *Platform {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Platform{config: config, key: key, client: client, now: time.Now}
+}
+
+func (p *Platform) Issuer() string {
+	return p.config.Issuer
+}
+
+// AuthRequestURL checks that the login was initiated by the configured platform and builds
+// the OIDC authentication request the browser is redirected to.
+func (p *Platform) AuthRequestURL(login LoginRequest, state string, nonce string) (string, error) {
+	if login.Issuer != p.config.Issuer || (login.ClientID != "" && login.ClientID != p.config.ClientID) ||
+		(p.config.DeploymentID != "" && login.LTIDeploymentID != "" && login.LTIDeploymentID != p.config.DeploymentID) {
+		return "", ErrUnknownIssuer
+	}
+	if login.LoginHint == "" {
+		return "", fmt.Errorf("%w: missing login_hint", ErrInvalidLaunch)
+	}
+	query := url.Values{
+		"scope":         {"openid"},
+		"response_type": {"id_token"},
+		"response_mode": {"form_post"},
+		"prompt":        {"none"},
+		"client_id":     {p.config.ClientID},
+		"redirect_uri":  {p.config.LaunchURL},
+		"login_hint":    {login.LoginHint},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+	if login.LTIMessageHint != "" {
+		query.Set("lti_message_hint", login.LTIMessageHint)
+	}
+	separator := "?"
+	if strings.Contains(p.config.AuthLoginURL, "?") {
+		separator = "&"
+	}
+	return p.config.AuthLoginURL + separator + query.Encode(), nil
+}
+
+// VerifyLaunch checks the signature of the launch ID token against the platform's key set and
+// validates issuer, audience, expiry, nonce, deployment and message type.
+func (p *Platform) VerifyLaunch(rawToken string, nonce string) (*LaunchClaims, error) {
+	claims := &LaunchClaims{}
+	_, err := jwt.ParseWithClaims(rawToken, claims, p.keyFunc,
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(p.now),
+		jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLaunch, err)
+	}
+	switch {
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidLaunch)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidLaunch)
+	case claims.Version != Version:
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrInvalidLaunch, claims.Version)
+	case claims.MessageType != MessageTypeResourceLink:
+		return nil, fmt.Errorf("%w: unsupported message type %q", ErrInvalidLaunch, claims.MessageType)
+	case claims.DeploymentID == "" || (p.config.DeploymentID != "" && claims.DeploymentID != p.config.DeploymentID):
+		return nil, fmt.Errorf("%w: unknown deployment %q", ErrInvalidLaunch, claims.DeploymentID)
+	case claims.ResourceLink.ID == "":
+		return nil, fmt.Errorf("%w: missing resource link", ErrInvalidLaunch)
+	}
+	return claims, nil
+}
+
+// KeySet returns the tool's public key as a JSON Web Key Set for the platform to verify
+// client assertions with.
+func (p *Platform) KeySet() map[string]interface{} {
+	return map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": p.config.KeyID,
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+	}}}
+}
+
+func (p *Platform) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if key, ok := p.cachedKey(kid); ok {
+		return key, nil
+	}
+	// The platform may have rotated its keys since they were cached.
+	keys, err := oidc.FetchKeySet(p.client, p.config.KeySetURL)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+	if key, ok := p.cachedKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *Platform) cachedKey(kid string) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidHeader means a header value would break into further header lines, like a login with a line
+// break used as the recipient.
+var ErrInvalidHeader = errors.New("mail header contains a line break")
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email. Production uses SMTP, local development writes messages to disk or the log.
+type Mailer interface {
+	Send(message Message) error
+}
+
+func validateHeaders(values ...string) error {
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return ErrInvalidHeader
+		}
+	}
+	return nil
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"diplom/internal/domain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Answers have no organization of their own, they belong to the organization of the student who gave them.
 type AnswerRepository struct {
 	db *pgxpool.Pool
 }
@@ -15,39 +17,142 @@ func NewAnswerRepository(db *pgxpool.Pool) *AnswerRepository {
 	return &AnswerRepository{db: db}
 }
 
+// Create saves the answer together with its recognized words.
 func (r *AnswerRepository) Create(answer *domain.Answer) (uuid.UUID, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id, err := insertAnswer(ctx, tx, answer)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+// CreateGraded saves the recording of a recognition job, the answer with its words and the grade of the
+// phrase stream in one transaction. A second answer of the same job is rejected with ErrJobAlreadyGraded
+// and nothing is saved.
+func (r *AnswerRepository) CreateGraded(orgID uuid.UUID, audio *domain.AudioAnswer, answer *domain.Answer,
+	phraseStreamID uuid.UUID, status string) (uuid.UUID, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	audioID := uuid.New()
+	_, err = tx.Exec(ctx, `INSERT INTO diplom.audio_answers (id, path_to_audio, record_time, organization_id, recognition_job_id)
+    VALUES ($1, $2, $3, $4, $5)`, audioID, audio.PathToAudio, audio.RecordTime, orgID, audio.RecognitionJobID)
+	if err != nil {
+		return uuid.Nil, jobConflict(err)
+	}
+	answer.AudioAnswerID = audioID
+	id, err := insertAnswer(ctx, tx, answer)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE diplom.phrase_streams SET answer_id = $2, status = $3 WHERE id = $1
+    AND scenario_id IN (SELECT id FROM diplom.scenarios WHERE organization_id = $4)`, phraseStreamID, id, status, orgID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+// GetIDByRecognitionJob returns the answer saved by a recognition job.
+func (r *AnswerRepository) GetIDByRecognitionJob(jobID uuid.UUID) (uuid.UUID, error) {
+	query := `SELECT a.id FROM diplom.answers a JOIN diplom.audio_answers aa ON aa.id = a.audio_answer_id
+    WHERE aa.recognition_job_id = $1`
+	var id uuid.UUID
+	err := r.db.QueryRow(context.Background(), query, jobID).Scan(&id)
+	return id, err
+}
+
+func insertAnswer(ctx context.Context, tx pgx.Tx, answer *domain.Answer) (uuid.UUID, error) {
 	id := uuid.New()
 	query := `INSERT INTO diplom.answers (id, user_id, audio_answer_id, text, is_correct) VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	_, err := r.db.Exec(context.Background(), query, id, answer.UserID, answer.AudioAnswerID, answer.Text, answer.IsCorrect)
-	return id, err
+	if _, err := tx.Exec(ctx, query, id, answer.UserID, answer.AudioAnswerID, answer.Text, answer.IsCorrect); err != nil {
+		return uuid.Nil, err
+	}
+	for i, word := range answer.Words {
+		_, err := tx.Exec(ctx, `INSERT INTO diplom.answer_words (answer_id, position, word, start_ms, end_ms, confidence)
+    VALUES ($1, $2, $3, $4, $5, $6)`, id, i, word.Word, word.StartMs, word.EndMs, word.Confidence)
+		if err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return id, nil
 }
 
-func (r *AnswerRepository) GetByID(id uuid.UUID) (*domain.Answer, error) {
-	query := `SELECT id, user_id, audio_answer_id, text, is_correct FROM diplom.answers WHERE id = $1`
+func (r *AnswerRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Answer, error) {
+	query := `SELECT a.id, a.user_id, a.audio_answer_id, a.text, a.is_correct FROM diplom.answers a
+    JOIN diplom.users u ON u.id = a.user_id WHERE a.id = $1 AND u.organization_id = $2`
 	answer := &domain.Answer{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&answer.ID, &answer.UserID, &answer.AudioAnswerID, &answer.Text, &answer.IsCorrect)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&answer.ID, &answer.UserID, &answer.AudioAnswerID, &answer.Text, &answer.IsCorrect)
 
 	if err != nil {
 		return nil, err
 	}
-	return answer, nil
+	answers := []domain.Answer{*answer}
+	if err := loadAnswerWords(r.db, answers); err != nil {
+		return nil, err
+	}
+	return &answers[0], nil
 }
 
-func (r *AnswerRepository) Update(answer *domain.Answer) error {
-	query := `UPDATE diplom.answers SET user_id = $2, audio_answer_id = $3, text = $4, is_correct = $5 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, answer.ID, answer.UserID, answer.AudioAnswerID, answer.Text, answer.IsCorrect)
+// Update changes an answer of the organization. The answer can't be moved to a student of another organization.
+func (r *AnswerRepository) Update(orgID uuid.UUID, answer *domain.Answer) error {
+	query := `UPDATE diplom.answers SET user_id = $2, audio_answer_id = $3, text = $4, is_correct = $5 WHERE id = $1
+    AND user_id IN (SELECT id FROM diplom.users WHERE organization_id = $6)
+    AND $2 IN (SELECT id FROM diplom.users WHERE organization_id = $6)`
+	_, err := r.db.Exec(context.Background(), query, answer.ID, answer.UserID, answer.AudioAnswerID, answer.Text, answer.IsCorrect, orgID)
 	return err
 }
 
-func (r *AnswerRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.answers WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
-	return err
+// Delete removes the answer together with its audio record in one transaction, detaching it from
+// the phrase stream it answered. It returns the path of the recording, empty if the answer had none.
+func (r *AnswerRepository) Delete(orgID uuid.UUID, id uuid.UUID) (string, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var audioID *uuid.UUID
+	var path *string
+	err = tx.QueryRow(ctx, `SELECT a.audio_answer_id, aa.path_to_audio FROM diplom.answers a
+    LEFT JOIN diplom.audio_answers aa ON aa.id = a.audio_answer_id
+    WHERE a.id = $1 AND a.user_id IN (SELECT id FROM diplom.users WHERE organization_id = $2)`, id, orgID).Scan(&audioID, &path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE diplom.phrase_streams SET answer_id = NULL WHERE answer_id = $1`, id); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM diplom.answers WHERE id = $1`, id); err != nil {
+		return "", err
+	}
+	if audioID != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM diplom.audio_answers WHERE id = $1`, *audioID); err != nil {
+			return "", err
+		}
+	}
+	if path == nil {
+		return "", tx.Commit(ctx)
+	}
+	return *path, tx.Commit(ctx)
 }
 
-func (r *AnswerRepository) GetAll() ([]domain.Answer, error) {
-	query := `SELECT id, user_id, audio_answer_id, text, is_correct FROM diplom.answers`
-	rows, err := r.db.Query(context.Background(), query)
+func (r *AnswerRepository) GetAll(orgID uuid.UUID) ([]domain.Answer, error) {
+	query := `SELECT a.id, a.user_id, a.audio_answer_id, a.text, a.is_correct FROM diplom.answers a
+    JOIN diplom.users u ON u.id = a.user_id WHERE u.organization_id = $1`
+	rows, err := r.db.Query(context.Background(), query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -61,5 +166,38 @@ func (r *AnswerRepository) GetAll() ([]domain.Answer, error) {
 		}
 		answers = append(answers, answer)
 	}
-	return answers, nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return answers, loadAnswerWords(r.db, answers)
+}
+
+// loadAnswerWords fills in the recognized words of the answers, in the order they were said.
+func loadAnswerWords(db *pgxpool.Pool, answers []domain.Answer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	index := make(map[uuid.UUID]int, len(answers))
+	ids := make([]uuid.UUID, len(answers))
+	for i, answer := range answers {
+		index[answer.ID] = i
+		ids[i] = answer.ID
+		answers[i].Words = []domain.AnswerWord{}
+	}
+	rows, err := db.Query(context.Background(), `SELECT answer_id, word, start_ms, end_ms, confidence
+    FROM diplom.answer_words WHERE answer_id = ANY($1) ORDER BY answer_id, position`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var answerID uuid.UUID
+		var word domain.AnswerWord
+		if err := rows.Scan(&answerID, &word.Word, &word.StartMs, &word.EndMs, &word.Confidence); err != nil {
+			return err
+		}
+		answer := &answers[index[answerID]]
+		answer.Words = append(answer.Words, word)
+	}
+	return rows.Err()
 }
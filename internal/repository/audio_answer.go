@@ -3,10 +3,14 @@ package repository
 import (
 	"context"
 	"diplom/internal/domain"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var ErrJobAlreadyGraded = errors.New("recognition job already has an answer")
+
 type AudioAnswerRepository struct {
 	db *pgxpool.Pool
 }
@@ -15,17 +19,31 @@ func NewAudioAnswerRepository(db *pgxpool.Pool) *AudioAnswerRepository {
 	return &AudioAnswerRepository{db: db}
 }
 
+// Create saves the recording. A second recording of the same recognition job is rejected with ErrJobAlreadyGraded.
 func (r *AudioAnswerRepository) Create(audioAnswer *domain.AudioAnswer) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.audio_answers (id, path_to_audio, record_time) VALUES ($1, $2, $3)`
-	_, err := r.db.Exec(context.Background(), query, id, audioAnswer.PathToAudio, audioAnswer.RecordTime)
-	return id, err
+	query := `INSERT INTO diplom.audio_answers (id, path_to_audio, record_time, organization_id, recognition_job_id) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(context.Background(), query, id, audioAnswer.PathToAudio, audioAnswer.RecordTime, audioAnswer.OrganizationID,
+		audioAnswer.RecognitionJobID)
+	if err != nil {
+		return uuid.Nil, jobConflict(err)
+	}
+	return id, nil
+}
+
+// jobConflict turns a violation of the unique recognition job index into ErrJobAlreadyGraded.
+func jobConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == "audio_answers_recognition_job_id_key" {
+		return ErrJobAlreadyGraded
+	}
+	return err
 }
 
-func (r *AudioAnswerRepository) GetByID(id uuid.UUID) (*domain.AudioAnswer, error) {
-	query := `SELECT id, path_to_audio, record_time FROM diplom.audio_answers WHERE id = $1`
+func (r *AudioAnswerRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.AudioAnswer, error) {
+	query := `SELECT id, path_to_audio, record_time, organization_id FROM diplom.audio_answers WHERE id = $1 AND organization_id = $2`
 	audioAnswer := &domain.AudioAnswer{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&audioAnswer.ID, &audioAnswer.PathToAudio, &audioAnswer.RecordTime)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&audioAnswer.ID, &audioAnswer.PathToAudio, &audioAnswer.RecordTime, &audioAnswer.OrganizationID)
 
 	if err != nil {
 		return nil, err
@@ -39,9 +57,9 @@ func (r *AudioAnswerRepository) GetByID(id uuid.UUID) (*domain.AudioAnswer, erro
 //	return err
 //}
 
-func (r *AudioAnswerRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.audio_answers WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *AudioAnswerRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.audio_answers WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
@@ -9,10 +9,10 @@ import (
 
 type AudioPhraseRepositoryInterface interface {
 	Create(audioPhrase *domain.AudioPhrase) (uuid.UUID, error)
-	GetByID(id uuid.UUID) (*domain.AudioPhrase, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.AudioPhrase, error)
 	Update(audioPhrase *domain.AudioPhrase) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]domain.AudioPhrase, error)
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID) ([]domain.AudioPhrase, error)
 }
 
 type AudioPhraseRepository struct {
@@ -25,15 +25,15 @@ func NewAudioPhraseRepository(db *pgxpool.Pool) *AudioPhraseRepository {
 
 func (r *AudioPhraseRepository) Create(audioPhrase *domain.AudioPhrase) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.audio_phrases (id, path_to_audio, phrase_id, accent, noise) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(context.Background(), query, id, audioPhrase.PathToAudio, audioPhrase.PhraseID, audioPhrase.Accent, int(audioPhrase.Noise))
+	query := `INSERT INTO diplom.audio_phrases (id, path_to_audio, phrase_id, accent, noise, organization_id) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(context.Background(), query, id, audioPhrase.PathToAudio, audioPhrase.PhraseID, audioPhrase.Accent, int(audioPhrase.Noise), audioPhrase.OrganizationID)
 	return id, err
 }
 
-func (r *AudioPhraseRepository) GetByID(id uuid.UUID) (*domain.AudioPhrase, error) {
-	query := `SELECT id, path_to_audio, phrase_id, accent, noise FROM diplom.audio_phrases WHERE id = $1`
+func (r *AudioPhraseRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.AudioPhrase, error) {
+	query := `SELECT id, path_to_audio, phrase_id, accent, noise, organization_id FROM diplom.audio_phrases WHERE id = $1 AND organization_id = $2`
 	audioPhrase := &domain.AudioPhrase{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&audioPhrase.ID, &audioPhrase.PathToAudio, &audioPhrase.PhraseID, &audioPhrase.Accent, &audioPhrase.Noise)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&audioPhrase.ID, &audioPhrase.PathToAudio, &audioPhrase.PhraseID, &audioPhrase.Accent, &audioPhrase.Noise, &audioPhrase.OrganizationID)
 
 	if err != nil {
 		return nil, err
@@ -42,20 +42,20 @@ func (r *AudioPhraseRepository) GetByID(id uuid.UUID) (*domain.AudioPhrase, erro
 }
 
 func (r *AudioPhraseRepository) Update(audioPhrase *domain.AudioPhrase) error {
-	query := `UPDATE diplom.audio_phrases SET path_to_audio = $2, phrase_id = $3, accent = $4, noise = $5 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, audioPhrase.ID, audioPhrase.PathToAudio, audioPhrase.PhraseID, audioPhrase.Accent, audioPhrase.Noise)
+	query := `UPDATE diplom.audio_phrases SET path_to_audio = $2, phrase_id = $3, accent = $4, noise = $5 WHERE id = $1 AND organization_id = $6`
+	_, err := r.db.Exec(context.Background(), query, audioPhrase.ID, audioPhrase.PathToAudio, audioPhrase.PhraseID, audioPhrase.Accent, audioPhrase.Noise, audioPhrase.OrganizationID)
 	return err
 }
 
-func (r *AudioPhraseRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.audio_phrases WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *AudioPhraseRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.audio_phrases WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
-func (r *AudioPhraseRepository) GetAll() ([]domain.AudioPhrase, error) {
-	query := `SELECT id, path_to_audio, phrase_id, accent, noise FROM diplom.audio_phrases`
-	rows, err := r.db.Query(context.Background(), query)
+func (r *AudioPhraseRepository) GetAll(orgID uuid.UUID) ([]domain.AudioPhrase, error) {
+	query := `SELECT id, path_to_audio, phrase_id, accent, noise, organization_id FROM diplom.audio_phrases WHERE organization_id = $1`
+	rows, err := r.db.Query(context.Background(), query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +64,7 @@ func (r *AudioPhraseRepository) GetAll() ([]domain.AudioPhrase, error) {
 	var audioPhrases []domain.AudioPhrase
 	for rows.Next() {
 		audioPhrase := domain.AudioPhrase{}
-		if err := rows.Scan(&audioPhrase.ID, &audioPhrase.PathToAudio, &audioPhrase.PhraseID, &audioPhrase.Accent, &audioPhrase.Noise); err != nil {
+		if err := rows.Scan(&audioPhrase.ID, &audioPhrase.PathToAudio, &audioPhrase.PhraseID, &audioPhrase.Accent, &audioPhrase.Noise, &audioPhrase.OrganizationID); err != nil {
 			return nil, err
 		}
 		audioPhrases = append(audioPhrases, audioPhrase)
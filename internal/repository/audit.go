@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepositoryInterface interface {
+	Create(entry *domain.AuditEntry) error
+	List(filter domain.AuditFilter) ([]domain.AuditEntry, int, error)
+	RedactUser(userID uuid.UUID) error
+}
+
+type AuditRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditRepository(db *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(entry *domain.AuditEntry) error {
+	query := `INSERT INTO diplom.audit_log (id, organization_id, actor_id, actor_login, action, entity_type, entity_id, before, after, ip, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := r.db.Exec(context.Background(), query, entry.ID, entry.OrganizationID, entry.ActorID, entry.ActorLogin, entry.Action,
+		entry.EntityType, entry.EntityID, nullableJSON(entry.Before), nullableJSON(entry.After), entry.IP, entry.CreatedAt)
+	return err
+}
+
+// RedactUser drops the snapshots of the user and of records that belong to the user, like answers, and the
+// login of the user as an actor. The entries themselves stay, so the log still shows what was done when.
+func (r *AuditRepository) RedactUser(userID uuid.UUID) error {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `UPDATE diplom.audit_log SET before = NULL, after = NULL
+WHERE entity_id = $1 OR before->>'user_id' = $1::text OR after->>'user_id' = $1::text`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+	query = `UPDATE diplom.audit_log SET actor_login = '' WHERE actor_id = $1`
+	if _, err := tx.Exec(ctx, query, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// List returns the newest entries of the filter's organization first together with the total number of matches.
+func (r *AuditRepository) List(filter domain.AuditFilter) ([]domain.AuditEntry, int, error) {
+	where := ` WHERE ($1::uuid IS NULL OR actor_id = $1) AND ($2 = '' OR action = $2) AND ($3 = '' OR entity_type = $3)
+    AND ($4::uuid IS NULL OR entity_id = $4) AND ($5::timestamp IS NULL OR created_at >= $5)
+    AND ($6::timestamp IS NULL OR created_at < $6) AND organization_id = $7`
+	args := []interface{}{filter.ActorID, filter.Action, filter.EntityType, filter.EntityID, filter.From, filter.To, filter.OrganizationID}
+
+	var total int
+	if err := r.db.QueryRow(context.Background(), `SELECT count(*) FROM diplom.audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, organization_id, actor_id, actor_login, action, entity_type, entity_id, before, after, ip, created_at
+FROM diplom.audit_log` + where + ` ORDER BY created_at DESC, id LIMIT $8 OFFSET $9`
+	rows, err := r.db.Query(context.Background(), query, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []domain.AuditEntry{}
+	for rows.Next() {
+		entry := domain.AuditEntry{}
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.OrganizationID, &entry.ActorID, &entry.ActorLogin, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &entry.IP, &entry.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+	return entries, total, rows.Err()
+}
+
+func nullableJSON(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type GroupRepositoryInterface interface {
+	Create(group *domain.Group) (uuid.UUID, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Group, error)
+	Delete(id uuid.UUID) error
+	GetByTeacher(teacherID uuid.UUID) ([]domain.Group, error)
+	AddMember(groupID uuid.UUID, userID uuid.UUID, enrolledAt time.Time) error
+	RemoveMember(groupID uuid.UUID, userID uuid.UUID) error
+	GetMembers(groupID uuid.UUID) ([]domain.GroupMember, error)
+	GetGroupPhrases(groupID uuid.UUID) ([][]string, error)
+	GetGroupProgress(groupID uuid.UUID) ([][]string, error)
+	GetGroupAnswers(groupID uuid.UUID) ([]domain.Answer, error)
+}
+
+type GroupRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewGroupRepository(db *pgxpool.Pool) *GroupRepository {
+	return &GroupRepository{db: db}
+}
+
+func (r *GroupRepository) Create(group *domain.Group) (uuid.UUID, error) {
+	id := uuid.New()
+	query := `INSERT INTO diplom.groups (id, title, teacher_id, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(context.Background(), query, id, group.Title, group.TeacherID, group.CreatedAt)
+	return id, err
+}
+
+// GetByID finds a group of the organization. Groups belong to the organization of their teacher.
+func (r *GroupRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Group, error) {
+	query := `SELECT g.id, g.title, g.teacher_id, g.created_at FROM diplom.groups g
+    JOIN diplom.users t ON t.id = g.teacher_id WHERE g.id = $1 AND t.organization_id = $2`
+	group := &domain.Group{}
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&group.ID, &group.Title, &group.TeacherID, &group.CreatedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return group, nil
+}
+
+func (r *GroupRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM diplom.groups WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
+
+func (r *GroupRepository) GetByTeacher(teacherID uuid.UUID) ([]domain.Group, error) {
+	query := `SELECT id, title, teacher_id, created_at FROM diplom.groups WHERE teacher_id = $1 ORDER BY created_at`
+	rows, err := r.db.Query(context.Background(), query, teacherID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []domain.Group
+	for rows.Next() {
+		group := domain.Group{}
+		if err := rows.Scan(&group.ID, &group.Title, &group.TeacherID, &group.CreatedAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) AddMember(groupID uuid.UUID, userID uuid.UUID, enrolledAt time.Time) error {
+	query := `INSERT INTO diplom.group_members (group_id, user_id, enrolled_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err := r.db.Exec(context.Background(), query, groupID, userID, enrolledAt)
+	return err
+}
+
+func (r *GroupRepository) RemoveMember(groupID uuid.UUID, userID uuid.UUID) error {
+	query := `DELETE FROM diplom.group_members WHERE group_id = $1 AND user_id = $2`
+	_, err := r.db.Exec(context.Background(), query, groupID, userID)
+	return err
+}
+
+func (r *GroupRepository) GetMembers(groupID uuid.UUID) ([]domain.GroupMember, error) {
+	query := `SELECT u.id, u.name, u.login, gm.enrolled_at FROM diplom.group_members gm
+    JOIN diplom.users u ON u.id = gm.user_id WHERE gm.group_id = $1 ORDER BY u.name`
+	rows, err := r.db.Query(context.Background(), query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []domain.GroupMember
+	for rows.Next() {
+		member := domain.GroupMember{}
+		if err := rows.Scan(&member.UserID, &member.Name, &member.Login, &member.EnrolledAt); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// GetGroupPhrases returns rows of (user id, user name, phrase text) for every student of the group.
+func (r *GroupRepository) GetGroupPhrases(groupID uuid.UUID) ([][]string, error) {
+	query := `SELECT u.id::text, u.name, p.text FROM diplom.group_members gm JOIN diplom.users u ON u.id = gm.user_id
+    JOIN diplom.scenarios s ON s.user_id = u.id JOIN diplom.phrase_streams ps ON ps.scenario_id = s.id
+    JOIN diplom.phrases p ON p.id = ps.phrase_id WHERE gm.group_id = $1 ORDER BY u.name`
+	return r.queryRows(query, groupID, 3)
+}
+
+// GetGroupProgress returns rows of (user id, user name, phrase text, phrase stream status, scenario status).
+func (r *GroupRepository) GetGroupProgress(groupID uuid.UUID) ([][]string, error) {
+	query := `SELECT u.id::text, u.name, p.text, ps.status, s.status FROM diplom.group_members gm
+    JOIN diplom.users u ON u.id = gm.user_id JOIN diplom.scenarios s ON s.user_id = u.id
+    JOIN diplom.phrase_streams ps ON ps.scenario_id = s.id JOIN diplom.phrases p ON p.id = ps.phrase_id
+    WHERE gm.group_id = $1 ORDER BY u.name`
+	return r.queryRows(query, groupID, 5)
+}
+
+func (r *GroupRepository) GetGroupAnswers(groupID uuid.UUID) ([]domain.Answer, error) {
+	query := `SELECT a.id, a.user_id, a.audio_answer_id, a.text, a.is_correct FROM diplom.answers a
+    JOIN diplom.group_members gm ON gm.user_id = a.user_id WHERE gm.group_id = $1`
+	rows, err := r.db.Query(context.Background(), query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var answers []domain.Answer
+	for rows.Next() {
+		answer := domain.Answer{}
+		if err := rows.Scan(&answer.ID, &answer.UserID, &answer.AudioAnswerID, &answer.Text, &answer.IsCorrect); err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return answers, loadAnswerWords(r.db, answers)
+}
+
+func (r *GroupRepository) queryRows(query string, groupID uuid.UUID, columns int) ([][]string, error) {
+	rows, err := r.db.Query(context.Background(), query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result [][]string
+	for rows.Next() {
+		row := make([]string, columns)
+		dest := make([]interface{}, columns)
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LTIRepositoryInterface interface {
+	GetCourseGroup(issuer string, contextID string) (uuid.UUID, error)
+	LinkCourseGroup(issuer string, contextID string, groupID uuid.UUID) error
+	SaveLaunch(launch *domain.LTILaunch) error
+	GetLatestLaunch(userID uuid.UUID) (*domain.LTILaunch, error)
+	GetCourseStudents(issuer string, contextID string) ([]uuid.UUID, error)
+}
+
+type LTIRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewLTIRepository(db *pgxpool.Pool) *LTIRepository {
+	return &LTIRepository{db: db}
+}
+
+// GetCourseGroup returns the group linked to a course of the platform.
+func (r *LTIRepository) GetCourseGroup(issuer string, contextID string) (uuid.UUID, error) {
+	query := `SELECT group_id FROM diplom.lti_courses WHERE issuer = $1 AND context_id = $2`
+	var groupID uuid.UUID
+	err := r.db.QueryRow(context.Background(), query, issuer, contextID).Scan(&groupID)
+	return groupID, err
+}
+
+func (r *LTIRepository) LinkCourseGroup(issuer string, contextID string, groupID uuid.UUID) error {
+	query := `INSERT INTO diplom.lti_courses (issuer, context_id, group_id) VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(context.Background(), query, issuer, contextID, groupID)
+	return err
+}
+
+// SaveLaunch stores the launch, replacing the previous one of the user from the same activity.
+func (r *LTIRepository) SaveLaunch(launch *domain.LTILaunch) error {
+	query := `INSERT INTO diplom.lti_launches (user_id, issuer, resource_link_id, deployment_id, context_id, subject, line_item, launched_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (user_id, issuer, resource_link_id) DO UPDATE SET deployment_id = EXCLUDED.deployment_id,
+    context_id = EXCLUDED.context_id, subject = EXCLUDED.subject, line_item = EXCLUDED.line_item, launched_at = EXCLUDED.launched_at`
+	_, err := r.db.Exec(context.Background(), query, launch.UserID, launch.Issuer, launch.ResourceLinkID, launch.DeploymentID,
+		launch.ContextID, launch.Subject, launch.LineItem, launch.LaunchedAt)
+	return err
+}
+
+func (r *LTIRepository) GetLatestLaunch(userID uuid.UUID) (*domain.LTILaunch, error) {
+	query := `SELECT user_id, issuer, resource_link_id, deployment_id, context_id, subject, line_item, launched_at
+FROM diplom.lti_launches WHERE user_id = $1 ORDER BY launched_at DESC LIMIT 1`
+	launch := &domain.LTILaunch{}
+	err := r.db.QueryRow(context.Background(), query, userID).Scan(&launch.UserID, &launch.Issuer, &launch.ResourceLinkID,
+		&launch.DeploymentID, &launch.ContextID, &launch.Subject, &launch.LineItem, &launch.LaunchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return launch, nil
+}
+
+// GetCourseStudents returns the students who have launched the tool from the course.
+func (r *LTIRepository) GetCourseStudents(issuer string, contextID string) ([]uuid.UUID, error) {
+	query := `SELECT DISTINCT l.user_id FROM diplom.lti_launches l JOIN diplom.users u ON u.id = l.user_id
+WHERE l.issuer = $1 AND l.context_id = $2 AND u.role = 'student'`
+	rows, err := r.db.Query(context.Background(), query, issuer, contextID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		students = append(students, id)
+	}
+	return students, rows.Err()
+}
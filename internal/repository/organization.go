@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type OrganizationRepositoryInterface interface {
+	Create(org *domain.Organization) error
+	GetByID(id uuid.UUID) (*domain.Organization, error)
+	GetBySlug(slug string) (*domain.Organization, error)
+	GetAll() ([]domain.Organization, error)
+	Update(org *domain.Organization) error
+	Delete(id uuid.UUID) error
+	HasContent(id uuid.UUID) (bool, error)
+}
+
+var ErrSlugTaken = errors.New("organization slug is already taken")
+
+type OrganizationRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewOrganizationRepository(db *pgxpool.Pool) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+func (r *OrganizationRepository) Create(org *domain.Organization) error {
+	query := `INSERT INTO diplom.organizations (id, name, slug, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(context.Background(), query, org.ID, org.Name, org.Slug, org.CreatedAt)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == "organizations_slug_key" {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+func (r *OrganizationRepository) GetByID(id uuid.UUID) (*domain.Organization, error) {
+	query := `SELECT id, name, slug, created_at FROM diplom.organizations WHERE id = $1`
+	org := &domain.Organization{}
+	err := r.db.QueryRow(context.Background(), query, id).Scan(&org.ID, &org.Name, &org.Slug, &org.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *OrganizationRepository) GetBySlug(slug string) (*domain.Organization, error) {
+	query := `SELECT id, name, slug, created_at FROM diplom.organizations WHERE slug = $1`
+	org := &domain.Organization{}
+	err := r.db.QueryRow(context.Background(), query, slug).Scan(&org.ID, &org.Name, &org.Slug, &org.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (r *OrganizationRepository) GetAll() ([]domain.Organization, error) {
+	query := `SELECT id, name, slug, created_at FROM diplom.organizations ORDER BY name, id`
+	rows, err := r.db.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orgs := []domain.Organization{}
+	for rows.Next() {
+		org := domain.Organization{}
+		if err := rows.Scan(&org.ID, &org.Name, &org.Slug, &org.CreatedAt); err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *OrganizationRepository) Update(org *domain.Organization) error {
+	query := `UPDATE diplom.organizations SET name = $2 WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, org.ID, org.Name)
+	return err
+}
+
+func (r *OrganizationRepository) Delete(id uuid.UUID) error {
+	query := `DELETE FROM diplom.organizations WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id)
+	return err
+}
+
+// HasContent reports whether any users or content still belong to the organization.
+func (r *OrganizationRepository) HasContent(id uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM diplom.users WHERE organization_id = $1)
+    OR EXISTS (SELECT 1 FROM diplom.phrase_types WHERE organization_id = $1)
+    OR EXISTS (SELECT 1 FROM diplom.phrases WHERE organization_id = $1)
+    OR EXISTS (SELECT 1 FROM diplom.scenarios WHERE organization_id = $1)
+    OR EXISTS (SELECT 1 FROM diplom.audio_phrases WHERE organization_id = $1)
+    OR EXISTS (SELECT 1 FROM diplom.audio_answers WHERE organization_id = $1)`
+	var exists bool
+	err := r.db.QueryRow(context.Background(), query, id).Scan(&exists)
+	return exists, err
+}
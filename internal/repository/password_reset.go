@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type PasswordResetRepositoryInterface interface {
+	Create(reset *domain.PasswordReset) error
+	GetByTokenHash(tokenHash string) (*domain.PasswordReset, error)
+	MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error)
+	InvalidateForUser(userID uuid.UUID, usedAt time.Time) error
+}
+
+type PasswordResetRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(db *pgxpool.Pool) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Create(reset *domain.PasswordReset) error {
+	query := `INSERT INTO diplom.password_resets (id, user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.Exec(context.Background(), query, reset.ID, reset.UserID, reset.TokenHash, reset.CreatedAt, reset.ExpiresAt)
+	return err
+}
+
+func (r *PasswordResetRepository) GetByTokenHash(tokenHash string) (*domain.PasswordReset, error) {
+	query := `SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM diplom.password_resets WHERE token_hash = $1`
+	reset := &domain.PasswordReset{}
+	err := r.db.QueryRow(context.Background(), query, tokenHash).Scan(&reset.ID, &reset.UserID, &reset.TokenHash,
+		&reset.CreatedAt, &reset.ExpiresAt, &reset.UsedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return reset, nil
+}
+
+// MarkUsed consumes the token. It reports false if the token was already used, so two concurrent
+// requests can't both redeem it.
+func (r *PasswordResetRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	query := `UPDATE diplom.password_resets SET used_at = $2 WHERE id = $1 AND used_at IS NULL`
+	tag, err := r.db.Exec(context.Background(), query, id, usedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *PasswordResetRepository) InvalidateForUser(userID uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE diplom.password_resets SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL`
+	_, err := r.db.Exec(context.Background(), query, userID, usedAt)
+	return err
+}
@@ -3,18 +3,22 @@ package repository
 import (
 	"context"
 	"diplom/internal/domain"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PhraseRepositoryInterface interface {
 	Create(phrase *domain.Phrase) (uuid.UUID, error)
-	GetByID(id uuid.UUID) (*domain.Phrase, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Phrase, error)
 	Update(phrase *domain.Phrase) error
-	Delete(id uuid.UUID) error
-	GetAll(textSearch string) ([]domain.Phrase, error)
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID, textSearch string) ([]domain.Phrase, error)
 }
 
+// ErrPhraseTypeNotFound is returned when a phrase refers to a type outside of its organization.
+var ErrPhraseTypeNotFound = errors.New("phrase type not found")
+
 type PhraseRepository struct {
 	db *pgxpool.Pool
 	pr PhraseTypeRepositoryInterface
@@ -26,15 +30,22 @@ func NewPhraseRepository(db *pgxpool.Pool, ph PhraseTypeRepositoryInterface) *Ph
 
 func (r *PhraseRepository) Create(phrase *domain.Phrase) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.phrases (id, text, type_id) VALUES ($1, $2, $3)`
-	_, err := r.db.Exec(context.Background(), query, id, phrase.Text, phrase.TypeID)
-	return id, err
+	query := `INSERT INTO diplom.phrases (id, text, markup, type_id, organization_id)
+SELECT $1, $2, $5, pt.id, pt.organization_id FROM diplom.phrase_types pt WHERE pt.id = $3 AND pt.organization_id = $4`
+	tag, err := r.db.Exec(context.Background(), query, id, phrase.Text, phrase.TypeID, phrase.OrganizationID, phrase.Markup)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return uuid.Nil, ErrPhraseTypeNotFound
+	}
+	return id, nil
 }
 
-func (r *PhraseRepository) GetByID(id uuid.UUID) (*domain.Phrase, error) {
-	query := `SELECT id, text, type_id FROM diplom.phrases WHERE id = $1`
+func (r *PhraseRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Phrase, error) {
+	query := `SELECT id, text, markup, type_id, organization_id FROM diplom.phrases WHERE id = $1 AND organization_id = $2`
 	phrase := &domain.Phrase{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&phrase.ID, &phrase.Text, &phrase.TypeID)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&phrase.ID, &phrase.Text, &phrase.Markup, &phrase.TypeID, &phrase.OrganizationID)
 
 	if err != nil {
 		return nil, err
@@ -42,21 +53,29 @@ func (r *PhraseRepository) GetByID(id uuid.UUID) (*domain.Phrase, error) {
 	return phrase, nil
 }
 
+// Update changes a phrase of the organization. The new type must belong to the same organization.
 func (r *PhraseRepository) Update(phrase *domain.Phrase) error {
-	query := `UPDATE diplom.phrases SET text = $2, type_id = $3 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, phrase.ID, phrase.Text, phrase.TypeID)
-	return err
+	query := `UPDATE diplom.phrases SET text = $2, markup = $5, type_id = $3 WHERE id = $1 AND organization_id = $4
+    AND EXISTS (SELECT 1 FROM diplom.phrase_types pt WHERE pt.id = $3 AND pt.organization_id = $4)`
+	tag, err := r.db.Exec(context.Background(), query, phrase.ID, phrase.Text, phrase.TypeID, phrase.OrganizationID, phrase.Markup)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrPhraseTypeNotFound
+	}
+	return nil
 }
 
-func (r *PhraseRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.phrases WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *PhraseRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.phrases WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
-func (r *PhraseRepository) GetAll(textSearch string) ([]domain.Phrase, error) {
-	query := `SELECT id, text, type_id FROM diplom.phrases WHERE text ILIKE '%' || $1 || '%'`
-	rows, err := r.db.Query(context.Background(), query, textSearch)
+func (r *PhraseRepository) GetAll(orgID uuid.UUID, textSearch string) ([]domain.Phrase, error) {
+	query := `SELECT id, text, markup, type_id, organization_id FROM diplom.phrases WHERE organization_id = $1 AND text ILIKE '%' || $2 || '%'`
+	rows, err := r.db.Query(context.Background(), query, orgID, textSearch)
 	if err != nil {
 		return nil, err
 	}
@@ -65,10 +84,10 @@ func (r *PhraseRepository) GetAll(textSearch string) ([]domain.Phrase, error) {
 	var phrases []domain.Phrase
 	for rows.Next() {
 		phrase := domain.Phrase{}
-		if err := rows.Scan(&phrase.ID, &phrase.Text, &phrase.TypeID); err != nil {
+		if err := rows.Scan(&phrase.ID, &phrase.Text, &phrase.Markup, &phrase.TypeID, &phrase.OrganizationID); err != nil {
 			return nil, err
 		}
-		phType, err := r.pr.GetByID(phrase.TypeID)
+		phType, err := r.pr.GetByID(orgID, phrase.TypeID)
 		if err != nil {
 			return nil, err
 		}
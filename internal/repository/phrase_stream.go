@@ -3,20 +3,26 @@ package repository
 import (
 	"context"
 	"diplom/internal/domain"
+	"errors"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PhraseStreamRepositoryInterface interface {
-	Create(phraseStream *domain.PhraseStream) (uuid.UUID, error)
-	GetByID(id uuid.UUID) (*domain.PhraseStream, error)
-	Update(id uuid.UUID, answerID uuid.UUID, status string) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]domain.PhraseStream, error)
-	GetStudentPhrases(userID uuid.UUID) ([]string, error)
-	GetStudentProgress(userID uuid.UUID) ([][]string, error)
+	Create(orgID uuid.UUID, phraseStream *domain.PhraseStream) (uuid.UUID, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseStream, error)
+	Update(orgID uuid.UUID, id uuid.UUID, answerID uuid.UUID, status string) error
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID) ([]domain.PhraseStream, error)
+	GetStudentPhrases(orgID uuid.UUID, userID uuid.UUID) ([]string, error)
+	GetStudentProgress(orgID uuid.UUID, userID uuid.UUID) ([][]string, error)
+	GetScenarioResult(orgID uuid.UUID, scenarioID uuid.UUID) (*domain.ScenarioResult, error)
 }
 
+// ErrScenarioNotFound is returned when a phrase stream refers to a scenario outside of the organization.
+var ErrScenarioNotFound = errors.New("scenario not found")
+
+// Phrase streams belong to the organization of their scenario.
 type PhraseStreamRepository struct {
 	db *pgxpool.Pool
 }
@@ -25,17 +31,25 @@ func NewPhraseStreamRepository(db *pgxpool.Pool) *PhraseStreamRepository {
 	return &PhraseStreamRepository{db: db}
 }
 
-func (r *PhraseStreamRepository) Create(phraseStream *domain.PhraseStream) (uuid.UUID, error) {
+func (r *PhraseStreamRepository) Create(orgID uuid.UUID, phraseStream *domain.PhraseStream) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.phrase_streams (id, audio_phrase_id, scenario_id, answer_id, phrase_id, status) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
-	_, err := r.db.Exec(context.Background(), query, id, phraseStream.AudioPhraseID, phraseStream.ScenarioID, "5d6629cb-ea31-42c6-8214-1732ab8619ea", phraseStream.PhraseID, phraseStream.Status)
-	return id, err
+	query := `INSERT INTO diplom.phrase_streams (id, audio_phrase_id, scenario_id, answer_id, phrase_id, status)
+SELECT $1, $2, s.id, $4, $5, $6 FROM diplom.scenarios s WHERE s.id = $3 AND s.organization_id = $7`
+	tag, err := r.db.Exec(context.Background(), query, id, phraseStream.AudioPhraseID, phraseStream.ScenarioID, "5d6629cb-ea31-42c6-8214-1732ab8619ea", phraseStream.PhraseID, phraseStream.Status, orgID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return uuid.Nil, ErrScenarioNotFound
+	}
+	return id, nil
 }
 
-func (r *PhraseStreamRepository) GetByID(id uuid.UUID) (*domain.PhraseStream, error) {
-	query := `SELECT id, audio_phrase_id, scenario_id, answer_id, phrase_id, status FROM diplom.phrase_streams WHERE id = $1`
+func (r *PhraseStreamRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseStream, error) {
+	query := `SELECT ps.id, ps.audio_phrase_id, ps.scenario_id, ps.answer_id, ps.phrase_id, ps.status FROM diplom.phrase_streams ps
+    JOIN diplom.scenarios s ON s.id = ps.scenario_id WHERE ps.id = $1 AND s.organization_id = $2`
 	phraseStream := &domain.PhraseStream{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&phraseStream.ID, &phraseStream.AudioPhraseID, &phraseStream.ScenarioID, &phraseStream.AnswerID, &phraseStream.PhraseID, &phraseStream.Status)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&phraseStream.ID, &phraseStream.AudioPhraseID, &phraseStream.ScenarioID, &phraseStream.AnswerID, &phraseStream.PhraseID, &phraseStream.Status)
 
 	if err != nil {
 		return nil, err
@@ -43,21 +57,24 @@ func (r *PhraseStreamRepository) GetByID(id uuid.UUID) (*domain.PhraseStream, er
 	return phraseStream, nil
 }
 
-func (r *PhraseStreamRepository) Update(id uuid.UUID, answerID uuid.UUID, status string) error {
-	query := `UPDATE diplom.phrase_streams SET answer_id = $2, status = $3 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id, answerID, status)
+func (r *PhraseStreamRepository) Update(orgID uuid.UUID, id uuid.UUID, answerID uuid.UUID, status string) error {
+	query := `UPDATE diplom.phrase_streams SET answer_id = $2, status = $3 WHERE id = $1
+    AND scenario_id IN (SELECT id FROM diplom.scenarios WHERE organization_id = $4)`
+	_, err := r.db.Exec(context.Background(), query, id, answerID, status, orgID)
 	return err
 }
 
-func (r *PhraseStreamRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.phrase_streams WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *PhraseStreamRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.phrase_streams WHERE id = $1
+    AND scenario_id IN (SELECT id FROM diplom.scenarios WHERE organization_id = $2)`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
-func (r *PhraseStreamRepository) GetAll() ([]domain.PhraseStream, error) {
-	query := `SELECT id, audio_phrase_id, scenario_id, answer_id, phrase_id, status FROM diplom.phrase_streams`
-	rows, err := r.db.Query(context.Background(), query)
+func (r *PhraseStreamRepository) GetAll(orgID uuid.UUID) ([]domain.PhraseStream, error) {
+	query := `SELECT ps.id, ps.audio_phrase_id, ps.scenario_id, ps.answer_id, ps.phrase_id, ps.status FROM diplom.phrase_streams ps
+    JOIN diplom.scenarios s ON s.id = ps.scenario_id WHERE s.organization_id = $1`
+	rows, err := r.db.Query(context.Background(), query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,10 +91,10 @@ func (r *PhraseStreamRepository) GetAll() ([]domain.PhraseStream, error) {
 	return phraseStreams, nil
 }
 
-func (r *PhraseStreamRepository) GetStudentPhrases(userID uuid.UUID) ([]string, error) {
+func (r *PhraseStreamRepository) GetStudentPhrases(orgID uuid.UUID, userID uuid.UUID) ([]string, error) {
 	query := `SELECT p.text FROM diplom.phrases p JOIN diplom.phrase_streams ps ON p.id = ps.phrase_id JOIN
-    diplom.scenarios s on s.id = ps.scenario_id WHERE s.user_id =  $1`
-	rows, err := r.db.Query(context.Background(), query, userID)
+    diplom.scenarios s on s.id = ps.scenario_id WHERE s.user_id =  $1 AND s.organization_id = $2 AND p.organization_id = $2`
+	rows, err := r.db.Query(context.Background(), query, userID, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -94,10 +111,10 @@ func (r *PhraseStreamRepository) GetStudentPhrases(userID uuid.UUID) ([]string,
 	return phrases, nil
 }
 
-func (r *PhraseStreamRepository) GetStudentProgress(userID uuid.UUID) ([][]string, error) {
+func (r *PhraseStreamRepository) GetStudentProgress(orgID uuid.UUID, userID uuid.UUID) ([][]string, error) {
 	query := `SELECT p.text, ps.status, s.status FROM diplom.phrases p JOIN diplom.phrase_streams ps ON p.id = ps.phrase_id JOIN
-    diplom.scenarios s on s.id = ps.scenario_id WHERE s.user_id =  $1`
-	rows, err := r.db.Query(context.Background(), query, userID)
+    diplom.scenarios s on s.id = ps.scenario_id WHERE s.user_id =  $1 AND s.organization_id = $2 AND p.organization_id = $2`
+	rows, err := r.db.Query(context.Background(), query, userID, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -115,3 +132,16 @@ func (r *PhraseStreamRepository) GetStudentProgress(userID uuid.UUID) ([][]strin
 	}
 	return progress, nil
 }
+
+// GetScenarioResult counts the phrase streams of the scenario and how many of them were answered correctly.
+func (r *PhraseStreamRepository) GetScenarioResult(orgID uuid.UUID, scenarioID uuid.UUID) (*domain.ScenarioResult, error) {
+	query := `SELECT s.id, s.user_id, COUNT(ps.id), COUNT(ps.id) FILTER (WHERE ps.status = 'success')
+FROM diplom.scenarios s LEFT JOIN diplom.phrase_streams ps ON ps.scenario_id = s.id
+WHERE s.id = $1 AND s.organization_id = $2 GROUP BY s.id, s.user_id`
+	result := &domain.ScenarioResult{}
+	err := r.db.QueryRow(context.Background(), query, scenarioID, orgID).Scan(&result.ScenarioID, &result.UserID, &result.Total, &result.Passed)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -9,10 +9,10 @@ import (
 
 type PhraseTypeRepositoryInterface interface {
 	Create(phraseType *domain.PhraseType) (uuid.UUID, error)
-	GetByID(id uuid.UUID) (*domain.PhraseType, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseType, error)
 	Update(phraseType *domain.PhraseType) error
-	Delete(id uuid.UUID) error
-	GetAll() ([]domain.PhraseType, error)
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID) ([]domain.PhraseType, error)
 }
 
 type PhraseTypeRepository struct {
@@ -25,15 +25,15 @@ func NewPhraseTypeRepository(db *pgxpool.Pool) *PhraseTypeRepository {
 
 func (r *PhraseTypeRepository) Create(phraseType *domain.PhraseType) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.phrase_types (id, title) VALUES ($1, $2)`
-	_, err := r.db.Exec(context.Background(), query, id, phraseType.Title)
+	query := `INSERT INTO diplom.phrase_types (id, title, organization_id) VALUES ($1, $2, $3)`
+	_, err := r.db.Exec(context.Background(), query, id, phraseType.Title, phraseType.OrganizationID)
 	return id, err
 }
 
-func (r *PhraseTypeRepository) GetByID(id uuid.UUID) (*domain.PhraseType, error) {
-	query := `SELECT id, title FROM diplom.phrase_types WHERE id = $1`
+func (r *PhraseTypeRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseType, error) {
+	query := `SELECT id, title, organization_id FROM diplom.phrase_types WHERE id = $1 AND organization_id = $2`
 	phraseType := &domain.PhraseType{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&phraseType.ID, &phraseType.Title)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&phraseType.ID, &phraseType.Title, &phraseType.OrganizationID)
 
 	if err != nil {
 		return nil, err
@@ -42,20 +42,20 @@ func (r *PhraseTypeRepository) GetByID(id uuid.UUID) (*domain.PhraseType, error)
 }
 
 func (r *PhraseTypeRepository) Update(phraseType *domain.PhraseType) error {
-	query := `UPDATE diplom.phrase_types SET title = $2 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, phraseType.ID, phraseType.Title)
+	query := `UPDATE diplom.phrase_types SET title = $2 WHERE id = $1 AND organization_id = $3`
+	_, err := r.db.Exec(context.Background(), query, phraseType.ID, phraseType.Title, phraseType.OrganizationID)
 	return err
 }
 
-func (r *PhraseTypeRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.phrase_types WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *PhraseTypeRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.phrase_types WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
-func (r *PhraseTypeRepository) GetAll() ([]domain.PhraseType, error) {
-	query := `SELECT id, title FROM diplom.phrase_types`
-	rows, err := r.db.Query(context.Background(), query)
+func (r *PhraseTypeRepository) GetAll(orgID uuid.UUID) ([]domain.PhraseType, error) {
+	query := `SELECT id, title, organization_id FROM diplom.phrase_types WHERE organization_id = $1`
+	rows, err := r.db.Query(context.Background(), query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +64,7 @@ func (r *PhraseTypeRepository) GetAll() ([]domain.PhraseType, error) {
 	var phraseTypes []domain.PhraseType
 	for rows.Next() {
 		phraseType := domain.PhraseType{}
-		if err := rows.Scan(&phraseType.ID, &phraseType.Title); err != nil {
+		if err := rows.Scan(&phraseType.ID, &phraseType.Title, &phraseType.OrganizationID); err != nil {
 			return nil, err
 		}
 		phraseTypes = append(phraseTypes, phraseType)
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type RecognitionJobRepositoryInterface interface {
+	Create(job *domain.RecognitionJob) error
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.RecognitionJob, error)
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]domain.RecognitionJob, error)
+	Update(job *domain.RecognitionJob) error
+}
+
+type RecognitionJobRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewRecognitionJobRepository(db *pgxpool.Pool) *RecognitionJobRepository {
+	return &RecognitionJobRepository{db: db}
+}
+
+const recognitionJobColumns = `id, organization_id, user_id, phrase_stream_id, path_to_audio, record_time, status,
+    operation_id, attempts, answer_id, error, next_run_at, created_at, updated_at`
+
+func scanRecognitionJob(row pgx.Row) (domain.RecognitionJob, error) {
+	job := domain.RecognitionJob{}
+	err := row.Scan(&job.ID, &job.OrganizationID, &job.UserID, &job.PhraseStreamID, &job.PathToAudio, &job.RecordTime,
+		&job.Status, &job.OperationID, &job.Attempts, &job.AnswerID, &job.Error, &job.NextRunAt, &job.CreatedAt, &job.UpdatedAt)
+	return job, err
+}
+
+func (r *RecognitionJobRepository) Create(job *domain.RecognitionJob) error {
+	query := `INSERT INTO diplom.recognition_jobs (` + recognitionJobColumns + `)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+	_, err := r.db.Exec(context.Background(), query, job.ID, job.OrganizationID, job.UserID, job.PhraseStreamID,
+		job.PathToAudio, job.RecordTime, job.Status, job.OperationID, job.Attempts, job.AnswerID, job.Error, job.NextRunAt,
+		job.CreatedAt, job.UpdatedAt)
+	return err
+}
+
+func (r *RecognitionJobRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.RecognitionJob, error) {
+	query := `SELECT ` + recognitionJobColumns + ` FROM diplom.recognition_jobs WHERE id = $1 AND organization_id = $2`
+	job, err := scanRecognitionJob(r.db.QueryRow(context.Background(), query, id, orgID))
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimDue takes up to limit pending jobs that are due and hides them from other workers for the lease,
+// so several instances of the server can run jobs without doing one twice.
+func (r *RecognitionJobRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]domain.RecognitionJob, error) {
+	query := `UPDATE diplom.recognition_jobs SET next_run_at = $2 WHERE id IN (
+    SELECT id FROM diplom.recognition_jobs WHERE status = 'pending' AND next_run_at <= $1
+    ORDER BY next_run_at LIMIT $3 FOR UPDATE SKIP LOCKED)
+    RETURNING ` + recognitionJobColumns
+	rows, err := r.db.Query(context.Background(), query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []domain.RecognitionJob
+	for rows.Next() {
+		job, err := scanRecognitionJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Update saves the progress of a job.
+func (r *RecognitionJobRepository) Update(job *domain.RecognitionJob) error {
+	query := `UPDATE diplom.recognition_jobs SET status = $2, operation_id = $3, attempts = $4, answer_id = $5, error = $6,
+    next_run_at = $7, updated_at = $8 WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, job.ID, job.Status, job.OperationID, job.Attempts, job.AnswerID,
+		job.Error, job.NextRunAt, job.UpdatedAt)
+	return err
+}
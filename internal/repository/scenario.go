@@ -7,6 +7,14 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+type ScenarioRepositoryInterface interface {
+	Create(scenario *domain.Scenario) (uuid.UUID, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Scenario, error)
+	Update(scenario *domain.Scenario) error
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID) ([]domain.Scenario, error)
+}
+
 type ScenarioRepository struct {
 	db *pgxpool.Pool
 }
@@ -17,15 +25,15 @@ func NewScenarioRepository(db *pgxpool.Pool) *ScenarioRepository {
 
 func (r *ScenarioRepository) Create(scenario *domain.Scenario) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.scenarios (id, title, status, start_date, end_date, user_id) VALUES ($1, $2, $3, $4, $5, $6)  RETURNING id`
-	_, err := r.db.Exec(context.Background(), query, id, scenario.Title, scenario.Status, scenario.StartDate, scenario.EndDate, scenario.UserID)
+	query := `INSERT INTO diplom.scenarios (id, title, status, start_date, end_date, user_id, organization_id) VALUES ($1, $2, $3, $4, $5, $6, $7)  RETURNING id`
+	_, err := r.db.Exec(context.Background(), query, id, scenario.Title, scenario.Status, scenario.StartDate, scenario.EndDate, scenario.UserID, scenario.OrganizationID)
 	return id, err
 }
 
-func (r *ScenarioRepository) GetByID(id uuid.UUID) (*domain.Scenario, error) {
-	query := `SELECT id, title, status, start_date, end_date, user_id FROM diplom.scenarios WHERE id = $1`
+func (r *ScenarioRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Scenario, error) {
+	query := `SELECT id, title, status, start_date, end_date, user_id, organization_id FROM diplom.scenarios WHERE id = $1 AND organization_id = $2`
 	scenario := &domain.Scenario{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&scenario.ID, &scenario.Title, &scenario.Status, &scenario.StartDate, &scenario.EndDate, &scenario.UserID)
+	err := r.db.QueryRow(context.Background(), query, id, orgID).Scan(&scenario.ID, &scenario.Title, &scenario.Status, &scenario.StartDate, &scenario.EndDate, &scenario.UserID, &scenario.OrganizationID)
 
 	if err != nil {
 		return nil, err
@@ -34,20 +42,20 @@ func (r *ScenarioRepository) GetByID(id uuid.UUID) (*domain.Scenario, error) {
 }
 
 func (r *ScenarioRepository) Update(scenario *domain.Scenario) error {
-	query := `UPDATE diplom.scenarios SET title = $2, status = $3, start_date = $4, end_date = $5, user_id = $6 WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, scenario.ID, scenario.Title, scenario.Status, scenario.StartDate, scenario.EndDate, scenario.UserID)
+	query := `UPDATE diplom.scenarios SET title = $2, status = $3, start_date = $4, end_date = $5, user_id = $6 WHERE id = $1 AND organization_id = $7`
+	_, err := r.db.Exec(context.Background(), query, scenario.ID, scenario.Title, scenario.Status, scenario.StartDate, scenario.EndDate, scenario.UserID, scenario.OrganizationID)
 	return err
 }
 
-func (r *ScenarioRepository) Delete(id uuid.UUID) error {
-	query := `DELETE FROM diplom.scenarios WHERE id = $1`
-	_, err := r.db.Exec(context.Background(), query, id)
+func (r *ScenarioRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.scenarios WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
 	return err
 }
 
-func (r *ScenarioRepository) GetAll() ([]domain.Scenario, error) {
-	query := `SELECT id, title, status, start_date, end_date, user_id FROM diplom.scenarios`
-	rows, err := r.db.Query(context.Background(), query)
+func (r *ScenarioRepository) GetAll(orgID uuid.UUID) ([]domain.Scenario, error) {
+	query := `SELECT id, title, status, start_date, end_date, user_id, organization_id FROM diplom.scenarios WHERE organization_id = $1`
+	rows, err := r.db.Query(context.Background(), query, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +64,7 @@ func (r *ScenarioRepository) GetAll() ([]domain.Scenario, error) {
 	var scenarios []domain.Scenario
 	for rows.Next() {
 		scenario := domain.Scenario{}
-		if err := rows.Scan(&scenario.ID, &scenario.Title, &scenario.Status, &scenario.StartDate, &scenario.EndDate, &scenario.UserID); err != nil {
+		if err := rows.Scan(&scenario.ID, &scenario.Title, &scenario.Status, &scenario.StartDate, &scenario.EndDate, &scenario.UserID, &scenario.OrganizationID); err != nil {
 			return nil, err
 		}
 		scenarios = append(scenarios, scenario)
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
+)
+
+type SessionRepositoryInterface interface {
+	Create(session *domain.Session) error
+	GetByID(id uuid.UUID) (*domain.Session, error)
+	Rotate(id uuid.UUID, oldHash string, refreshTokenHash string, lastUsedAt time.Time, expiresAt time.Time) (bool, error)
+	Revoke(id uuid.UUID, revokedAt time.Time) error
+	RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error
+	GetActiveByUser(userID uuid.UUID, now time.Time) ([]domain.Session, error)
+}
+
+type SessionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewSessionRepository(db *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(session *domain.Session) error {
+	query := `INSERT INTO diplom.sessions (id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.Exec(context.Background(), query, session.ID, session.UserID, session.RefreshTokenHash, session.UserAgent,
+		session.IP, session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	return err
+}
+
+func (r *SessionRepository) GetByID(id uuid.UUID) (*domain.Session, error) {
+	query := `SELECT id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+FROM diplom.sessions WHERE id = $1`
+	session := &domain.Session{}
+	err := r.db.QueryRow(context.Background(), query, id).Scan(&session.ID, &session.UserID, &session.RefreshTokenHash,
+		&session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt)
+
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Rotate replaces the refresh token of an active session if it is still oldHash. It reports false if
+// the token was rotated or the session revoked meanwhile, so two concurrent refreshes can't both win.
+func (r *SessionRepository) Rotate(id uuid.UUID, oldHash string, refreshTokenHash string, lastUsedAt time.Time,
+	expiresAt time.Time) (bool, error) {
+	query := `UPDATE diplom.sessions SET refresh_token_hash = $3, last_used_at = $4, expires_at = $5
+WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL`
+	tag, err := r.db.Exec(context.Background(), query, id, oldHash, refreshTokenHash, lastUsedAt, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *SessionRepository) Revoke(id uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE diplom.sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(context.Background(), query, id, revokedAt)
+	return err
+}
+
+func (r *SessionRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE diplom.sessions SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.Exec(context.Background(), query, userID, revokedAt)
+	return err
+}
+
+func (r *SessionRepository) GetActiveByUser(userID uuid.UUID, now time.Time) ([]domain.Session, error) {
+	query := `SELECT id, user_id, refresh_token_hash, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+FROM diplom.sessions WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2 ORDER BY last_used_at DESC`
+	rows, err := r.db.Query(context.Background(), query, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []domain.Session
+	for rows.Next() {
+		session := domain.Session{}
+		if err := rows.Scan(&session.ID, &session.UserID, &session.RefreshTokenHash, &session.UserAgent, &session.IP,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &session.RevokedAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
@@ -3,47 +3,94 @@ package repository
 import (
 	"context"
 	"diplom/internal/domain"
+	"errors"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
 )
 
 type UserRepositoryInterface interface {
 	GetByID(id uuid.UUID) (*domain.User, error)
 	Login(login string) (*domain.User, error)
 	Update(user *domain.User) error
+	UpdatePassword(id uuid.UUID, password string) error
 	Delete(id uuid.UUID) error
 	Create(user *domain.User) (uuid.UUID, error)
+	List(filter domain.UserFilter) ([]domain.User, int, error)
+	SetDeactivatedAt(id uuid.UUID, deactivatedAt *time.Time) error
+	DeleteWithContent(id uuid.UUID) ([]string, error)
+	GetPersonalData(id uuid.UUID) (*domain.PersonalData, error)
+	CreateAPIKey(key *domain.APIKey) error
+	GetAPIKeyByHash(keyHash string) (*domain.APIKey, error)
+	GetAPIKeys(userID uuid.UUID) ([]domain.APIKey, error)
+	RevokeAPIKey(userID uuid.UUID, id uuid.UUID, revokedAt time.Time) (bool, error)
+	TouchAPIKey(id uuid.UUID, usedAt time.Time) error
+	GetByIdentity(issuer string, subject string) (*domain.User, error)
+	CreateWithIdentity(user *domain.User, issuer string, subject string) (uuid.UUID, error)
+	LinkIdentity(userID uuid.UUID, issuer string, subject string) error
 }
 
+var ErrLoginTaken = errors.New("login is already taken")
+
+const uniqueViolationCode = "23505"
+
 type UserRepository struct {
 	db *pgxpool.Pool
 }
 
+// selectUserQuery loads a user together with the permissions granted by their role.
+const selectUserQuery = `SELECT u.id, u.organization_id, u.name, u.login, u.password, u.role, u.deactivated_at,
+       COALESCE(array_agg(rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+FROM diplom.users u LEFT JOIN diplom.role_permissions rp ON rp.role = u.role`
+
+func scanUser(row pgx.Row) (*domain.User, error) {
+	user := &domain.User{}
+	var permissions []string
+	if err := row.Scan(&user.ID, &user.OrganizationID, &user.Name, &user.Login, &user.Password, &user.Role, &user.DeactivatedAt, &permissions); err != nil {
+		return nil, err
+	}
+	for _, p := range permissions {
+		user.Permissions = append(user.Permissions, domain.Permission(p))
+	}
+	return user, nil
+}
+
 func NewUserRepository(db *pgxpool.Pool) *UserRepository {
 	return &UserRepository{db: db}
 }
 
 func (r *UserRepository) Create(user *domain.User) (uuid.UUID, error) {
 	id := uuid.New()
-	query := `INSERT INTO diplom.users (id, name, login, password, role) VALUES ($1, $2, $3, $4, $5)`
-	_, err := r.db.Exec(context.Background(), query, id, user.Name, user.Login, user.Password, user.Role)
-	return id, err
+	query := `INSERT INTO diplom.users (id, organization_id, name, login, password, role) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := r.db.Exec(context.Background(), query, id, user.OrganizationID, user.Name, user.Login, user.Password, user.Role)
+	return id, loginConflict(err)
 }
 
 func (r *UserRepository) GetByID(id uuid.UUID) (*domain.User, error) {
-	query := `SELECT id, name, login, password, role FROM diplom.users WHERE id = $1`
-	user := &domain.User{}
-	err := r.db.QueryRow(context.Background(), query, id).Scan(&user.ID, &user.Name, &user.Login, &user.Password, &user.Role)
-
-	if err != nil {
-		return nil, err
-	}
-	return user, nil
+	query := selectUserQuery + ` WHERE u.id = $1 GROUP BY u.id`
+	return scanUser(r.db.QueryRow(context.Background(), query, id))
 }
 
 func (r *UserRepository) Update(user *domain.User) error {
 	query := `UPDATE diplom.users SET name = $2, login = $3, password = $4, role = $5 WHERE id = $1`
 	_, err := r.db.Exec(context.Background(), query, user.ID, user.Name, user.Login, user.Password, user.Role)
+	return loginConflict(err)
+}
+
+func (r *UserRepository) UpdatePassword(id uuid.UUID, password string) error {
+	query := `UPDATE diplom.users SET password = $2 WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id, password)
+	return err
+}
+
+// loginConflict turns a violation of the unique login index into ErrLoginTaken.
+func loginConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == "users_login_key" {
+		return ErrLoginTaken
+	}
 	return err
 }
 
@@ -53,32 +100,318 @@ func (r *UserRepository) Delete(id uuid.UUID) error {
 	return err
 }
 
-//func (r *UserRepository) GetAll() ([]domain.User, error) {
-//	query := `SELECT id, name, login, password, role FROM users`
-//	rows, err := r.db.Query(context.Background(), query)
-//	if err != nil {
-//		return nil, err
-//	}
-//	defer rows.Close()
-//
-//	var users []domain.User
-//	for rows.Next() {
-//		user := domain.User{}
-//		if err := rows.Scan(&user.ID, &user.Name, &user.Login, &user.Password, &user.Role); err != nil {
-//			return nil, err
-//		}
-//		users = append(users, user)
-//	}
-//	return users, nil
-//}
+// List returns one page of users of the filter's organization and the total number of matches.
+func (r *UserRepository) List(filter domain.UserFilter) ([]domain.User, int, error) {
+	where := ` WHERE u.organization_id = $1 AND (u.name ILIKE '%' || $2 || '%' OR u.login ILIKE '%' || $2 || '%')
+    AND ($3 = '' OR u.role = $3)`
+	var total int
+	countQuery := `SELECT count(*) FROM diplom.users u` + where
+	if err := r.db.QueryRow(context.Background(), countQuery, filter.OrganizationID, filter.Search, string(filter.Role)).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := selectUserQuery + where + ` GROUP BY u.id ORDER BY u.name, u.id LIMIT $4 OFFSET $5`
+	rows, err := r.db.Query(context.Background(), query, filter.OrganizationID, filter.Search, string(filter.Role), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var users []domain.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, *user)
+	}
+	return users, total, rows.Err()
+}
+
+func (r *UserRepository) SetDeactivatedAt(id uuid.UUID, deactivatedAt *time.Time) error {
+	query := `UPDATE diplom.users SET deactivated_at = $2 WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id, deactivatedAt)
+	return err
+}
+
+// GetPersonalData loads the user with their scenarios, phrase streams, answers, answer audio records and
+// the recognition jobs that have not saved an answer.
+func (r *UserRepository) GetPersonalData(id uuid.UUID) (*domain.PersonalData, error) {
+	user, err := r.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	data := &domain.PersonalData{User: user, Scenarios: []domain.Scenario{}, PhraseStreams: []domain.PhraseStream{},
+		Answers: []domain.Answer{}, AudioAnswers: []domain.AudioAnswer{}}
+	ctx := context.Background()
+
+	rows, err := r.db.Query(ctx, `SELECT id, title, status, start_date, end_date, user_id, organization_id
+    FROM diplom.scenarios WHERE user_id = $1 ORDER BY start_date`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		scenario := domain.Scenario{}
+		if err := rows.Scan(&scenario.ID, &scenario.Title, &scenario.Status, &scenario.StartDate, &scenario.EndDate,
+			&scenario.UserID, &scenario.OrganizationID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Scenarios = append(data.Scenarios, scenario)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query(ctx, `SELECT ps.id, ps.audio_phrase_id, ps.scenario_id, ps.answer_id, ps.phrase_id, ps.status
+    FROM diplom.phrase_streams ps JOIN diplom.scenarios s ON s.id = ps.scenario_id WHERE s.user_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		stream := domain.PhraseStream{}
+		if err := rows.Scan(&stream.ID, &stream.AudioPhraseID, &stream.ScenarioID, &stream.AnswerID, &stream.PhraseID, &stream.Status); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.PhraseStreams = append(data.PhraseStreams, stream)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query(ctx, `SELECT id, user_id, audio_answer_id, text, is_correct FROM diplom.answers WHERE user_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		answer := domain.Answer{}
+		if err := rows.Scan(&answer.ID, &answer.UserID, &answer.AudioAnswerID, &answer.Text, &answer.IsCorrect); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.Answers = append(data.Answers, answer)
+	}
+	rows.Close()
+	if err := loadAnswerWords(r.db, data.Answers); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.Query(ctx, `SELECT aa.id, aa.organization_id, aa.path_to_audio, aa.record_time FROM diplom.audio_answers aa
+    JOIN diplom.answers a ON a.audio_answer_id = aa.id WHERE a.user_id = $1 ORDER BY aa.record_time`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		audio := domain.AudioAnswer{}
+		if err := rows.Scan(&audio.ID, &audio.OrganizationID, &audio.PathToAudio, &audio.RecordTime); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		data.AudioAnswers = append(data.AudioAnswers, audio)
+	}
+	rows.Close()
+
+	rows, err = r.db.Query(ctx, `SELECT `+recognitionJobColumns+` FROM diplom.recognition_jobs
+    WHERE user_id = $1 AND answer_id IS NULL ORDER BY created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		job, err := scanRecognitionJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		data.RecognitionJobs = append(data.RecognitionJobs, job)
+	}
+	return data, rows.Err()
+}
+
+// DeleteWithContent removes the user together with their scenarios, phrase streams, answers, audio records
+// and recognition jobs in one transaction. It returns the paths of the audio files that are no longer referenced.
+func (r *UserRepository) DeleteWithContent(id uuid.UUID) ([]string, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var audioPhraseIDs, audioAnswerIDs []uuid.UUID
+	var paths []string
+	rows, err := tx.Query(ctx, `SELECT ap.id, ap.path_to_audio FROM diplom.audio_phrases ap
+    JOIN diplom.phrase_streams ps ON ps.audio_phrase_id = ap.id JOIN diplom.scenarios s ON s.id = ps.scenario_id
+    WHERE s.user_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var audioID uuid.UUID
+		var path string
+		if err := rows.Scan(&audioID, &path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		audioPhraseIDs = append(audioPhraseIDs, audioID)
+		paths = append(paths, path)
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, `SELECT aa.id, aa.path_to_audio FROM diplom.audio_answers aa
+    JOIN diplom.answers a ON a.audio_answer_id = aa.id WHERE a.user_id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var audioID uuid.UUID
+		var path string
+		if err := rows.Scan(&audioID, &path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		audioAnswerIDs = append(audioAnswerIDs, audioID)
+		paths = append(paths, path)
+	}
+	rows.Close()
+
+	// The recordings of graded jobs are the ones of the answers.
+	rows, err = tx.Query(ctx, `SELECT path_to_audio FROM diplom.recognition_jobs WHERE user_id = $1 AND answer_id IS NULL`, id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+
+	steps := []struct {
+		query string
+		arg   interface{}
+	}{
+		{`DELETE FROM diplom.recognition_jobs WHERE user_id = $1`, id},
+		{`DELETE FROM diplom.phrase_streams WHERE scenario_id IN (SELECT id FROM diplom.scenarios WHERE user_id = $1)`, id},
+		{`UPDATE diplom.phrase_streams SET answer_id = NULL WHERE answer_id IN (SELECT id FROM diplom.answers WHERE user_id = $1)`, id},
+		{`DELETE FROM diplom.answers WHERE user_id = $1`, id},
+		{`DELETE FROM diplom.audio_answers WHERE id = ANY($1)`, audioAnswerIDs},
+		{`DELETE FROM diplom.audio_phrases WHERE id = ANY($1)`, audioPhraseIDs},
+		{`DELETE FROM diplom.scenarios WHERE user_id = $1`, id},
+		{`DELETE FROM diplom.users WHERE id = $1`, id},
+	}
+	for _, step := range steps {
+		if _, err := tx.Exec(ctx, step.query, step.arg); err != nil {
+			return nil, err
+		}
+	}
+	return paths, tx.Commit(ctx)
+}
 
 func (r *UserRepository) Login(login string) (*domain.User, error) {
-	query := `SELECT id, name, login, password, role FROM diplom.users WHERE login = $1`
-	user := &domain.User{}
-	err := r.db.QueryRow(context.Background(), query, login).Scan(&user.ID, &user.Name, &user.Login, &user.Password, &user.Role)
+	query := selectUserQuery + ` WHERE u.login = $1 GROUP BY u.id`
+	return scanUser(r.db.QueryRow(context.Background(), query, login))
+}
+
+const selectAPIKeyQuery = `SELECT id, user_id, name, prefix, key_hash, scopes, created_at, last_used_at, expires_at, revoked_at
+FROM diplom.api_keys`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	key := &domain.APIKey{}
+	var scopes []string
+	if err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.CreatedAt,
+		&key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt); err != nil {
+		return nil, err
+	}
+	for _, s := range scopes {
+		key.Scopes = append(key.Scopes, domain.Permission(s))
+	}
+	return key, nil
+}
 
+func (r *UserRepository) CreateAPIKey(key *domain.APIKey) error {
+	scopes := make([]string, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+	query := `INSERT INTO diplom.api_keys (id, user_id, name, prefix, key_hash, scopes, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := r.db.Exec(context.Background(), query, key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash, scopes,
+		key.CreatedAt, key.ExpiresAt)
+	return err
+}
+
+func (r *UserRepository) GetAPIKeyByHash(keyHash string) (*domain.APIKey, error) {
+	query := selectAPIKeyQuery + ` WHERE key_hash = $1`
+	return scanAPIKey(r.db.QueryRow(context.Background(), query, keyHash))
+}
+
+func (r *UserRepository) GetAPIKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	query := selectAPIKeyQuery + ` WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.db.Query(context.Background(), query, userID)
 	if err != nil {
 		return nil, err
 	}
-	return user, nil
+	defer rows.Close()
+
+	keys := []domain.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey reports false if the user has no such key.
+func (r *UserRepository) RevokeAPIKey(userID uuid.UUID, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	query := `UPDATE diplom.api_keys SET revoked_at = COALESCE(revoked_at, $3) WHERE id = $1 AND user_id = $2`
+	tag, err := r.db.Exec(context.Background(), query, id, userID, revokedAt)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *UserRepository) TouchAPIKey(id uuid.UUID, usedAt time.Time) error {
+	query := `UPDATE diplom.api_keys SET last_used_at = $2 WHERE id = $1`
+	_, err := r.db.Exec(context.Background(), query, id, usedAt)
+	return err
+}
+
+// GetByIdentity finds the user linked to an account at an external identity provider.
+func (r *UserRepository) GetByIdentity(issuer string, subject string) (*domain.User, error) {
+	query := selectUserQuery + ` JOIN diplom.user_identities ui ON ui.user_id = u.id
+WHERE ui.issuer = $1 AND ui.subject = $2 GROUP BY u.id`
+	return scanUser(r.db.QueryRow(context.Background(), query, issuer, subject))
+}
+
+// CreateWithIdentity creates a user and links it to the external account in one transaction.
+func (r *UserRepository) CreateWithIdentity(user *domain.User, issuer string, subject string) (uuid.UUID, error) {
+	ctx := context.Background()
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id := uuid.New()
+	_, err = tx.Exec(ctx, `INSERT INTO diplom.users (id, organization_id, name, login, password, role) VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, user.OrganizationID, user.Name, user.Login, user.Password, user.Role)
+	if err != nil {
+		return uuid.Nil, loginConflict(err)
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO diplom.user_identities (issuer, subject, user_id, created_at) VALUES ($1, $2, $3, $4)`,
+		issuer, subject, id, time.Now())
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (r *UserRepository) LinkIdentity(userID uuid.UUID, issuer string, subject string) error {
+	query := `INSERT INTO diplom.user_identities (issuer, subject, user_id, created_at) VALUES ($1, $2, $3, $4)`
+	_, err := r.db.Exec(context.Background(), query, issuer, subject, userID, time.Now())
+	return err
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"diplom/internal/domain"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type VoiceRepositoryInterface interface {
+	Create(voice *domain.Voice) (uuid.UUID, error)
+	GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Voice, error)
+	GetByAccent(orgID uuid.UUID, accent string) (*domain.Voice, error)
+	Update(voice *domain.Voice) error
+	Delete(orgID uuid.UUID, id uuid.UUID) error
+	GetAll(orgID uuid.UUID) ([]domain.Voice, error)
+}
+
+var ErrAccentTaken = errors.New("accent is already in the voice catalog")
+
+type VoiceRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewVoiceRepository(db *pgxpool.Pool) *VoiceRepository {
+	return &VoiceRepository{db: db}
+}
+
+const selectVoiceQuery = `SELECT id, organization_id, accent, voice, language, emotion, speed FROM diplom.voices`
+
+func scanVoice(row pgx.Row) (*domain.Voice, error) {
+	voice := &domain.Voice{}
+	err := row.Scan(&voice.ID, &voice.OrganizationID, &voice.Accent, &voice.Voice, &voice.Language, &voice.Emotion, &voice.Speed)
+	if err != nil {
+		return nil, err
+	}
+	return voice, nil
+}
+
+func (r *VoiceRepository) Create(voice *domain.Voice) (uuid.UUID, error) {
+	id := uuid.New()
+	query := `INSERT INTO diplom.voices (id, organization_id, accent, voice, language, emotion, speed)
+    VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	_, err := r.db.Exec(context.Background(), query, id, voice.OrganizationID, voice.Accent, voice.Voice, voice.Language,
+		voice.Emotion, voice.Speed)
+	return id, accentConflict(err)
+}
+
+func (r *VoiceRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Voice, error) {
+	query := selectVoiceQuery + ` WHERE id = $1 AND organization_id = $2`
+	return scanVoice(r.db.QueryRow(context.Background(), query, id, orgID))
+}
+
+func (r *VoiceRepository) GetByAccent(orgID uuid.UUID, accent string) (*domain.Voice, error) {
+	query := selectVoiceQuery + ` WHERE accent = $1 AND organization_id = $2`
+	return scanVoice(r.db.QueryRow(context.Background(), query, accent, orgID))
+}
+
+func (r *VoiceRepository) Update(voice *domain.Voice) error {
+	query := `UPDATE diplom.voices SET accent = $3, voice = $4, language = $5, emotion = $6, speed = $7
+    WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, voice.ID, voice.OrganizationID, voice.Accent, voice.Voice, voice.Language,
+		voice.Emotion, voice.Speed)
+	return accentConflict(err)
+}
+
+func (r *VoiceRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	query := `DELETE FROM diplom.voices WHERE id = $1 AND organization_id = $2`
+	_, err := r.db.Exec(context.Background(), query, id, orgID)
+	return err
+}
+
+func (r *VoiceRepository) GetAll(orgID uuid.UUID) ([]domain.Voice, error) {
+	query := selectVoiceQuery + ` WHERE organization_id = $1 ORDER BY accent`
+	rows, err := r.db.Query(context.Background(), query, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	voices := []domain.Voice{}
+	for rows.Next() {
+		voice, err := scanVoice(rows)
+		if err != nil {
+			return nil, err
+		}
+		voices = append(voices, *voice)
+	}
+	return voices, rows.Err()
+}
+
+// accentConflict turns a violation of the unique accent index into ErrAccentTaken.
+func accentConflict(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == "voices_organization_accent_key" {
+		return ErrAccentTaken
+	}
+	return err
+}
@@ -17,8 +17,8 @@ func (m *MockAudioPhraseRepository) Create(audioPhrase *domain.AudioPhrase) (uui
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
-func (m *MockAudioPhraseRepository) GetByID(id uuid.UUID) (*domain.AudioPhrase, error) {
-	args := m.Called(id)
+func (m *MockAudioPhraseRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.AudioPhrase, error) {
+	args := m.Called(orgID, id)
 	return args.Get(0).(*domain.AudioPhrase), args.Error(1)
 }
 
@@ -27,13 +27,13 @@ func (m *MockAudioPhraseRepository) Update(audioPhrase *domain.AudioPhrase) erro
 	return args.Error(0)
 }
 
-func (m *MockAudioPhraseRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockAudioPhraseRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
 	return args.Error(0)
 }
 
-func (m *MockAudioPhraseRepository) GetAll() ([]domain.AudioPhrase, error) {
-	args := m.Called()
+func (m *MockAudioPhraseRepository) GetAll(orgID uuid.UUID) ([]domain.AudioPhrase, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]domain.AudioPhrase), args.Error(1)
 }
 
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditService struct {
+	repo repository.AuditRepositoryInterface
+	now  func() time.Time
+}
+
+func NewAuditService(repo repository.AuditRepositoryInterface) *AuditService {
+	return &AuditService{repo: repo, now: time.Now}
+}
+
+// Record appends an entry to the audit log of the actor's organization. before and after are stored as JSON snapshots of the
+// entity, either may be nil (nothing existed before a create, nothing is left after a delete).
+func (s *AuditService) Record(actor *domain.User, ip string, action string, entityType string, entityID uuid.UUID,
+	before interface{}, after interface{}) error {
+	entry := &domain.AuditEntry{
+		ID:             uuid.New(),
+		OrganizationID: actor.OrganizationID,
+		ActorID:        actor.ID,
+		ActorLogin:     actor.Login,
+		Action:         action,
+		EntityType:     entityType,
+		IP:             ip,
+		CreatedAt:      s.now(),
+	}
+	if entityID != uuid.Nil {
+		entry.EntityID = &entityID
+	}
+	var err error
+	if entry.Before, err = snapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = snapshot(after); err != nil {
+		return err
+	}
+	return s.repo.Create(entry)
+}
+
+// RedactUser removes the personal data of an erased user from the audit log.
+func (s *AuditService) RedactUser(userID uuid.UUID) error {
+	return s.repo.RedactUser(userID)
+}
+
+// Query returns the requested page of entries, newest first. Pages start at 1.
+func (s *AuditService) Query(filter domain.AuditFilter, page int, pageSize int) (*domain.AuditPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	filter.Limit = pageSize
+	filter.Offset = (page - 1) * pageSize
+	entries, total, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.AuditPage{Entries: entries, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+func snapshot(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil || string(data) == "null" {
+		return nil, err
+	}
+	return data, nil
+}
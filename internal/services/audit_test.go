@@ -0,0 +1,83 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) Create(entry *domain.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) List(filter domain.AuditFilter) ([]domain.AuditEntry, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]domain.AuditEntry), args.Int(1), args.Error(2)
+}
+
+func (m *MockAuditRepository) RedactUser(userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func TestAuditService_Record(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := new(MockAuditRepository)
+	service := NewAuditService(repo)
+	service.now = func() time.Time { return now }
+	actor := &domain.User{ID: uuid.New(), OrganizationID: uuid.New(), Login: "admin@example.com"}
+	phrase := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff"}
+
+	t.Run("delete keeps the before snapshot", func(t *testing.T) {
+		var entry *domain.AuditEntry
+		repo.On("Create", mock.AnythingOfType("*domain.AuditEntry")).
+			Run(func(args mock.Arguments) { entry = args.Get(0).(*domain.AuditEntry) }).Return(nil).Once()
+
+		err := service.Record(actor, "10.0.0.1", "phrase.delete", "phrase", phrase.ID, phrase, nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, actor.ID, entry.ActorID)
+		assert.Equal(t, actor.OrganizationID, entry.OrganizationID)
+		assert.Equal(t, "admin@example.com", entry.ActorLogin)
+		assert.Equal(t, phrase.ID, *entry.EntityID)
+		assert.Contains(t, string(entry.Before), `"text":"Cleared for takeoff"`)
+		assert.Nil(t, entry.After)
+		assert.Equal(t, now, entry.CreatedAt)
+	})
+
+	t.Run("typed nil snapshot is stored as empty", func(t *testing.T) {
+		var entry *domain.AuditEntry
+		repo.On("Create", mock.AnythingOfType("*domain.AuditEntry")).
+			Run(func(args mock.Arguments) { entry = args.Get(0).(*domain.AuditEntry) }).Return(nil).Once()
+
+		err := service.Record(actor, "10.0.0.1", "user.revoke_sessions", "user", uuid.New(), (*domain.User)(nil), nil)
+
+		assert.NoError(t, err)
+		assert.Nil(t, entry.Before)
+	})
+}
+
+func TestAuditService_Query(t *testing.T) {
+	repo := new(MockAuditRepository)
+	service := NewAuditService(repo)
+	entityType := "answer"
+	repo.On("List", domain.AuditFilter{EntityType: entityType, Limit: 100, Offset: 200}).
+		Return([]domain.AuditEntry{{Action: "answer.delete"}}, 201, nil)
+
+	page, err := service.Query(domain.AuditFilter{EntityType: entityType}, 3, 500)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 201, page.Total)
+	assert.Equal(t, 3, page.Page)
+	assert.Equal(t, 100, page.PageSize)
+	assert.Len(t, page.Entries, 1)
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, session revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionNotActive    = errors.New("session is revoked or expired")
+	ErrSessionOwnerInvalid = errors.New("session belongs to another user")
+)
+
+type AuthService struct {
+	users      repository.UserRepositoryInterface
+	sessions   repository.SessionRepositoryInterface
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+type Tokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	SessionID        uuid.UUID
+}
+
+type accessClaims struct {
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+func NewAuthService(users repository.UserRepositoryInterface, sessions repository.SessionRepositoryInterface,
+	secret []byte, accessTTL time.Duration, refreshTTL time.Duration) *AuthService {
+	return &AuthService{users: users, sessions: sessions, secret: secret, accessTTL: accessTTL, refreshTTL: refreshTTL, now: time.Now}
+}
+
+// StartSession opens a new session for an already authenticated user and issues its first token pair.
+func (s *AuthService) StartSession(user *domain.User, userAgent string, ip string) (*Tokens, error) {
+	now := s.now()
+	session := &domain.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+	refreshToken, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenHash = hashToken(refreshToken)
+	if err := s.sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return s.issueTokens(user, session, refreshToken)
+}
+
+// Refresh rotates the refresh token of a session. Presenting a token that was already rotated
+// means it leaked, so the whole session is revoked.
+func (s *AuthService) Refresh(refreshToken string) (*Tokens, error) {
+	sessionID, ok := parseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	session, err := s.sessions.GetByID(sessionID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	now := s.now()
+	if !session.Active(now) {
+		return nil, ErrSessionNotActive
+	}
+	if subtle.ConstantTimeCompare([]byte(session.RefreshTokenHash), []byte(hashToken(refreshToken))) != 1 {
+		if err := s.sessions.Revoke(session.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	user, err := s.users.GetByID(session.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !user.Active() {
+		return nil, ErrUserInactive
+	}
+	newToken, err := newRefreshToken(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	oldHash := session.RefreshTokenHash
+	session.RefreshTokenHash = hashToken(newToken)
+	session.LastUsedAt = now
+	session.ExpiresAt = now.Add(s.refreshTTL)
+	rotated, err := s.sessions.Rotate(session.ID, oldHash, session.RefreshTokenHash, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another refresh with the same token won the race.
+		if err := s.sessions.Revoke(session.ID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrRefreshTokenReused
+	}
+	return s.issueTokens(user, session, newToken)
+}
+
+// Authenticate validates an access token and loads the user and session it was issued for.
+func (s *AuthService) Authenticate(token string) (*domain.User, uuid.UUID, error) {
+	claims := &accessClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.now), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	sessionID, err := uuid.Parse(claims.SessionID)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	session, err := s.sessions.GetByID(sessionID)
+	if err != nil || session.UserID != userID || !session.Active(s.now()) {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	user, err := s.users.GetByID(userID)
+	if err != nil || !user.Active() {
+		return nil, uuid.Nil, ErrInvalidToken
+	}
+	return user, sessionID, nil
+}
+
+func (s *AuthService) GetActiveSessions(userID uuid.UUID) ([]domain.Session, error) {
+	return s.sessions.GetActiveByUser(userID, s.now())
+}
+
+// RevokeSession ends one session of the user, e.g. on logout.
+func (s *AuthService) RevokeSession(userID uuid.UUID, sessionID uuid.UUID) error {
+	session, err := s.sessions.GetByID(sessionID)
+	if err != nil {
+		return ErrSessionNotFound
+	}
+	if session.UserID != userID {
+		return ErrSessionOwnerInvalid
+	}
+	return s.sessions.Revoke(sessionID, s.now())
+}
+
+func (s *AuthService) RevokeAllSessions(userID uuid.UUID) error {
+	return s.sessions.RevokeAllForUser(userID, s.now())
+}
+
+func (s *AuthService) issueTokens(user *domain.User, session *domain.Session, refreshToken string) (*Tokens, error) {
+	now := s.now()
+	expiresAt := now.Add(s.accessTTL)
+	claims := accessClaims{
+		SessionID: session.ID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID.String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        uuid.NewString(),
+		},
+	}
+	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+	return &Tokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+	}, nil
+}
+
+// newRefreshToken builds an opaque token "<session id>.<random>" so a presented token can be
+// matched to its session even after it has been rotated.
+func newRefreshToken(sessionID uuid.UUID) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return sessionID.String() + "." + base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+func parseRefreshToken(token string) (uuid.UUID, bool) {
+	sessionPart, secret, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return uuid.Nil, false
+	}
+	sessionID, err := uuid.Parse(sessionPart)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return sessionID, true
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(session *domain.Session) error {
+	args := m.Called(session)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(id uuid.UUID) (*domain.Session, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Rotate(id uuid.UUID, oldHash string, refreshTokenHash string, lastUsedAt time.Time,
+	expiresAt time.Time) (bool, error) {
+	args := m.Called(id, oldHash, refreshTokenHash, lastUsedAt, expiresAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) Revoke(id uuid.UUID, revokedAt time.Time) error {
+	args := m.Called(id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) RevokeAllForUser(userID uuid.UUID, revokedAt time.Time) error {
+	args := m.Called(userID, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetActiveByUser(userID uuid.UUID, now time.Time) ([]domain.Session, error) {
+	args := m.Called(userID, now)
+	return args.Get(0).([]domain.Session), args.Error(1)
+}
+
+func startTestSession(t *testing.T, authService *AuthService, sessionRepo *MockSessionRepository, user *domain.User) (*Tokens, *domain.Session) {
+	var session *domain.Session
+	sessionRepo.On("Create", mock.AnythingOfType("*domain.Session")).Run(func(args mock.Arguments) {
+		session = args.Get(0).(*domain.Session)
+	}).Return(nil).Once()
+
+	tokens, err := authService.StartSession(user, "test-agent", "127.0.0.1")
+	assert.NoError(t, err)
+	return tokens, session
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	sessionRepo := new(MockSessionRepository)
+	authService := NewAuthService(userRepo, sessionRepo, []byte("test-secret"), time.Minute, time.Hour)
+
+	t.Run("valid token resolves user and session", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New(), Name: "John Doe", Login: "john@example.com"}
+		tokens, session := startTestSession(t, authService, sessionRepo, user)
+		userRepo.On("GetByID", user.ID).Return(user, nil)
+		sessionRepo.On("GetByID", session.ID).Return(session, nil)
+
+		resolved, sessionID, err := authService.Authenticate(tokens.AccessToken)
+
+		assert.NoError(t, err)
+		assert.Equal(t, user.ID, resolved.ID)
+		assert.Equal(t, session.ID, sessionID)
+	})
+
+	t.Run("token of revoked session is rejected", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens, session := startTestSession(t, authService, sessionRepo, user)
+		revokedAt := time.Now()
+		revoked := *session
+		revoked.RevokedAt = &revokedAt
+		sessionRepo.On("GetByID", session.ID).Return(&revoked, nil)
+
+		_, _, err := authService.Authenticate(tokens.AccessToken)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("token signed with another secret is rejected", func(t *testing.T) {
+		other := NewAuthService(userRepo, sessionRepo, []byte("other-secret"), time.Minute, time.Hour)
+		tokens, _ := startTestSession(t, other, sessionRepo, &domain.User{ID: uuid.New()})
+
+		_, _, err := authService.Authenticate(tokens.AccessToken)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		expired := NewAuthService(userRepo, sessionRepo, []byte("test-secret"), time.Minute, time.Hour)
+		expired.now = func() time.Time { return time.Now().Add(-time.Hour) }
+		tokens, _ := startTestSession(t, expired, sessionRepo, &domain.User{ID: uuid.New()})
+
+		_, _, err := authService.Authenticate(tokens.AccessToken)
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("garbage token is rejected", func(t *testing.T) {
+		_, _, err := authService.Authenticate("not-a-token")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	sessionRepo := new(MockSessionRepository)
+	authService := NewAuthService(userRepo, sessionRepo, []byte("test-secret"), time.Minute, time.Hour)
+
+	t.Run("refresh rotates the token", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens, session := startTestSession(t, authService, sessionRepo, user)
+		userRepo.On("GetByID", user.ID).Return(user, nil)
+		sessionRepo.On("GetByID", session.ID).Return(session, nil)
+		sessionRepo.On("Rotate", session.ID, session.RefreshTokenHash, mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Once()
+
+		refreshed, err := authService.Refresh(tokens.RefreshToken)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+		assert.Equal(t, session.ID, refreshed.SessionID)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("reusing a rotated token revokes the session", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens, session := startTestSession(t, authService, sessionRepo, user)
+		rotated := *session
+		rotated.RefreshTokenHash = hashToken("newer-token")
+		sessionRepo.On("GetByID", session.ID).Return(&rotated, nil)
+		sessionRepo.On("Revoke", session.ID, mock.Anything).Return(nil).Once()
+
+		_, err := authService.Refresh(tokens.RefreshToken)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("concurrent refresh with the same token revokes the session", func(t *testing.T) {
+		user := &domain.User{ID: uuid.New()}
+		tokens, session := startTestSession(t, authService, sessionRepo, user)
+		userRepo.On("GetByID", user.ID).Return(user, nil)
+		sessionRepo.On("GetByID", session.ID).Return(session, nil)
+		sessionRepo.On("Rotate", session.ID, session.RefreshTokenHash, mock.Anything, mock.Anything, mock.Anything).Return(false, nil).Once()
+		sessionRepo.On("Revoke", session.ID, mock.Anything).Return(nil).Once()
+
+		_, err := authService.Refresh(tokens.RefreshToken)
+
+		assert.ErrorIs(t, err, ErrRefreshTokenReused)
+		sessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("malformed token is rejected", func(t *testing.T) {
+		_, err := authService.Refresh("malformed")
+
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	sessionRepo := new(MockSessionRepository)
+	authService := NewAuthService(userRepo, sessionRepo, []byte("test-secret"), time.Minute, time.Hour)
+
+	t.Run("session of another user is not revoked", func(t *testing.T) {
+		session := &domain.Session{ID: uuid.New(), UserID: uuid.New()}
+		sessionRepo.On("GetByID", session.ID).Return(session, nil)
+
+		err := authService.RevokeSession(uuid.New(), session.ID)
+
+		assert.ErrorIs(t, err, ErrSessionOwnerInvalid)
+		sessionRepo.AssertNotCalled(t, "Revoke", session.ID, mock.Anything)
+	})
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+var (
+	ErrGroupNotFound     = errors.New("group not found")
+	ErrGroupAccessDenied = errors.New("group belongs to another teacher")
+	ErrNotStudent        = errors.New("only students can be enrolled in a group")
+	ErrStudentNotFound   = errors.New("student not found")
+)
+
+type GroupService struct {
+	groups repository.GroupRepositoryInterface
+	users  repository.UserRepositoryInterface
+}
+
+func NewGroupService(groups repository.GroupRepositoryInterface, users repository.UserRepositoryInterface) *GroupService {
+	return &GroupService{groups: groups, users: users}
+}
+
+func (s *GroupService) CreateGroup(teacher *domain.User, title string) (uuid.UUID, error) {
+	return s.groups.Create(&domain.Group{
+		Title:     title,
+		TeacherID: teacher.ID,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *GroupService) GetTeacherGroups(teacher *domain.User) ([]domain.Group, error) {
+	return s.groups.GetByTeacher(teacher.ID)
+}
+
+// GetGroup returns the group if the caller owns it. Administrators can access every group of their organization.
+func (s *GroupService) GetGroup(caller *domain.User, groupID uuid.UUID) (*domain.Group, error) {
+	group, err := s.groups.GetByID(caller.OrganizationID, groupID)
+	if err != nil {
+		return nil, ErrGroupNotFound
+	}
+	if group.TeacherID != caller.ID && caller.Role != domain.RoleAdmin && caller.Role != domain.RoleSuperAdmin {
+		return nil, ErrGroupAccessDenied
+	}
+	return group, nil
+}
+
+func (s *GroupService) DeleteGroup(caller *domain.User, groupID uuid.UUID) error {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return err
+	}
+	return s.groups.Delete(groupID)
+}
+
+func (s *GroupService) EnrollStudent(caller *domain.User, groupID uuid.UUID, studentID uuid.UUID) error {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return err
+	}
+	student, err := s.users.GetByID(studentID)
+	if err != nil || student.OrganizationID != caller.OrganizationID {
+		return ErrStudentNotFound
+	}
+	if student.Role != domain.RoleStudent {
+		return ErrNotStudent
+	}
+	return s.groups.AddMember(groupID, studentID, time.Now())
+}
+
+func (s *GroupService) RemoveStudent(caller *domain.User, groupID uuid.UUID, studentID uuid.UUID) error {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return err
+	}
+	return s.groups.RemoveMember(groupID, studentID)
+}
+
+func (s *GroupService) GetMembers(caller *domain.User, groupID uuid.UUID) ([]domain.GroupMember, error) {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return nil, err
+	}
+	return s.groups.GetMembers(groupID)
+}
+
+func (s *GroupService) GetGroupPhrases(caller *domain.User, groupID uuid.UUID) ([][]string, error) {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return nil, err
+	}
+	return s.groups.GetGroupPhrases(groupID)
+}
+
+func (s *GroupService) GetGroupProgress(caller *domain.User, groupID uuid.UUID) ([][]string, error) {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return nil, err
+	}
+	return s.groups.GetGroupProgress(groupID)
+}
+
+func (s *GroupService) GetGroupAnswers(caller *domain.User, groupID uuid.UUID) ([]domain.Answer, error) {
+	if _, err := s.GetGroup(caller, groupID); err != nil {
+		return nil, err
+	}
+	return s.groups.GetGroupAnswers(groupID)
+}
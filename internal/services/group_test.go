@@ -0,0 +1,164 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type MockGroupRepository struct {
+	mock.Mock
+}
+
+func (m *MockGroupRepository) Create(group *domain.Group) (uuid.UUID, error) {
+	args := m.Called(group)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockGroupRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Group, error) {
+	args := m.Called(orgID, id)
+	return args.Get(0).(*domain.Group), args.Error(1)
+}
+
+func (m *MockGroupRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) GetByTeacher(teacherID uuid.UUID) ([]domain.Group, error) {
+	args := m.Called(teacherID)
+	return args.Get(0).([]domain.Group), args.Error(1)
+}
+
+func (m *MockGroupRepository) AddMember(groupID uuid.UUID, userID uuid.UUID, enrolledAt time.Time) error {
+	args := m.Called(groupID, userID, enrolledAt)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) RemoveMember(groupID uuid.UUID, userID uuid.UUID) error {
+	args := m.Called(groupID, userID)
+	return args.Error(0)
+}
+
+func (m *MockGroupRepository) GetMembers(groupID uuid.UUID) ([]domain.GroupMember, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([]domain.GroupMember), args.Error(1)
+}
+
+func (m *MockGroupRepository) GetGroupPhrases(groupID uuid.UUID) ([][]string, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockGroupRepository) GetGroupProgress(groupID uuid.UUID) ([][]string, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([][]string), args.Error(1)
+}
+
+func (m *MockGroupRepository) GetGroupAnswers(groupID uuid.UUID) ([]domain.Answer, error) {
+	args := m.Called(groupID)
+	return args.Get(0).([]domain.Answer), args.Error(1)
+}
+
+func TestGroupService_GetGroup(t *testing.T) {
+	groupRepo := new(MockGroupRepository)
+	service := NewGroupService(groupRepo, new(MockUserRepository))
+	orgID := uuid.New()
+	teacher := &domain.User{ID: uuid.New(), Role: domain.RoleTeacher, OrganizationID: orgID}
+	group := &domain.Group{ID: uuid.New(), Title: "ATC-1", TeacherID: teacher.ID}
+	groupRepo.On("GetByID", orgID, group.ID).Return(group, nil)
+
+	t.Run("owner gets the group", func(t *testing.T) {
+		result, err := service.GetGroup(teacher, group.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, group, result)
+	})
+
+	t.Run("another teacher is denied", func(t *testing.T) {
+		other := &domain.User{ID: uuid.New(), Role: domain.RoleTeacher, OrganizationID: orgID}
+
+		_, err := service.GetGroup(other, group.ID)
+
+		assert.ErrorIs(t, err, ErrGroupAccessDenied)
+	})
+
+	t.Run("admin gets any group", func(t *testing.T) {
+		admin := &domain.User{ID: uuid.New(), Role: domain.RoleAdmin, OrganizationID: orgID}
+
+		_, err := service.GetGroup(admin, group.ID)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("admin of another organization doesn't see the group", func(t *testing.T) {
+		foreignOrg := uuid.New()
+		admin := &domain.User{ID: uuid.New(), Role: domain.RoleAdmin, OrganizationID: foreignOrg}
+		groupRepo.On("GetByID", foreignOrg, group.ID).Return((*domain.Group)(nil), errors.New("no rows"))
+
+		_, err := service.GetGroup(admin, group.ID)
+
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	})
+
+	t.Run("missing group", func(t *testing.T) {
+		id := uuid.New()
+		groupRepo.On("GetByID", orgID, id).Return((*domain.Group)(nil), errors.New("no rows"))
+
+		_, err := service.GetGroup(teacher, id)
+
+		assert.ErrorIs(t, err, ErrGroupNotFound)
+	})
+}
+
+func TestGroupService_EnrollStudent(t *testing.T) {
+	groupRepo := new(MockGroupRepository)
+	userRepo := new(MockUserRepository)
+	service := NewGroupService(groupRepo, userRepo)
+	orgID := uuid.New()
+	teacher := &domain.User{ID: uuid.New(), Role: domain.RoleTeacher, OrganizationID: orgID}
+	group := &domain.Group{ID: uuid.New(), Title: "ATC-1", TeacherID: teacher.ID}
+	groupRepo.On("GetByID", orgID, group.ID).Return(group, nil)
+
+	t.Run("student is enrolled", func(t *testing.T) {
+		student := &domain.User{ID: uuid.New(), Role: domain.RoleStudent, OrganizationID: orgID}
+		userRepo.On("GetByID", student.ID).Return(student, nil)
+		groupRepo.On("AddMember", group.ID, student.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := service.EnrollStudent(teacher, group.ID, student.ID)
+
+		assert.NoError(t, err)
+		groupRepo.AssertExpectations(t)
+	})
+
+	t.Run("teacher can't be enrolled", func(t *testing.T) {
+		other := &domain.User{ID: uuid.New(), Role: domain.RoleTeacher, OrganizationID: orgID}
+		userRepo.On("GetByID", other.ID).Return(other, nil)
+
+		err := service.EnrollStudent(teacher, group.ID, other.ID)
+
+		assert.ErrorIs(t, err, ErrNotStudent)
+	})
+
+	t.Run("unknown student", func(t *testing.T) {
+		id := uuid.New()
+		userRepo.On("GetByID", id).Return((*domain.User)(nil), errors.New("no rows"))
+
+		err := service.EnrollStudent(teacher, group.ID, id)
+
+		assert.ErrorIs(t, err, ErrStudentNotFound)
+	})
+
+	t.Run("student of another organization", func(t *testing.T) {
+		student := &domain.User{ID: uuid.New(), Role: domain.RoleStudent, OrganizationID: uuid.New()}
+		userRepo.On("GetByID", student.ID).Return(student, nil)
+
+		err := service.EnrollStudent(teacher, group.ID, student.ID)
+
+		assert.ErrorIs(t, err, ErrStudentNotFound)
+	})
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+type ThrottleConfig struct {
+	MaxFailuresPerLogin int
+	MaxFailuresPerIP    int
+	Window              time.Duration
+	Lockout             time.Duration
+}
+
+type failureCounter struct {
+	failures    int
+	windowStart time.Time
+	lockedUntil time.Time
+}
+
+// LoginThrottler counts failed login attempts per login and per client IP and locks
+// the key out for a while once it reaches the limit. State is kept in memory.
+type LoginThrottler struct {
+	config   ThrottleConfig
+	mu       sync.Mutex
+	counters map[string]*failureCounter
+	now      func() time.Time
+}
+
+func NewLoginThrottler(config ThrottleConfig) *LoginThrottler {
+	return &LoginThrottler{config: config, counters: make(map[string]*failureCounter), now: time.Now}
+}
+
+// Allow reports whether a login attempt may proceed and, if not, how long the caller has to wait.
+func (t *LoginThrottler) Allow(login string, ip string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	var wait time.Duration
+	for _, key := range []string{loginKey(login), ipKey(ip)} {
+		if counter, ok := t.counters[key]; ok && now.Before(counter.lockedUntil) {
+			if remaining := counter.lockedUntil.Sub(now); remaining > wait {
+				wait = remaining
+			}
+		}
+	}
+	return wait, wait == 0
+}
+
+func (t *LoginThrottler) Failure(login string, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.register(loginKey(login), t.config.MaxFailuresPerLogin, now)
+	t.register(ipKey(ip), t.config.MaxFailuresPerIP, now)
+	t.cleanup(now)
+}
+
+// Success forgets failures of the login. The IP counter is kept so one client
+// can't spray passwords across many accounts.
+func (t *LoginThrottler) Success(login string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.counters, loginKey(login))
+}
+
+func (t *LoginThrottler) register(key string, limit int, now time.Time) {
+	if limit <= 0 {
+		return
+	}
+	counter, ok := t.counters[key]
+	if !ok || now.Sub(counter.windowStart) > t.config.Window {
+		counter = &failureCounter{windowStart: now}
+		t.counters[key] = counter
+	}
+	counter.failures++
+	if counter.failures >= limit {
+		counter.lockedUntil = now.Add(t.config.Lockout)
+		counter.failures = 0
+		counter.windowStart = now
+	}
+}
+
+func (t *LoginThrottler) cleanup(now time.Time) {
+	for key, counter := range t.counters {
+		if now.Sub(counter.windowStart) > t.config.Window && now.After(counter.lockedUntil) {
+			delete(t.counters, key)
+		}
+	}
+}
+
+func loginKey(login string) string {
+	return "login:" + strings.ToLower(strings.TrimSpace(login))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
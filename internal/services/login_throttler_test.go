@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestThrottler(now *time.Time) *LoginThrottler {
+	throttler := NewLoginThrottler(ThrottleConfig{
+		MaxFailuresPerLogin: 3,
+		MaxFailuresPerIP:    5,
+		Window:              time.Minute,
+		Lockout:             10 * time.Minute,
+	})
+	throttler.now = func() time.Time { return *now }
+	return throttler
+}
+
+func TestLoginThrottler_LocksLogin(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttler := newTestThrottler(&now)
+
+	for i := 0; i < 3; i++ {
+		_, ok := throttler.Allow("John", "10.0.0.1")
+		assert.True(t, ok)
+		throttler.Failure("John", "10.0.0.1")
+	}
+
+	wait, ok := throttler.Allow("john", "10.0.0.2")
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Minute, wait)
+
+	now = now.Add(10 * time.Minute)
+	_, ok = throttler.Allow("john", "10.0.0.2")
+	assert.True(t, ok)
+}
+
+func TestLoginThrottler_LocksIP(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttler := newTestThrottler(&now)
+
+	for _, login := range []string{"a", "b", "c", "d", "e"} {
+		throttler.Failure(login, "10.0.0.1")
+	}
+
+	_, ok := throttler.Allow("f", "10.0.0.1")
+	assert.False(t, ok)
+	_, ok = throttler.Allow("f", "10.0.0.2")
+	assert.True(t, ok)
+}
+
+func TestLoginThrottler_WindowAndSuccess(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttler := newTestThrottler(&now)
+
+	throttler.Failure("john", "10.0.0.1")
+	throttler.Failure("john", "10.0.0.1")
+	now = now.Add(2 * time.Minute)
+	throttler.Failure("john", "10.0.0.1")
+	_, ok := throttler.Allow("john", "10.0.0.1")
+	assert.True(t, ok, "failures outside the window are forgotten")
+
+	throttler.Failure("john", "10.0.0.1")
+	throttler.Success("john")
+	throttler.Failure("john", "10.0.0.1")
+	_, ok = throttler.Allow("john", "10.0.0.1")
+	assert.True(t, ok, "successful login resets the counter")
+}
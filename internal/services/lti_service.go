@@ -0,0 +1,223 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/lti"
+	"diplom/internal/repository"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+var (
+	ErrLTIStateInvalid  = errors.New("unknown or expired LTI launch")
+	ErrLTILoginConflict = errors.New("a local account with this login already exists")
+)
+
+type pendingLaunch struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+// LTIService lets an LTI 1.3 platform such as Moodle launch the application as a tool. Users are
+// created on their first launch and put into the group linked to the course, scenario scores are
+// passed back to the gradebook of the platform.
+type LTIService struct {
+	platform       *lti.Platform
+	users          repository.UserRepositoryInterface
+	groups         repository.GroupRepositoryInterface
+	launches       repository.LTIRepositoryInterface
+	organizationID uuid.UUID
+	now            func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]pendingLaunch
+}
+
+func NewLTIService(platform *lti.Platform, users repository.UserRepositoryInterface, groups repository.GroupRepositoryInterface,
+	launches repository.LTIRepositoryInterface, options ...func(*LTIService)) *LTIService {
+	s := &LTIService{platform: platform, users: users, groups: groups, launches: launches,
+		organizationID: domain.DefaultOrganizationID, now: time.Now, pending: make(map[string]pendingLaunch)}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithLTIOrganization sets the organization users launching from the platform belong to.
+func WithLTIOrganization(orgID uuid.UUID) func(*LTIService) {
+	return func(s *LTIService) {
+		s.organizationID = orgID
+	}
+}
+
+// KeySet returns the public key set the platform verifies the tool's requests with.
+func (s *LTIService) KeySet() map[string]interface{} {
+	return s.platform.KeySet()
+}
+
+// BeginLogin answers a third-party initiated login with the URL of the platform's authentication
+// endpoint. The nonce stays on the server until the launch.
+func (s *LTIService) BeginLogin(login lti.LoginRequest) (string, error) {
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	url, err := s.platform.AuthRequestURL(login, state, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, launch := range s.pending {
+		if now.After(launch.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = pendingLaunch{nonce: nonce, expiresAt: now.Add(pendingLoginTTL)}
+	return url, nil
+}
+
+// CompleteLaunch verifies the launch token and returns the local user.
+func (s *LTIService) CompleteLaunch(state string, idToken string) (*domain.User, error) {
+	s.mu.Lock()
+	launch, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+	if !ok || s.now().After(launch.expiresAt) {
+		return nil, ErrLTIStateInvalid
+	}
+
+	claims, err := s.platform.VerifyLaunch(idToken, launch.nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.findOrCreateUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active() {
+		return nil, ErrUserInactive
+	}
+	err = s.launches.SaveLaunch(&domain.LTILaunch{
+		UserID:         user.ID,
+		Issuer:         s.platform.Issuer(),
+		ResourceLinkID: claims.ResourceLink.ID,
+		DeploymentID:   claims.DeploymentID,
+		ContextID:      claims.Context.ID,
+		Subject:        claims.Subject,
+		LineItem:       claims.LineItem(),
+		LaunchedAt:     s.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims.Context.ID != "" {
+		if err := s.joinCourseGroup(user, claims); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// PublishScore posts the scenario score of a student to the gradebook column of the activity
+// they last launched the tool from. Students who never came from the platform are skipped.
+func (s *LTIService) PublishScore(orgID uuid.UUID, result *domain.ScenarioResult) error {
+	if orgID != s.organizationID {
+		return nil
+	}
+	launch, err := s.launches.GetLatestLaunch(result.UserID)
+	if err != nil || launch.LineItem == "" || launch.Issuer != s.platform.Issuer() {
+		return nil
+	}
+	return s.platform.PostScore(launch.LineItem, lti.Score{
+		UserID:           launch.Subject,
+		ScoreGiven:       result.Score() * 100,
+		ScoreMaximum:     100,
+		Comment:          fmt.Sprintf("%d of %d phrases recognized", result.Passed, result.Total),
+		ActivityProgress: lti.ActivityCompleted,
+		GradingProgress:  lti.GradingFullyGraded,
+		Timestamp:        s.now(),
+	})
+}
+
+func (s *LTIService) findOrCreateUser(claims *lti.LaunchClaims) (*domain.User, error) {
+	issuer := s.platform.Issuer()
+	if user, err := s.users.GetByIdentity(issuer, claims.Subject); err == nil {
+		return user, nil
+	}
+
+	user := &domain.User{
+		OrganizationID: s.organizationID,
+		Name:           claims.Name,
+		Login:          claims.Email,
+		Role:           domain.RoleStudent,
+	}
+	if user.Login == "" {
+		user.Login = "lti-" + claims.Subject
+	}
+	if user.Name == "" {
+		user.Name = user.Login
+	}
+	if claims.IsInstructor() {
+		user.Role = domain.RoleTeacher
+	}
+	// The password stays empty, so the account can only be used through the platform.
+	id, err := s.users.CreateWithIdentity(user, issuer, claims.Subject)
+	if errors.Is(err, repository.ErrLoginTaken) {
+		return nil, ErrLTILoginConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.users.GetByID(id)
+}
+
+// joinCourseGroup puts students into the group of the course. The group is created on the first
+// launch of an instructor, who becomes its teacher; students who launched before are enrolled then.
+func (s *LTIService) joinCourseGroup(user *domain.User, claims *lti.LaunchClaims) error {
+	issuer := s.platform.Issuer()
+	groupID, err := s.launches.GetCourseGroup(issuer, claims.Context.ID)
+	if err == nil {
+		if user.Role != domain.RoleStudent {
+			return nil
+		}
+		return s.groups.AddMember(groupID, user.ID, s.now())
+	}
+	if !claims.IsInstructor() || user.Role == domain.RoleStudent {
+		return nil
+	}
+
+	title := claims.Context.Title
+	if title == "" {
+		title = claims.Context.Label
+	}
+	if title == "" {
+		title = claims.Context.ID
+	}
+	groupID, err = s.groups.Create(&domain.Group{Title: title, TeacherID: user.ID, CreatedAt: s.now()})
+	if err != nil {
+		return err
+	}
+	if err := s.launches.LinkCourseGroup(issuer, claims.Context.ID, groupID); err != nil {
+		return err
+	}
+	students, err := s.launches.GetCourseStudents(issuer, claims.Context.ID)
+	if err != nil {
+		return err
+	}
+	for _, studentID := range students {
+		if err := s.groups.AddMember(groupID, studentID, s.now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"diplom/internal/domain"
+	"diplom/internal/lti"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockLTIRepository struct {
+	mock.Mock
+}
+
+func (m *MockLTIRepository) GetCourseGroup(issuer string, contextID string) (uuid.UUID, error) {
+	args := m.Called(issuer, contextID)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockLTIRepository) LinkCourseGroup(issuer string, contextID string, groupID uuid.UUID) error {
+	args := m.Called(issuer, contextID, groupID)
+	return args.Error(0)
+}
+
+func (m *MockLTIRepository) SaveLaunch(launch *domain.LTILaunch) error {
+	args := m.Called(launch)
+	return args.Error(0)
+}
+
+func (m *MockLTIRepository) GetLatestLaunch(userID uuid.UUID) (*domain.LTILaunch, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*domain.LTILaunch), args.Error(1)
+}
+
+func (m *MockLTIRepository) GetCourseStudents(issuer string, contextID string) ([]uuid.UUID, error) {
+	args := m.Called(issuer, contextID)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+const (
+	testLineItem   = "/mod/lti/services.php/2/lineitems/7/lineitem?type_id=1"
+	testDeployment = "1"
+)
+
+// mockPlatform is a minimal LTI 1.3 platform: it publishes its key set, signs launch tokens,
+// issues grade service tokens for a valid client assertion and records posted scores.
+type mockPlatform struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	toolKey *rsa.PrivateKey
+
+	mu     sync.Mutex
+	claims jwt.MapClaims
+	scores []lti.Score
+}
+
+func newMockPlatform(t *testing.T) *mockPlatform {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	toolKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockPlatform{key: key, toolKey: toolKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "platform-key", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_, err := jwt.Parse(r.PostForm.Get("client_assertion"), func(*jwt.Token) (interface{}, error) {
+			return &toolKey.PublicKey, nil
+		}, jwt.WithIssuer("diplom"), jwt.WithSubject("diplom"), jwt.WithAudience(m.server.URL+"/token"))
+		if err != nil || r.PostForm.Get("scope") != lti.ScoreScope {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "platform-token", "expires_in": 3600})
+	})
+	mux.HandleFunc("/mod/lti/services.php/2/lineitems/7/lineitem/scores", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer platform-token" ||
+			r.Header.Get("Content-Type") != "application/vnd.ims.lis.v1.score+json" || r.URL.Query().Get("type_id") != "1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var score lti.Score
+		_ = json.NewDecoder(r.Body).Decode(&score)
+		m.mu.Lock()
+		m.scores = append(m.scores, score)
+		m.mu.Unlock()
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// launch plays the platform answering the authentication request: it signs a launch token for
+// the nonce of the request.
+func (m *mockPlatform) launch(t *testing.T, authURL string) (string, string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "id_token", query.Get("response_type"))
+	assert.Equal(t, "form_post", query.Get("response_mode"))
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   "diplom",
+		"sub":   "moodle-user-5",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": query.Get("nonce"),
+		"email": "petrov@school.example",
+		"name":  "Petr Petrov",
+		"https://purl.imsglobal.org/spec/lti/claim/message_type":  lti.MessageTypeResourceLink,
+		"https://purl.imsglobal.org/spec/lti/claim/version":       lti.Version,
+		"https://purl.imsglobal.org/spec/lti/claim/deployment_id": testDeployment,
+		"https://purl.imsglobal.org/spec/lti/claim/resource_link": map[string]string{"id": "activity-3"},
+		"https://purl.imsglobal.org/spec/lti/claim/context":       map[string]string{"id": "course-12", "title": "Radio Phraseology"},
+		"https://purl.imsglobal.org/spec/lti/claim/roles":         []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Learner"},
+		"https://purl.imsglobal.org/spec/lti-ags/claim/endpoint": map[string]interface{}{
+			"scope":    []string{lti.ScoreScope},
+			"lineitem": m.server.URL + testLineItem,
+		},
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "platform-key"
+	signed, err := token.SignedString(m.key)
+	require.NoError(t, err)
+	return query.Get("state"), signed
+}
+
+func (m *mockPlatform) loginRequest() lti.LoginRequest {
+	return lti.LoginRequest{Issuer: m.server.URL, LoginHint: "5", ClientID: "diplom", LTIDeploymentID: testDeployment}
+}
+
+func newTestLTIService(platform *mockPlatform, users *MockUserRepository, groups *MockGroupRepository, launches *MockLTIRepository) *LTIService {
+	return NewLTIService(lti.NewPlatform(lti.Config{
+		Issuer:       platform.server.URL,
+		ClientID:     "diplom",
+		DeploymentID: testDeployment,
+		AuthLoginURL: platform.server.URL + "/auth",
+		AuthTokenURL: platform.server.URL + "/token",
+		KeySetURL:    platform.server.URL + "/jwks",
+		LaunchURL:    "http://localhost:8080/api/v1/lti/launch",
+		KeyID:        "tool-key",
+	}, platform.toolKey, platform.server.Client()), users, groups, launches)
+}
+
+func launchWithLogin(t *testing.T, service *LTIService, platform *mockPlatform) (*domain.User, error) {
+	authURL, err := service.BeginLogin(platform.loginRequest())
+	require.NoError(t, err)
+	state, idToken := platform.launch(t, authURL)
+	return service.CompleteLaunch(state, idToken)
+}
+
+func TestLTIService_StudentLaunch(t *testing.T) {
+	platform := newMockPlatform(t)
+	users := new(MockUserRepository)
+	groups := new(MockGroupRepository)
+	launches := new(MockLTIRepository)
+	service := newTestLTIService(platform, users, groups, launches)
+	student := &domain.User{ID: uuid.New(), OrganizationID: domain.DefaultOrganizationID, Login: "petrov@school.example", Role: domain.RoleStudent}
+	groupID := uuid.New()
+
+	users.On("GetByIdentity", platform.server.URL, "moodle-user-5").Return((*domain.User)(nil), errors.New("no rows"))
+	users.On("CreateWithIdentity", mock.MatchedBy(func(u *domain.User) bool {
+		return u.Login == "petrov@school.example" && u.Name == "Petr Petrov" && u.Role == domain.RoleStudent &&
+			u.OrganizationID == domain.DefaultOrganizationID && u.Password == ""
+	}), platform.server.URL, "moodle-user-5").Return(student.ID, nil)
+	users.On("GetByID", student.ID).Return(student, nil)
+	launches.On("SaveLaunch", mock.MatchedBy(func(l *domain.LTILaunch) bool {
+		return l.UserID == student.ID && l.ResourceLinkID == "activity-3" && l.ContextID == "course-12" &&
+			l.LineItem == platform.server.URL+testLineItem && l.Subject == "moodle-user-5"
+	})).Return(nil)
+	launches.On("GetCourseGroup", platform.server.URL, "course-12").Return(groupID, nil)
+	groups.On("AddMember", groupID, student.ID, mock.AnythingOfType("time.Time")).Return(nil)
+
+	user, err := launchWithLogin(t, service, platform)
+
+	require.NoError(t, err)
+	assert.Equal(t, student, user)
+	users.AssertExpectations(t)
+	launches.AssertExpectations(t)
+	groups.AssertExpectations(t)
+}
+
+func TestLTIService_InstructorCreatesCourseGroup(t *testing.T) {
+	platform := newMockPlatform(t)
+	platform.claims = jwt.MapClaims{
+		"https://purl.imsglobal.org/spec/lti/claim/roles": []string{"http://purl.imsglobal.org/vocab/lis/v2/membership#Instructor"},
+	}
+	users := new(MockUserRepository)
+	groups := new(MockGroupRepository)
+	launches := new(MockLTIRepository)
+	service := newTestLTIService(platform, users, groups, launches)
+	teacher := &domain.User{ID: uuid.New(), OrganizationID: domain.DefaultOrganizationID, Role: domain.RoleTeacher}
+	earlyStudent := uuid.New()
+	groupID := uuid.New()
+
+	users.On("GetByIdentity", platform.server.URL, "moodle-user-5").Return(teacher, nil)
+	launches.On("SaveLaunch", mock.AnythingOfType("*domain.LTILaunch")).Return(nil)
+	launches.On("GetCourseGroup", platform.server.URL, "course-12").Return(uuid.Nil, errors.New("no rows"))
+	groups.On("Create", mock.MatchedBy(func(g *domain.Group) bool {
+		return g.Title == "Radio Phraseology" && g.TeacherID == teacher.ID
+	})).Return(groupID, nil)
+	launches.On("LinkCourseGroup", platform.server.URL, "course-12", groupID).Return(nil)
+	launches.On("GetCourseStudents", platform.server.URL, "course-12").Return([]uuid.UUID{earlyStudent}, nil)
+	groups.On("AddMember", groupID, earlyStudent, mock.AnythingOfType("time.Time")).Return(nil)
+
+	_, err := launchWithLogin(t, service, platform)
+
+	require.NoError(t, err)
+	groups.AssertExpectations(t)
+	launches.AssertExpectations(t)
+	users.AssertNotCalled(t, "CreateWithIdentity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLTIService_StudentWaitsForCourseGroup(t *testing.T) {
+	platform := newMockPlatform(t)
+	users := new(MockUserRepository)
+	groups := new(MockGroupRepository)
+	launches := new(MockLTIRepository)
+	service := newTestLTIService(platform, users, groups, launches)
+	student := &domain.User{ID: uuid.New(), Role: domain.RoleStudent}
+
+	users.On("GetByIdentity", platform.server.URL, "moodle-user-5").Return(student, nil)
+	launches.On("SaveLaunch", mock.AnythingOfType("*domain.LTILaunch")).Return(nil)
+	launches.On("GetCourseGroup", platform.server.URL, "course-12").Return(uuid.Nil, errors.New("no rows"))
+
+	_, err := launchWithLogin(t, service, platform)
+
+	require.NoError(t, err)
+	groups.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestLTIService_RejectsBadLaunches(t *testing.T) {
+	cases := map[string]jwt.MapClaims{
+		"wrong audience":   {"aud": "another-tool"},
+		"wrong issuer":     {"iss": "https://evil.example"},
+		"expired":          {"exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong nonce":      {"nonce": "replayed"},
+		"wrong deployment": {"https://purl.imsglobal.org/spec/lti/claim/deployment_id": "2"},
+		"wrong version":    {"https://purl.imsglobal.org/spec/lti/claim/version": "1.1"},
+		"deep linking":     {"https://purl.imsglobal.org/spec/lti/claim/message_type": "LtiDeepLinkingRequest"},
+	}
+	for name, claims := range cases {
+		t.Run(name, func(t *testing.T) {
+			platform := newMockPlatform(t)
+			platform.claims = claims
+			users := new(MockUserRepository)
+			service := newTestLTIService(platform, users, new(MockGroupRepository), new(MockLTIRepository))
+
+			_, err := launchWithLogin(t, service, platform)
+
+			assert.ErrorIs(t, err, lti.ErrInvalidLaunch)
+			users.AssertNotCalled(t, "GetByIdentity", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("state is used once", func(t *testing.T) {
+		platform := newMockPlatform(t)
+		users := new(MockUserRepository)
+		launches := new(MockLTIRepository)
+		service := newTestLTIService(platform, users, new(MockGroupRepository), launches)
+		users.On("GetByIdentity", platform.server.URL, "moodle-user-5").Return(&domain.User{ID: uuid.New(), Role: domain.RoleTeacher}, nil)
+		launches.On("SaveLaunch", mock.AnythingOfType("*domain.LTILaunch")).Return(nil)
+		launches.On("GetCourseGroup", platform.server.URL, "course-12").Return(uuid.New(), nil)
+
+		authURL, err := service.BeginLogin(platform.loginRequest())
+		require.NoError(t, err)
+		state, idToken := platform.launch(t, authURL)
+		_, err = service.CompleteLaunch(state, idToken)
+		require.NoError(t, err)
+		_, err = service.CompleteLaunch(state, idToken)
+
+		assert.ErrorIs(t, err, ErrLTIStateInvalid)
+	})
+
+	t.Run("login from an unknown platform", func(t *testing.T) {
+		platform := newMockPlatform(t)
+		service := newTestLTIService(platform, new(MockUserRepository), new(MockGroupRepository), new(MockLTIRepository))
+		login := platform.loginRequest()
+		login.Issuer = "https://evil.example"
+
+		_, err := service.BeginLogin(login)
+
+		assert.ErrorIs(t, err, lti.ErrUnknownIssuer)
+	})
+}
+
+func TestLTIService_PublishScore(t *testing.T) {
+	platform := newMockPlatform(t)
+	launches := new(MockLTIRepository)
+	service := newTestLTIService(platform, new(MockUserRepository), new(MockGroupRepository), launches)
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	t.Run("score is posted to the line item", func(t *testing.T) {
+		result := &domain.ScenarioResult{ScenarioID: uuid.New(), UserID: uuid.New(), Total: 4, Passed: 3}
+		launches.On("GetLatestLaunch", result.UserID).Return(&domain.LTILaunch{
+			UserID: result.UserID, Issuer: platform.server.URL, Subject: "moodle-user-5", LineItem: platform.server.URL + testLineItem,
+		}, nil)
+
+		err := service.PublishScore(domain.DefaultOrganizationID, result)
+		require.NoError(t, err)
+		err = service.PublishScore(domain.DefaultOrganizationID, result)
+		require.NoError(t, err)
+
+		require.Len(t, platform.scores, 2)
+		score := platform.scores[0]
+		assert.Equal(t, "moodle-user-5", score.UserID)
+		assert.Equal(t, 75.0, score.ScoreGiven)
+		assert.Equal(t, 100.0, score.ScoreMaximum)
+		assert.Equal(t, lti.GradingFullyGraded, score.GradingProgress)
+		assert.True(t, now.Equal(score.Timestamp))
+	})
+
+	t.Run("student without a launch is skipped", func(t *testing.T) {
+		result := &domain.ScenarioResult{UserID: uuid.New(), Total: 1, Passed: 1}
+		launches.On("GetLatestLaunch", result.UserID).Return((*domain.LTILaunch)(nil), errors.New("no rows"))
+
+		err := service.PublishScore(domain.DefaultOrganizationID, result)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("another organization is skipped", func(t *testing.T) {
+		err := service.PublishScore(uuid.New(), &domain.ScenarioResult{UserID: uuid.New()})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"crypto/rand"
+	"diplom/internal/domain"
+	"diplom/internal/oidc"
+	"diplom/internal/repository"
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOIDCStateInvalid  = errors.New("login request is unknown or expired, start the login again")
+	ErrOIDCLoginConflict = errors.New("a local account with this login already exists")
+)
+
+// pendingLoginTTL is how long the user has to finish logging in at the identity provider.
+const pendingLoginTTL = 10 * time.Minute
+
+type pendingLogin struct {
+	nonce        string
+	codeVerifier string
+	expiresAt    time.Time
+}
+
+// OIDCService logs users in through an external OpenID Connect provider. Users are matched by
+// issuer and subject and created with the student role in the configured organization on first login.
+type OIDCService struct {
+	provider       *oidc.Provider
+	users          repository.UserRepositoryInterface
+	linkByEmail    bool
+	organizationID uuid.UUID
+	now            func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]pendingLogin
+}
+
+// NewOIDCService creates the service. With linkByEmail an existing local account whose login equals
+// the verified email claim is linked to the external identity instead of being reported as a conflict.
+func NewOIDCService(provider *oidc.Provider, users repository.UserRepositoryInterface, linkByEmail bool,
+	options ...func(*OIDCService)) *OIDCService {
+	s := &OIDCService{provider: provider, users: users, linkByEmail: linkByEmail, organizationID: domain.DefaultOrganizationID,
+		now: time.Now, pending: make(map[string]pendingLogin)}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithOIDCOrganization sets the organization new users are created in and existing accounts may be linked from.
+func WithOIDCOrganization(orgID uuid.UUID) func(*OIDCService) {
+	return func(s *OIDCService) {
+		s.organizationID = orgID
+	}
+}
+
+// BeginLogin returns the URL of the identity provider's login page. The state, nonce and
+// PKCE verifier stay on the server until the callback.
+func (s *OIDCService) BeginLogin() (string, error) {
+	state, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", err
+	}
+	url, err := s.provider.AuthCodeURL(state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, login := range s.pending {
+		if now.After(login.expiresAt) {
+			delete(s.pending, key)
+		}
+	}
+	s.pending[state] = pendingLogin{nonce: nonce, codeVerifier: verifier, expiresAt: now.Add(pendingLoginTTL)}
+	return url, nil
+}
+
+// CompleteLogin redeems the authorization code from the callback and returns the local user.
+func (s *OIDCService) CompleteLogin(state string, code string) (*domain.User, error) {
+	s.mu.Lock()
+	login, ok := s.pending[state]
+	delete(s.pending, state)
+	s.mu.Unlock()
+	if !ok || s.now().After(login.expiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	claims, err := s.provider.Exchange(code, login.codeVerifier, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.findOrCreateUser(claims)
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active() {
+		return nil, ErrUserInactive
+	}
+	return user, nil
+}
+
+func (s *OIDCService) findOrCreateUser(claims *oidc.Claims) (*domain.User, error) {
+	issuer := s.provider.Issuer()
+	if user, err := s.users.GetByIdentity(issuer, claims.Subject); err == nil {
+		return user, nil
+	}
+
+	user := &domain.User{
+		OrganizationID: s.organizationID,
+		Name:           claims.Name,
+		Login:          claimsLogin(claims),
+		Role:           domain.RoleStudent,
+	}
+	if user.Name == "" {
+		user.Name = user.Login
+	}
+	if existing, err := s.users.Login(user.Login); err == nil {
+		if !s.linkByEmail || !claims.EmailVerified || !strings.EqualFold(existing.Login, claims.Email) ||
+			existing.OrganizationID != s.organizationID {
+			return nil, ErrOIDCLoginConflict
+		}
+		if err := s.users.LinkIdentity(existing.ID, issuer, claims.Subject); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+
+	// The password stays empty, so the account can't be used with the password login.
+	id, err := s.users.CreateWithIdentity(user, issuer, claims.Subject)
+	if errors.Is(err, repository.ErrLoginTaken) {
+		return nil, ErrOIDCLoginConflict
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.users.GetByID(id)
+}
+
+// claimsLogin picks the login for a new user: the email, then the preferred username, then the subject.
+func claimsLogin(claims *oidc.Claims) string {
+	switch {
+	case claims.Email != "":
+		return claims.Email
+	case claims.PreferredUsername != "":
+		return claims.PreferredUsername
+	default:
+		return claims.Subject
+	}
+}
+
+func randomString() (string, error) {
+	data := make([]byte, 32)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
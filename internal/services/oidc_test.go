@@ -0,0 +1,245 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"diplom/internal/domain"
+	"diplom/internal/oidc"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIssuer is a minimal OpenID Connect provider: discovery, JWKS and a token endpoint that
+// checks the PKCE verifier and issues an ID token with the configured claims.
+type mockIssuer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu         sync.Mutex
+	challenges map[string]string // code -> code challenge
+	nonces     map[string]string // code -> nonce
+	claims     jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	m := &mockIssuer{key: key, challenges: map[string]string{}, nonces: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "test-key", "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		code := r.PostForm.Get("code")
+		m.mu.Lock()
+		challenge, nonce := m.challenges[code], m.nonces[code]
+		m.mu.Unlock()
+		if challenge == "" || oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.sign(t, nonce), "token_type": "Bearer"})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize plays the user logging in: it records the PKCE challenge and returns a code.
+func (m *mockIssuer) authorize(t *testing.T, authURL string) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	code := uuid.NewString()
+	m.mu.Lock()
+	m.challenges[code] = query.Get("code_challenge")
+	m.nonces[code] = query.Get("nonce")
+	m.mu.Unlock()
+	return code
+}
+
+func (m *mockIssuer) sign(t *testing.T, nonce string) string {
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   "diplom",
+		"sub":   "student-42",
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": nonce,
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(m.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func newTestOIDCService(issuer *mockIssuer, users *MockUserRepository, linkByEmail bool) *OIDCService {
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:      issuer.server.URL,
+		ClientID:    "diplom",
+		RedirectURL: "http://localhost:8080/api/v1/auth/oidc/callback",
+	}, issuer.server.Client())
+	return NewOIDCService(provider, users, linkByEmail)
+}
+
+func loginWithState(t *testing.T, service *OIDCService, issuer *mockIssuer) (string, string) {
+	authURL, err := service.BeginLogin()
+	require.NoError(t, err)
+	parsed, _ := url.Parse(authURL)
+	return parsed.Query().Get("state"), issuer.authorize(t, authURL)
+}
+
+func TestOIDCService_FirstLoginCreatesUser(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.claims = jwt.MapClaims{"email": "ivanov@uni.example", "email_verified": true, "name": "Ivan Ivanov"}
+	users := new(MockUserRepository)
+	service := newTestOIDCService(issuer, users, false)
+	created := &domain.User{ID: uuid.New(), Name: "Ivan Ivanov", Login: "ivanov@uni.example", Role: domain.RoleStudent}
+	users.On("GetByIdentity", issuer.server.URL, "student-42").Return((*domain.User)(nil), errors.New("no rows"))
+	users.On("Login", "ivanov@uni.example").Return((*domain.User)(nil), errors.New("no rows"))
+	users.On("CreateWithIdentity", mock.MatchedBy(func(u *domain.User) bool {
+		return u.Login == "ivanov@uni.example" && u.Name == "Ivan Ivanov" && u.Role == domain.RoleStudent && u.Password == ""
+	}), issuer.server.URL, "student-42").Return(created.ID, nil)
+	users.On("GetByID", created.ID).Return(created, nil)
+
+	state, code := loginWithState(t, service, issuer)
+	user, err := service.CompleteLogin(state, code)
+
+	require.NoError(t, err)
+	assert.Equal(t, created, user)
+	users.AssertExpectations(t)
+
+	t.Run("state can't be reused", func(t *testing.T) {
+		_, err := service.CompleteLogin(state, code)
+
+		assert.ErrorIs(t, err, ErrOIDCStateInvalid)
+	})
+}
+
+func TestOIDCService_ExistingIdentity(t *testing.T) {
+	issuer := newMockIssuer(t)
+	users := new(MockUserRepository)
+	service := newTestOIDCService(issuer, users, false)
+	existing := &domain.User{ID: uuid.New(), Login: "ivanov@uni.example", Role: domain.RoleTeacher}
+	users.On("GetByIdentity", issuer.server.URL, "student-42").Return(existing, nil)
+
+	state, code := loginWithState(t, service, issuer)
+	user, err := service.CompleteLogin(state, code)
+
+	require.NoError(t, err)
+	assert.Equal(t, existing, user)
+	users.AssertNotCalled(t, "CreateWithIdentity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_LocalAccountConflict(t *testing.T) {
+	issuer := newMockIssuer(t)
+	issuer.claims = jwt.MapClaims{"email": "ivanov@uni.example", "email_verified": true}
+	local := &domain.User{ID: uuid.New(), OrganizationID: domain.DefaultOrganizationID, Login: "ivanov@uni.example", Role: domain.RoleStudent}
+
+	t.Run("conflict without linking", func(t *testing.T) {
+		users := new(MockUserRepository)
+		service := newTestOIDCService(issuer, users, false)
+		users.On("GetByIdentity", issuer.server.URL, "student-42").Return((*domain.User)(nil), errors.New("no rows"))
+		users.On("Login", "ivanov@uni.example").Return(local, nil)
+
+		state, code := loginWithState(t, service, issuer)
+		_, err := service.CompleteLogin(state, code)
+
+		assert.ErrorIs(t, err, ErrOIDCLoginConflict)
+	})
+
+	t.Run("verified email is linked", func(t *testing.T) {
+		users := new(MockUserRepository)
+		service := newTestOIDCService(issuer, users, true)
+		users.On("GetByIdentity", issuer.server.URL, "student-42").Return((*domain.User)(nil), errors.New("no rows"))
+		users.On("Login", "ivanov@uni.example").Return(local, nil)
+		users.On("LinkIdentity", local.ID, issuer.server.URL, "student-42").Return(nil)
+
+		state, code := loginWithState(t, service, issuer)
+		user, err := service.CompleteLogin(state, code)
+
+		assert.NoError(t, err)
+		assert.Equal(t, local, user)
+	})
+	t.Run("account of another organization isn't linked", func(t *testing.T) {
+		users := new(MockUserRepository)
+		service := newTestOIDCService(issuer, users, true)
+		service.organizationID = uuid.New()
+		users.On("GetByIdentity", issuer.server.URL, "student-42").Return((*domain.User)(nil), errors.New("no rows"))
+		users.On("Login", "ivanov@uni.example").Return(local, nil)
+
+		state, code := loginWithState(t, service, issuer)
+		_, err := service.CompleteLogin(state, code)
+
+		assert.ErrorIs(t, err, ErrOIDCLoginConflict)
+		users.AssertNotCalled(t, "LinkIdentity", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestOIDCService_RejectsBadTokens(t *testing.T) {
+	cases := map[string]jwt.MapClaims{
+		"wrong audience": {"aud": "another-client"},
+		"wrong issuer":   {"iss": "https://evil.example"},
+		"expired":        {"exp": time.Now().Add(-time.Hour).Unix()},
+		"wrong nonce":    {"nonce": "replayed"},
+	}
+	for name, claims := range cases {
+		t.Run(name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			issuer.claims = claims
+			users := new(MockUserRepository)
+			service := newTestOIDCService(issuer, users, false)
+
+			state, code := loginWithState(t, service, issuer)
+			_, err := service.CompleteLogin(state, code)
+
+			assert.ErrorIs(t, err, oidc.ErrInvalidIDToken)
+			users.AssertNotCalled(t, "GetByIdentity", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("wrong PKCE verifier", func(t *testing.T) {
+		issuer := newMockIssuer(t)
+		users := new(MockUserRepository)
+		service := newTestOIDCService(issuer, users, false)
+
+		state, code := loginWithState(t, service, issuer)
+		issuer.challenges[code] = oidc.CodeChallenge("something else")
+		_, err := service.CompleteLogin(state, code)
+
+		assert.ErrorIs(t, err, oidc.ErrExchangeFailed)
+	})
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"errors"
+	"github.com/google/uuid"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOrganizationNotFound = errors.New("organization not found")
+	ErrOrganizationNotEmpty = errors.New("organization still has users or content")
+	ErrDefaultOrganization  = errors.New("the default organization can't be deleted")
+	ErrInvalidSlug          = errors.New("slug must consist of lowercase letters, digits and dashes")
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrganizationService manages tenants. It is only available to super-admins.
+type OrganizationService struct {
+	repo repository.OrganizationRepositoryInterface
+	now  func() time.Time
+}
+
+func NewOrganizationService(repo repository.OrganizationRepositoryInterface) *OrganizationService {
+	return &OrganizationService{repo: repo, now: time.Now}
+}
+
+func (s *OrganizationService) CreateOrganization(name string, slug string) (*domain.Organization, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+	org := &domain.Organization{
+		ID:        uuid.New(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: s.now(),
+	}
+	if err := s.repo.Create(org); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) GetOrganization(id uuid.UUID) (*domain.Organization, error) {
+	org, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) GetOrganizationBySlug(slug string) (*domain.Organization, error) {
+	org, err := s.repo.GetBySlug(strings.ToLower(strings.TrimSpace(slug)))
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return org, nil
+}
+
+func (s *OrganizationService) ListOrganizations() ([]domain.Organization, error) {
+	return s.repo.GetAll()
+}
+
+func (s *OrganizationService) RenameOrganization(id uuid.UUID, name string) (*domain.Organization, error) {
+	org, err := s.GetOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+	org.Name = name
+	return org, s.repo.Update(org)
+}
+
+// DeleteOrganization removes an organization that no longer has users or content.
+func (s *OrganizationService) DeleteOrganization(id uuid.UUID) error {
+	if id == domain.DefaultOrganizationID {
+		return ErrDefaultOrganization
+	}
+	if _, err := s.GetOrganization(id); err != nil {
+		return err
+	}
+	hasContent, err := s.repo.HasContent(id)
+	if err != nil {
+		return err
+	}
+	if hasContent {
+		return ErrOrganizationNotEmpty
+	}
+	return s.repo.Delete(id)
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) Create(org *domain.Organization) error {
+	args := m.Called(org)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) GetByID(id uuid.UUID) (*domain.Organization, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetBySlug(slug string) (*domain.Organization, error) {
+	args := m.Called(slug)
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetAll() ([]domain.Organization, error) {
+	args := m.Called()
+	return args.Get(0).([]domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) Update(org *domain.Organization) error {
+	args := m.Called(org)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) Delete(id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) HasContent(id uuid.UUID) (bool, error) {
+	args := m.Called(id)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	repo := new(MockOrganizationRepository)
+	service := NewOrganizationService(repo)
+
+	t.Run("slug is normalized", func(t *testing.T) {
+		repo.On("Create", mock.AnythingOfType("*domain.Organization")).Return(nil).Once()
+
+		org, err := service.CreateOrganization("Aviation School", " Aviation-School ")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "aviation-school", org.Slug)
+		assert.NotEqual(t, uuid.Nil, org.ID)
+	})
+
+	t.Run("invalid slug", func(t *testing.T) {
+		for _, slug := range []string{"", "two words", "-edge", "under_score"} {
+			_, err := service.CreateOrganization("School", slug)
+
+			assert.ErrorIs(t, err, ErrInvalidSlug, slug)
+		}
+	})
+
+	t.Run("slug taken", func(t *testing.T) {
+		repo.On("Create", mock.AnythingOfType("*domain.Organization")).Return(repository.ErrSlugTaken).Once()
+
+		_, err := service.CreateOrganization("Default", "default")
+
+		assert.ErrorIs(t, err, repository.ErrSlugTaken)
+	})
+}
+
+func TestOrganizationService_DeleteOrganization(t *testing.T) {
+	repo := new(MockOrganizationRepository)
+	service := NewOrganizationService(repo)
+
+	t.Run("default organization is protected", func(t *testing.T) {
+		err := service.DeleteOrganization(domain.DefaultOrganizationID)
+
+		assert.ErrorIs(t, err, ErrDefaultOrganization)
+		repo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+
+	t.Run("unknown organization", func(t *testing.T) {
+		id := uuid.New()
+		repo.On("GetByID", id).Return((*domain.Organization)(nil), errors.New("no rows"))
+
+		err := service.DeleteOrganization(id)
+
+		assert.ErrorIs(t, err, ErrOrganizationNotFound)
+	})
+
+	t.Run("organization with content", func(t *testing.T) {
+		org := &domain.Organization{ID: uuid.New(), Slug: "busy"}
+		repo.On("GetByID", org.ID).Return(org, nil)
+		repo.On("HasContent", org.ID).Return(true, nil)
+
+		err := service.DeleteOrganization(org.ID)
+
+		assert.ErrorIs(t, err, ErrOrganizationNotEmpty)
+		repo.AssertNotCalled(t, "Delete", org.ID)
+	})
+
+	t.Run("empty organization is deleted", func(t *testing.T) {
+		org := &domain.Organization{ID: uuid.New(), Slug: "empty"}
+		repo.On("GetByID", org.ID).Return(org, nil)
+		repo.On("HasContent", org.ID).Return(false, nil)
+		repo.On("Delete", org.ID).Return(nil)
+
+		err := service.DeleteOrganization(org.ID)
+
+		assert.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "password must " + strings.Join(e.Violations, ", ")
+}
+
+// Validate reports every rule the password breaks, so the user can fix them in one go.
+func (p PasswordPolicy) Validate(password string) error {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var violations []string
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("be at least %d characters long", p.MinLength))
+	}
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "contain a symbol")
+	}
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	t.Run("strong password", func(t *testing.T) {
+		assert.NoError(t, policy.Validate("Tower-123"))
+	})
+
+	t.Run("every violation is reported", func(t *testing.T) {
+		err := policy.Validate("abc")
+
+		var policyErr *PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, []string{
+			"be at least 8 characters long",
+			"contain an uppercase letter",
+			"contain a digit",
+			"contain a symbol",
+		}, policyErr.Violations)
+	})
+
+	t.Run("length counts characters, not bytes", func(t *testing.T) {
+		err := PasswordPolicy{MinLength: 8}.Validate("пароль")
+
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"crypto/rand"
+	"diplom/internal/domain"
+	"diplom/internal/mailer"
+	"diplom/internal/repository"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"net/url"
+	"time"
+)
+
+var (
+	ErrWrongPassword     = errors.New("current password is incorrect")
+	ErrInvalidResetToken = errors.New("reset token is invalid, expired or already used")
+)
+
+type PasswordService struct {
+	users    repository.UserRepositoryInterface
+	resets   repository.PasswordResetRepositoryInterface
+	sessions repository.SessionRepositoryInterface
+	mailer   mailer.Mailer
+	policy   PasswordPolicy
+	resetTTL time.Duration
+	resetURL string
+	now      func() time.Time
+}
+
+func NewPasswordService(users repository.UserRepositoryInterface, resets repository.PasswordResetRepositoryInterface,
+	sessions repository.SessionRepositoryInterface, mailer mailer.Mailer, policy PasswordPolicy,
+	resetTTL time.Duration, resetURL string) *PasswordService {
+	return &PasswordService{users: users, resets: resets, sessions: sessions, mailer: mailer, policy: policy,
+		resetTTL: resetTTL, resetURL: resetURL, now: time.Now}
+}
+
+// ChangePassword sets a new password for a logged-in user who knows the current one.
+func (s *PasswordService) ChangePassword(userID uuid.UUID, currentPassword string, newPassword string) error {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)) != nil {
+		return ErrWrongPassword
+	}
+	if err := s.setPassword(userID, newPassword); err != nil {
+		return err
+	}
+	return s.resets.InvalidateForUser(userID, s.now())
+}
+
+// RequestReset mails a reset link to the user with the given login. Unknown and deactivated
+// accounts are ignored silently so the endpoint can't be used to find out which logins exist.
+func (s *PasswordService) RequestReset(login string) error {
+	user, err := s.users.Login(login)
+	if err != nil || !user.Active() {
+		return nil
+	}
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	reset := &domain.PasswordReset{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.resetTTL),
+	}
+	if err := s.resets.Create(reset); err != nil {
+		return err
+	}
+	link := s.resetURL + "?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(mailer.Message{
+		To:      user.Login,
+		Subject: "Password reset",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo set a new password open the link below. It is valid for %s and can be used once.\n\n%s\n\n"+
+			"If you didn't ask for a password reset, ignore this email.\n", user.Name, s.resetTTL, link),
+	})
+	if err != nil {
+		return fmt.Errorf("can't send password reset email to user %s: %w", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword redeems a reset token. All sessions of the user are revoked afterwards.
+func (s *PasswordService) ResetPassword(token string, newPassword string) error {
+	reset, err := s.resets.GetByTokenHash(hashToken(token))
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	now := s.now()
+	if !reset.Usable(now) {
+		return ErrInvalidResetToken
+	}
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+	used, err := s.resets.MarkUsed(reset.ID, now)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+	if err := s.setPassword(reset.UserID, newPassword); err != nil {
+		return err
+	}
+	if err := s.resets.InvalidateForUser(reset.UserID, now); err != nil {
+		return err
+	}
+	return s.sessions.RevokeAllForUser(reset.UserID, now)
+}
+
+func (s *PasswordService) setPassword(userID uuid.UUID, password string) error {
+	if err := s.policy.Validate(password); err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.users.UpdatePassword(userID, string(hashedPassword))
+}
+
+func newResetToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
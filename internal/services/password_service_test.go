@@ -0,0 +1,197 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/mailer"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) Create(reset *domain.PasswordReset) error {
+	args := m.Called(reset)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetByTokenHash(tokenHash string) (*domain.PasswordReset, error) {
+	args := m.Called(tokenHash)
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) MarkUsed(id uuid.UUID, usedAt time.Time) (bool, error) {
+	args := m.Called(id, usedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) InvalidateForUser(userID uuid.UUID, usedAt time.Time) error {
+	args := m.Called(userID, usedAt)
+	return args.Error(0)
+}
+
+type recordingMailer struct {
+	sent []mailer.Message
+}
+
+func (m *recordingMailer) Send(message mailer.Message) error {
+	m.sent = append(m.sent, message)
+	return nil
+}
+
+func newTestPasswordService(now time.Time) (*PasswordService, *MockUserRepository, *MockPasswordResetRepository,
+	*MockSessionRepository, *recordingMailer) {
+	users := new(MockUserRepository)
+	resets := new(MockPasswordResetRepository)
+	sessions := new(MockSessionRepository)
+	mail := &recordingMailer{}
+	service := NewPasswordService(users, resets, sessions, mail, PasswordPolicy{MinLength: 8}, time.Hour, "https://example.com/reset")
+	service.now = func() time.Time { return now }
+	return service, users, resets, sessions, mail
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	service, users, resets, _, _ := newTestPasswordService(now)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.MinCost)
+	user := &domain.User{ID: uuid.New(), Password: string(hash)}
+	users.On("GetByID", user.ID).Return(user, nil)
+
+	t.Run("wrong current password", func(t *testing.T) {
+		err := service.ChangePassword(user.ID, "guess", "new-password")
+
+		assert.ErrorIs(t, err, ErrWrongPassword)
+	})
+
+	t.Run("weak new password", func(t *testing.T) {
+		err := service.ChangePassword(user.ID, "old-password", "short")
+
+		var policyErr *PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		users.On("UpdatePassword", user.ID, mock.AnythingOfType("string")).Return(nil).Once()
+		resets.On("InvalidateForUser", user.ID, now).Return(nil).Once()
+
+		err := service.ChangePassword(user.ID, "old-password", "new-password")
+
+		assert.NoError(t, err)
+		hashed := users.Calls[len(users.Calls)-1].Arguments.String(1)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashed), []byte("new-password")))
+		resets.AssertExpectations(t)
+	})
+}
+
+func TestPasswordService_RequestReset(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("sends a link with a single-use token", func(t *testing.T) {
+		service, users, resets, _, mail := newTestPasswordService(now)
+		user := &domain.User{ID: uuid.New(), Name: "John", Login: "john@example.com"}
+		users.On("Login", "john@example.com").Return(user, nil)
+		var stored *domain.PasswordReset
+		resets.On("Create", mock.AnythingOfType("*domain.PasswordReset")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(*domain.PasswordReset) }).Return(nil)
+
+		err := service.RequestReset("john@example.com")
+
+		assert.NoError(t, err)
+		assert.Len(t, mail.sent, 1)
+		assert.Equal(t, "john@example.com", mail.sent[0].To)
+		assert.Equal(t, now.Add(time.Hour), stored.ExpiresAt)
+		_, token, found := strings.Cut(mail.sent[0].Body, "https://example.com/reset?token=")
+		assert.True(t, found)
+		token = strings.Fields(token)[0]
+		assert.Equal(t, hashToken(token), stored.TokenHash)
+	})
+
+	t.Run("login with a line break is not mailed", func(t *testing.T) {
+		dir := t.TempDir()
+		users, resets := new(MockUserRepository), new(MockPasswordResetRepository)
+		service := NewPasswordService(users, resets, new(MockSessionRepository), mailer.NewFileMailer(dir, "noreply@example.com"),
+			PasswordPolicy{MinLength: 8}, time.Hour, "https://example.com/reset")
+		login := "john@example.com\r\nBcc: victim@example.com"
+		users.On("Login", login).Return(&domain.User{ID: uuid.New(), Login: login}, nil)
+		resets.On("Create", mock.AnythingOfType("*domain.PasswordReset")).Return(nil)
+
+		err := service.RequestReset(login)
+
+		assert.ErrorIs(t, err, mailer.ErrInvalidHeader)
+		files, _ := os.ReadDir(dir)
+		assert.Empty(t, files)
+	})
+
+	t.Run("unknown login is ignored", func(t *testing.T) {
+		service, users, _, _, mail := newTestPasswordService(now)
+		users.On("Login", "nobody").Return((*domain.User)(nil), errors.New("no rows"))
+
+		err := service.RequestReset("nobody")
+
+		assert.NoError(t, err)
+		assert.Empty(t, mail.sent)
+	})
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+
+	t.Run("success revokes sessions", func(t *testing.T) {
+		service, users, resets, sessions, _ := newTestPasswordService(now)
+		reset := &domain.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: now.Add(time.Minute)}
+		resets.On("GetByTokenHash", hashToken("token")).Return(reset, nil)
+		resets.On("MarkUsed", reset.ID, now).Return(true, nil)
+		resets.On("InvalidateForUser", userID, now).Return(nil)
+		users.On("UpdatePassword", userID, mock.AnythingOfType("string")).Return(nil)
+		sessions.On("RevokeAllForUser", userID, now).Return(nil)
+
+		err := service.ResetPassword("token", "new-password")
+
+		assert.NoError(t, err)
+		sessions.AssertExpectations(t)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		service, _, resets, _, _ := newTestPasswordService(now)
+		reset := &domain.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: now.Add(-time.Minute)}
+		resets.On("GetByTokenHash", hashToken("token")).Return(reset, nil)
+
+		err := service.ResetPassword("token", "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	})
+
+	t.Run("token redeemed concurrently", func(t *testing.T) {
+		service, users, resets, _, _ := newTestPasswordService(now)
+		reset := &domain.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: now.Add(time.Minute)}
+		resets.On("GetByTokenHash", hashToken("token")).Return(reset, nil)
+		resets.On("MarkUsed", reset.ID, now).Return(false, nil)
+
+		err := service.ResetPassword("token", "new-password")
+
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+		users.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything)
+	})
+
+	t.Run("weak password keeps the token", func(t *testing.T) {
+		service, _, resets, _, _ := newTestPasswordService(now)
+		reset := &domain.PasswordReset{ID: uuid.New(), UserID: userID, ExpiresAt: now.Add(time.Minute)}
+		resets.On("GetByTokenHash", hashToken("token")).Return(reset, nil)
+
+		err := service.ResetPassword("token", "short")
+
+		var policyErr *PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		resets.AssertNotCalled(t, "MarkUsed", mock.Anything, mock.Anything)
+	})
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"diplom/internal/domain"
 	"diplom/internal/repository"
+	"diplom/internal/ssml"
 	"github.com/google/uuid"
 )
 
@@ -23,21 +24,45 @@ func NewPhraseService(repo repository.PhraseRepositoryInterface) *PhraseService
 }
 
 func (s *PhraseService) CreatePhrase(phrase *domain.Phrase) (uuid.UUID, error) {
+	if err := normalizeMarkup(phrase); err != nil {
+		return uuid.Nil, err
+	}
 	return s.repo.Create(phrase)
 }
 
-func (s *PhraseService) GetPhraseByID(id uuid.UUID) (*domain.Phrase, error) {
-	return s.repo.GetByID(id)
+func (s *PhraseService) GetPhraseByID(orgID uuid.UUID, id uuid.UUID) (*domain.Phrase, error) {
+	return s.repo.GetByID(orgID, id)
 }
 
 func (s *PhraseService) UpdatePhrase(phrase *domain.Phrase) error {
+	if err := normalizeMarkup(phrase); err != nil {
+		return err
+	}
 	return s.repo.Update(phrase)
 }
 
-func (s *PhraseService) DeletePhrase(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *PhraseService) DeletePhrase(orgID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(orgID, id)
 }
 
-func (s *PhraseService) GetAllPhrases(text string) ([]domain.Phrase, error) {
-	return s.repo.GetAll(text)
+func (s *PhraseService) GetAllPhrases(orgID uuid.UUID, text string) ([]domain.Phrase, error) {
+	return s.repo.GetAll(orgID, text)
+}
+
+// normalizeMarkup validates the speech markup of the phrase and replaces its text with the plain words
+// of the markup, so answers are graded against what is spoken. SSML pasted into the text is treated as
+// markup too.
+func normalizeMarkup(phrase *domain.Phrase) error {
+	if phrase.Markup == "" && ssml.IsSSML(phrase.Text) {
+		phrase.Markup = phrase.Text
+	}
+	if phrase.Markup == "" {
+		return nil
+	}
+	speech, err := ssml.Parse(phrase.Markup)
+	if err != nil {
+		return err
+	}
+	phrase.Text = speech.PlainText()
+	return nil
 }
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"context"
 	"diplom/client"
 	"diplom/internal/domain"
 	"diplom/internal/repository"
+	"diplom/internal/ssml"
+	"diplom/internal/storage"
+	"errors"
 	"fmt"
 	"github.com/go-audio/audio"
 	"github.com/go-audio/wav"
@@ -11,82 +15,121 @@ import (
 	"io"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	//"fmt"
 	"github.com/google/uuid"
 )
 
+var ErrUnknownAccent = errors.New("accent is not in the voice catalog")
+
+// phraseAudioDir is the directory of the synthesized phrases in the audio store.
+const phraseAudioDir = "phrases"
+
 type PhraseStreamService struct {
 	streams   repository.PhraseStreamRepositoryInterface
 	audio     repository.AudioPhraseRepositoryInterface
 	phrase    repository.PhraseRepositoryInterface
-	speechKit *client.YandexSpeechClient
+	voices    repository.VoiceRepositoryInterface
+	speechKit client.SpeechSynthesizer
+	store     *storage.AudioStore
 }
 
 func NewPhraseStreamService(p repository.PhraseStreamRepositoryInterface, a repository.AudioPhraseRepositoryInterface,
-	ph repository.PhraseRepositoryInterface) *PhraseStreamService {
-	return &PhraseStreamService{
+	ph repository.PhraseRepositoryInterface, v repository.VoiceRepositoryInterface, synthesizer client.SpeechSynthesizer,
+	options ...func(*PhraseStreamService)) *PhraseStreamService {
+	s := &PhraseStreamService{
 		streams:   p,
 		audio:     a,
 		phrase:    ph,
-		speechKit: client.NewYandexSpeechClient(),
+		voices:    v,
+		speechKit: synthesizer,
 	}
+	for _, o := range options {
+		o(s)
+	}
+	return s
 }
 
-func (s *PhraseStreamService) CreatePhraseStream(stream *domain.PhraseStream, audio *domain.AudioPhrase) (uuid.UUID, error) {
-	pharse, err := s.phrase.GetByID(stream.PhraseID)
+// WithPhraseAudio sets the store the synthesized phrases are written to. Without it no phrase can be synthesized.
+func WithPhraseAudio(store *storage.AudioStore) func(*PhraseStreamService) {
+	return func(s *PhraseStreamService) {
+		s.store = store
+	}
+}
+
+// CreatePhraseStream synthesizes the phrase into a new file of the audio store, whatever path audio has, and
+// adds it to the scenario. The file is a WAV as the noise is written in it.
+
+func (s *PhraseStreamService) CreatePhraseStream(ctx context.Context, orgID uuid.UUID, stream *domain.PhraseStream, audio *domain.AudioPhrase) (uuid.UUID, error) {
+	pharse, err := s.phrase.GetByID(orgID, stream.PhraseID)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	err = s.speechKit.SynthesizeSpeech(pharse.Text, audio.PathToAudio, audio.Accent)
+	voice, err := s.resolveVoice(orgID, audio.Accent)
 	if err != nil {
 		return uuid.Nil, err
 	}
-	err = addNoise(audio.PathToAudio, audio.Noise)
+	text := pharse.Text
+	if pharse.Markup != "" {
+		speech, err := ssml.Parse(pharse.Markup)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		text = speech.SSML()
+	}
+	audio.PathToAudio, err = s.store.NewPath(phraseAudioDir, ".wav")
 	if err != nil {
 		return uuid.Nil, err
 	}
+	err = s.speechKit.SynthesizeSpeech(ctx, text, audio.PathToAudio, voice)
+	if err == nil {
+		err = addNoise(audio.PathToAudio, audio.Noise)
+	}
+	if err != nil {
+		os.Remove(audio.PathToAudio)
+		return uuid.Nil, err
+	}
+	audio.OrganizationID = orgID
 	audioID, err := s.audio.Create(audio)
 	if err != nil {
 		return uuid.Nil, err
 	}
 	stream.AudioPhraseID = audioID
-	return s.streams.Create(stream)
+	return s.streams.Create(orgID, stream)
 }
 
-func (s *PhraseStreamService) UpdatePhraseStream(id uuid.UUID, answerID uuid.UUID, status string) error {
-	return s.streams.Update(id, answerID, status)
+// resolveVoice looks the accent up in the voice catalog of the organization. Without an accent the
+// provider's default voice is used.
+func (s *PhraseStreamService) resolveVoice(orgID uuid.UUID, accent string) (client.Voice, error) {
+	accent = strings.ToLower(strings.TrimSpace(accent))
+	if accent == "" {
+		return client.Voice{}, nil
+	}
+	voice, err := s.voices.GetByAccent(orgID, accent)
+	if err != nil {
+		return client.Voice{}, fmt.Errorf("%w: %q", ErrUnknownAccent, accent)
+	}
+	return client.Voice{Name: voice.Voice, Language: voice.Language, Emotion: voice.Emotion, Speed: voice.Speed}, nil
 }
 
-func (s *PhraseStreamService) GetStudentPhrases(userID uuid.UUID) ([]string, error) {
-	return s.streams.GetStudentPhrases(userID)
+func (s *PhraseStreamService) UpdatePhraseStream(orgID uuid.UUID, id uuid.UUID, answerID uuid.UUID, status string) error {
+	return s.streams.Update(orgID, id, answerID, status)
 }
 
-func (s *PhraseStreamService) GetStudentProgress(userID uuid.UUID) ([][]string, error) {
-	return s.streams.GetStudentProgress(userID)
+func (s *PhraseStreamService) GetStudentPhrases(orgID uuid.UUID, userID uuid.UUID) ([]string, error) {
+	return s.streams.GetStudentPhrases(orgID, userID)
 }
 
-func addNoise(inputPath string, noiseLevel float64) error {
-	f, err := os.Open(inputPath)
-	if err != nil {
-		return fmt.Errorf("ошибка открытия MP3: %w", err)
-	}
-	defer f.Close()
-
-	decoder, err := mp3.NewDecoder(f)
-	if err != nil {
-		return fmt.Errorf("ошибка создания декодера: %w", err)
-	}
+func (s *PhraseStreamService) GetStudentProgress(orgID uuid.UUID, userID uuid.UUID) ([][]string, error) {
+	return s.streams.GetStudentProgress(orgID, userID)
+}
 
-	pcmBytes, err := io.ReadAll(decoder)
+func addNoise(inputPath string, noiseLevel float64) error {
+	pcm, format, err := decodePCM(inputPath)
 	if err != nil {
-		return fmt.Errorf("ошибка чтения PCM данных: %w", err)
-	}
-
-	pcm := make([]int16, len(pcmBytes)/2)
-	for i := 0; i < len(pcm); i++ {
-		pcm[i] = int16(pcmBytes[2*i]) | int16(pcmBytes[2*i+1])<<8
+		return err
 	}
 
 	rand.Seed(time.Now().UnixNano())
@@ -104,12 +147,12 @@ func addNoise(inputPath string, noiseLevel float64) error {
 	}
 	defer outFile.Close()
 
-	enc := wav.NewEncoder(outFile, 44100, 16, 2, 1) // Частота и каналы нужно брать из mp3, упрощено 44.1kHz, стерео
+	enc := wav.NewEncoder(outFile, format.SampleRate, 16, format.NumChannels, 1)
 	defer enc.Close()
 
 	buf := &audio.IntBuffer{
 		Data:           make([]int, len(pcm)),
-		Format:         &audio.Format{NumChannels: 2, SampleRate: 44100},
+		Format:         format,
 		SourceBitDepth: 16,
 	}
 	for i, v := range pcm {
@@ -123,6 +166,51 @@ func addNoise(inputPath string, noiseLevel float64) error {
 	return nil
 }
 
+// decodePCM reads 16-bit samples from an MP3 file, as returned by SpeechKit, or a WAV file, as written
+// by the offline provider.
+func decodePCM(inputPath string) ([]int16, *audio.Format, error) {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка открытия аудиофайла: %w", err)
+	}
+	defer f.Close()
+
+	if wavDecoder := wav.NewDecoder(f); wavDecoder.IsValidFile() {
+		buf, err := wavDecoder.FullPCMBuffer()
+		if err != nil {
+			return nil, nil, fmt.Errorf("ошибка чтения WAV: %w", err)
+		}
+		if wavDecoder.BitDepth != 16 {
+			return nil, nil, fmt.Errorf("неподдерживаемая разрядность WAV: %d", wavDecoder.BitDepth)
+		}
+		pcm := make([]int16, len(buf.Data))
+		for i, v := range buf.Data {
+			pcm[i] = int16(v)
+		}
+		return pcm, buf.Format, nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+
+	decoder, err := mp3.NewDecoder(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка создания декодера: %w", err)
+	}
+
+	pcmBytes, err := io.ReadAll(decoder)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка чтения PCM данных: %w", err)
+	}
+
+	pcm := make([]int16, len(pcmBytes)/2)
+	for i := 0; i < len(pcm); i++ {
+		pcm[i] = int16(pcmBytes[2*i]) | int16(pcmBytes[2*i+1])<<8
+	}
+	// go-mp3 always decodes to 16-bit stereo.
+	return pcm, &audio.Format{NumChannels: 2, SampleRate: decoder.SampleRate()}, nil
+}
+
 func clamp(val, min, max int) int {
 	if val < min {
 		return min
@@ -1,67 +1,405 @@
 package services
 
 import (
+	"context"
+	"diplom/client"
 	"diplom/internal/domain"
+	"diplom/internal/storage"
 	"errors"
+	"github.com/go-audio/wav"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 type MockPhraseStreamRepository struct {
 	mock.Mock
 }
 
-func (m *MockPhraseStreamRepository) Create(phraseStream *domain.PhraseStream) (uuid.UUID, error) {
-	args := m.Called(phraseStream)
+func (m *MockPhraseStreamRepository) Create(orgID uuid.UUID, phraseStream *domain.PhraseStream) (uuid.UUID, error) {
+	args := m.Called(orgID, phraseStream)
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
-func (m *MockPhraseStreamRepository) GetByID(id uuid.UUID) (*domain.PhraseStream, error) {
-	args := m.Called(id)
+func (m *MockPhraseStreamRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseStream, error) {
+	args := m.Called(orgID, id)
 	return args.Get(0).(*domain.PhraseStream), args.Error(1)
 }
 
-func (m *MockPhraseStreamRepository) Update(id uuid.UUID, answerID uuid.UUID, status string) error {
-	args := m.Called(id, answerID, status)
+func (m *MockPhraseStreamRepository) Update(orgID uuid.UUID, id uuid.UUID, answerID uuid.UUID, status string) error {
+	args := m.Called(orgID, id, answerID, status)
 	return args.Error(0)
 }
 
-func (m *MockPhraseStreamRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockPhraseStreamRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
 	return args.Error(0)
 }
 
-func (m *MockPhraseStreamRepository) GetAll() ([]domain.PhraseStream, error) {
-	args := m.Called()
+func (m *MockPhraseStreamRepository) GetAll(orgID uuid.UUID) ([]domain.PhraseStream, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]domain.PhraseStream), args.Error(1)
 }
 
-func (m *MockPhraseStreamRepository) GetStudentPhrases(userID uuid.UUID) ([]string, error) {
-	args := m.Called(userID)
+func (m *MockPhraseStreamRepository) GetStudentPhrases(orgID uuid.UUID, userID uuid.UUID) ([]string, error) {
+	args := m.Called(orgID, userID)
 	return args.Get(0).([]string), args.Error(1)
 }
 
-func (m *MockPhraseStreamRepository) GetStudentProgress(userID uuid.UUID) ([][]string, error) {
-	args := m.Called(userID)
+func (m *MockPhraseStreamRepository) GetStudentProgress(orgID uuid.UUID, userID uuid.UUID) ([][]string, error) {
+	args := m.Called(orgID, userID)
 	return args.Get(0).([][]string), args.Error(1)
 }
 
+func (m *MockPhraseStreamRepository) GetScenarioResult(orgID uuid.UUID, scenarioID uuid.UUID) (*domain.ScenarioResult, error) {
+	args := m.Called(orgID, scenarioID)
+	return args.Get(0).(*domain.ScenarioResult), args.Error(1)
+}
+
+type MockSpeechSynthesizer struct {
+	mock.Mock
+}
+
+func (m *MockSpeechSynthesizer) SynthesizeSpeech(ctx context.Context, text string, fileName string, voice client.Voice) error {
+	args := m.Called(ctx, text, fileName, voice)
+	return args.Error(0)
+}
+
+// newPhraseAudio returns the option writing synthesized phrases to a store in a temporary directory.
+func newPhraseAudio(t *testing.T) func(*PhraseStreamService) {
+	store, err := storage.NewAudioStore(t.TempDir())
+	require.NoError(t, err)
+	return WithPhraseAudio(store)
+}
+
+func TestCreatePhraseStreamVoice(t *testing.T) {
+	mockRepo := new(MockPhraseStreamRepository)
+	phraseMockRepo := new(MockPhraseRepository)
+	audioPhraseMock := new(MockAudioPhraseRepository)
+	voiceMock := new(MockVoiceRepository)
+	synthesizer := new(MockSpeechSynthesizer)
+	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo, voiceMock, synthesizer, newPhraseAudio(t))
+	orgID := uuid.New()
+	phrase := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff"}
+	phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+
+	t.Run("accent resolves through the catalog", func(t *testing.T) {
+		audio := &domain.AudioPhrase{Accent: " British "}
+		voiceMock.On("GetByAccent", orgID, "british").Return(&domain.Voice{Accent: "british", Voice: "jane",
+			Language: "en-GB", Emotion: "neutral", Speed: 0.9}, nil)
+		synthesizer.On("SynthesizeSpeech", mock.Anything, phrase.Text, mock.Anything,
+			client.Voice{Name: "jane", Language: "en-GB", Emotion: "neutral", Speed: 0.9}).Return(errors.New("provider error"))
+
+		_, err := service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID}, audio)
+
+		assert.EqualError(t, err, "provider error")
+		synthesizer.AssertExpectations(t)
+		audioPhraseMock.AssertNotCalled(t, "Create", mock.Anything)
+		mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown accent", func(t *testing.T) {
+		audio := &domain.AudioPhrase{Accent: "martian"}
+		voiceMock.On("GetByAccent", orgID, "martian").Return((*domain.Voice)(nil), errors.New("no rows"))
+		calls := len(synthesizer.Calls)
+
+		_, err := service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID}, audio)
+
+		assert.ErrorIs(t, err, ErrUnknownAccent)
+		assert.Len(t, synthesizer.Calls, calls, "nothing must be synthesized")
+	})
+}
+
+func TestCreatePhraseStreamOffline(t *testing.T) {
+	mockRepo := new(MockPhraseStreamRepository)
+	phraseMockRepo := new(MockPhraseRepository)
+	audioPhraseMock := new(MockAudioPhraseRepository)
+	root := t.TempDir()
+	store, err := storage.NewAudioStore(root)
+	require.NoError(t, err)
+	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo, new(MockVoiceRepository), client.NewFakeSpeechClient(nil),
+		WithPhraseAudio(store))
+	orgID := uuid.New()
+
+	synthesize := func(text string) *wav.Decoder {
+		phrase := &domain.Phrase{ID: uuid.New(), Text: text}
+		// The path of the client is not used, the audio is written to a new file of the store.
+		audio := &domain.AudioPhrase{PathToAudio: filepath.Join(root, "..", "phrase.wav"), Noise: 0.01}
+		audioID := uuid.New()
+		streamID := uuid.New()
+		phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+		audioPhraseMock.On("Create", audio).Return(audioID, nil)
+		mockRepo.On("Create", orgID, mock.MatchedBy(func(s *domain.PhraseStream) bool { return s.PhraseID == phrase.ID })).Return(streamID, nil)
+
+		id, err := service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID}, audio)
+
+		require.NoError(t, err)
+		assert.Equal(t, streamID, id)
+		assert.Equal(t, orgID, audio.OrganizationID)
+		assert.True(t, strings.HasPrefix(audio.PathToAudio, filepath.Join(root, phraseAudioDir)+string(filepath.Separator)), audio.PathToAudio)
+		f, err := os.Open(audio.PathToAudio)
+		require.NoError(t, err)
+		t.Cleanup(func() { f.Close() })
+		decoder := wav.NewDecoder(f)
+		require.True(t, decoder.IsValidFile())
+		return decoder
+	}
+
+	short, err := synthesize("Roger").Duration()
+	require.NoError(t, err)
+	long, err := synthesize("Cleared for takeoff runway two seven").Duration()
+	require.NoError(t, err)
+	assert.Greater(t, long, short)
+}
+
+func TestCreatePhraseStreamYandex(t *testing.T) {
+	orgID := uuid.New()
+	phrase := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff"}
+
+	t.Run("request carries credentials, folder and voice", func(t *testing.T) {
+		var form url.Values
+		var authorization string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authorization = r.Header.Get("Authorization")
+			r.ParseForm()
+			form = r.PostForm
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		speechKit, err := client.NewYandexSpeechClient(client.ProviderConfig{IAMToken: "iam", FolderID: "folder", SynthesisURL: server.URL})
+		require.NoError(t, err)
+		phraseMockRepo := new(MockPhraseRepository)
+		voiceMock := new(MockVoiceRepository)
+		service := NewPhraseStreamService(new(MockPhraseStreamRepository), new(MockAudioPhraseRepository), phraseMockRepo, voiceMock, speechKit,
+			newPhraseAudio(t))
+		phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+		voiceMock.On("GetByAccent", orgID, "british").Return(&domain.Voice{Voice: "jane", Language: "en-GB", Speed: 1.2}, nil)
+
+		_, err = service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID},
+			&domain.AudioPhrase{Accent: "british"})
+
+		assert.Error(t, err)
+		assert.Equal(t, "Bearer iam", authorization)
+		assert.Equal(t, "folder", form.Get("folderId"))
+		assert.Equal(t, phrase.Text, form.Get("text"))
+		assert.Equal(t, "jane", form.Get("voice"))
+		assert.Equal(t, "en-GB", form.Get("lang"))
+		assert.Equal(t, "1.2", form.Get("speed"))
+	})
+
+	t.Run("markup is sent as SSML", func(t *testing.T) {
+		var form url.Values
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			form = r.PostForm
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer server.Close()
+		speechKit, err := client.NewYandexSpeechClient(client.ProviderConfig{APIKey: "key", SynthesisURL: server.URL})
+		require.NoError(t, err)
+		phraseMockRepo := new(MockPhraseRepository)
+		service := NewPhraseStreamService(new(MockPhraseStreamRepository), new(MockAudioPhraseRepository), phraseMockRepo,
+			new(MockVoiceRepository), speechKit, newPhraseAudio(t))
+		marked := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff", Markup: "Cleared [pause 500ms] *for takeoff*"}
+		phraseMockRepo.On("GetByID", orgID, marked.ID).Return(marked, nil)
+
+		_, err = service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: marked.ID},
+			&domain.AudioPhrase{})
+
+		assert.ErrorIs(t, err, client.ErrUnauthorized)
+		assert.Empty(t, form.Get("text"))
+		assert.Equal(t, `<speak>Cleared <break time="500ms"/> <emphasis level="strong">for takeoff</emphasis></speak>`,
+			form.Get("ssml"))
+	})
+
+	t.Run("cancelled request cancels synthesis", func(t *testing.T) {
+		started := make(chan struct{})
+		upstreamDone := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The server notices a closed connection only after the body has been read.
+			io.Copy(io.Discard, r.Body)
+			close(started)
+			<-r.Context().Done()
+			close(upstreamDone)
+		}))
+		defer server.Close()
+		speechKit, err := client.NewYandexSpeechClient(client.ProviderConfig{APIKey: "key", SynthesisURL: server.URL})
+		require.NoError(t, err)
+		phraseMockRepo := new(MockPhraseRepository)
+		service := NewPhraseStreamService(new(MockPhraseStreamRepository), new(MockAudioPhraseRepository), phraseMockRepo,
+			new(MockVoiceRepository), speechKit, newPhraseAudio(t))
+		phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+
+		_, err = service.CreatePhraseStream(ctx, orgID, &domain.PhraseStream{PhraseID: phrase.ID},
+			&domain.AudioPhrase{})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		select {
+		case <-upstreamDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream request was not cancelled")
+		}
+	})
+
+	t.Run("IAM token needs a folder", func(t *testing.T) {
+		_, err := client.NewYandexSpeechClient(client.ProviderConfig{IAMToken: "iam"})
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCreatePhraseStreamProviderFailures(t *testing.T) {
+	orgID := uuid.New()
+	phrase := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff"}
+	dir := t.TempDir()
+	wavFile := filepath.Join(dir, "clean.wav")
+	require.NoError(t, client.NewFakeSpeechClient(nil).SynthesizeSpeech(context.Background(), phrase.Text, wavFile, client.Voice{}))
+	clean, err := os.ReadFile(wavFile)
+	require.NoError(t, err)
+
+	// newService returns a service synthesizing through a stub answering with the given statuses in turn,
+	// the last one repeats. calls counts the requests that reached the stub.
+	newService := func(config client.ProviderConfig, statuses ...int) (*PhraseStreamService, *int) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := statuses[len(statuses)-1]
+			if calls < len(statuses) {
+				status = statuses[calls]
+			}
+			calls++
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				w.Write(clean)
+			}
+		}))
+		t.Cleanup(server.Close)
+		config.APIKey = "key"
+		config.SynthesisURL = server.URL
+		config.RetryBaseDelay = time.Millisecond
+		speechKit, err := client.NewYandexSpeechClient(config)
+		require.NoError(t, err)
+		streams := new(MockPhraseStreamRepository)
+		audio := new(MockAudioPhraseRepository)
+		phraseMockRepo := new(MockPhraseRepository)
+		phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+		audio.On("Create", mock.Anything).Return(uuid.New(), nil)
+		streams.On("Create", orgID, mock.Anything).Return(uuid.New(), nil)
+		return NewPhraseStreamService(streams, audio, phraseMockRepo, new(MockVoiceRepository), speechKit, newPhraseAudio(t)), &calls
+	}
+	create := func(service *PhraseStreamService) error {
+		_, err := service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID},
+			&domain.AudioPhrase{})
+		return err
+	}
+
+	t.Run("transient failures are retried", func(t *testing.T) {
+		service, calls := newService(client.ProviderConfig{}, http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK)
+
+		assert.NoError(t, create(service))
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("throttling is a quota error after the last attempt", func(t *testing.T) {
+		service, calls := newService(client.ProviderConfig{MaxAttempts: 2}, http.StatusTooManyRequests)
+
+		err := create(service)
+
+		assert.ErrorIs(t, err, client.ErrQuotaExceeded)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("rejected credentials are not retried", func(t *testing.T) {
+		service, calls := newService(client.ProviderConfig{}, http.StatusUnauthorized)
+
+		err := create(service)
+
+		assert.ErrorIs(t, err, client.ErrUnauthorized)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("circuit breaker fails fast while the provider is down", func(t *testing.T) {
+		service, calls := newService(client.ProviderConfig{MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Hour},
+			http.StatusInternalServerError)
+
+		assert.ErrorIs(t, create(service), client.ErrProviderUnavailable)
+		assert.ErrorIs(t, create(service), client.ErrProviderUnavailable)
+		err := create(service)
+
+		assert.ErrorIs(t, err, client.ErrProviderUnavailable)
+		assert.Equal(t, 2, *calls)
+	})
+}
+
+func TestCreatePhraseStreamCache(t *testing.T) {
+	orgID := uuid.New()
+	dir := t.TempDir()
+	fake := client.NewFakeSpeechClient(nil)
+	synthesizer := new(MockSpeechSynthesizer)
+	synthesizer.On("SynthesizeSpeech", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		fake.SynthesizeSpeech(args.Get(0).(context.Context), args.String(1), args.String(2), args.Get(3).(client.Voice))
+	})
+	cache, err := client.NewAudioCache(filepath.Join(dir, "cache"), 1<<20)
+	require.NoError(t, err)
+	streams := new(MockPhraseStreamRepository)
+	audio := new(MockAudioPhraseRepository)
+	phraseMockRepo := new(MockPhraseRepository)
+	audio.On("Create", mock.Anything).Return(uuid.New(), nil)
+	streams.On("Create", orgID, mock.Anything).Return(uuid.New(), nil)
+	service := NewPhraseStreamService(streams, audio, phraseMockRepo, new(MockVoiceRepository), client.NewCachingSynthesizer(synthesizer, cache),
+		newPhraseAudio(t))
+	listen := func(phrase *domain.Phrase) string {
+		phraseMockRepo.On("GetByID", orgID, phrase.ID).Return(phrase, nil)
+		audioPhrase := &domain.AudioPhrase{Noise: 0.05}
+		_, err := service.CreatePhraseStream(context.Background(), orgID, &domain.PhraseStream{PhraseID: phrase.ID}, audioPhrase)
+		require.NoError(t, err)
+		return audioPhrase.PathToAudio
+	}
+	phrase := &domain.Phrase{ID: uuid.New(), Text: "Cleared for takeoff"}
+
+	first := listen(phrase)
+	second := listen(phrase)
+
+	synthesizer.AssertNumberOfCalls(t, "SynthesizeSpeech", 1)
+	firstAudio, err := os.ReadFile(first)
+	require.NoError(t, err)
+	secondAudio, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.NotEqual(t, firstAudio, secondAudio, "noise must be applied to each copy of the clean audio")
+
+	listen(&domain.Phrase{ID: uuid.New(), Text: "Line up and wait"})
+	synthesizer.AssertNumberOfCalls(t, "SynthesizeSpeech", 2)
+}
+
 func TestUpdatePhraseStream(t *testing.T) {
 	mockRepo := new(MockPhraseStreamRepository)
 	phraseMockRepo := new(MockPhraseRepository)
 	audioPhraseMock := new(MockAudioPhraseRepository)
-	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo)
+	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo, new(MockVoiceRepository), new(MockSpeechSynthesizer))
+	orgID := uuid.New()
 
 	t.Run("success update phrase stream", func(t *testing.T) {
 		id := uuid.New()
 		answerID := uuid.New()
 		status := "completed"
 
-		mockRepo.On("Update", id, answerID, status).Return(nil)
+		mockRepo.On("Update", orgID, id, answerID, status).Return(nil)
 
-		err := service.UpdatePhraseStream(id, answerID, status)
+		err := service.UpdatePhraseStream(orgID, id, answerID, status)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -72,9 +410,9 @@ func TestUpdatePhraseStream(t *testing.T) {
 		answerID := uuid.New()
 		status := "completed"
 
-		mockRepo.On("Update", id, answerID, status).Return(errors.New("update error"))
+		mockRepo.On("Update", orgID, id, answerID, status).Return(errors.New("update error"))
 
-		err := service.UpdatePhraseStream(id, answerID, status)
+		err := service.UpdatePhraseStream(orgID, id, answerID, status)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -85,15 +423,16 @@ func TestGetStudentPhrases(t *testing.T) {
 	mockRepo := new(MockPhraseStreamRepository)
 	phraseMockRepo := new(MockPhraseRepository)
 	audioPhraseMock := new(MockAudioPhraseRepository)
-	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo)
+	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo, new(MockVoiceRepository), new(MockSpeechSynthesizer))
+	orgID := uuid.New()
 
 	t.Run("success get student phrases", func(t *testing.T) {
 		userID := uuid.New()
 		expectedPhrases := []string{"phrase1", "phrase2"}
 
-		mockRepo.On("GetStudentPhrases", userID).Return(expectedPhrases, nil)
+		mockRepo.On("GetStudentPhrases", orgID, userID).Return(expectedPhrases, nil)
 
-		phrases, err := service.GetStudentPhrases(userID)
+		phrases, err := service.GetStudentPhrases(orgID, userID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedPhrases, phrases)
@@ -103,9 +442,9 @@ func TestGetStudentPhrases(t *testing.T) {
 	t.Run("fail get student phrases", func(t *testing.T) {
 		userID := uuid.New()
 
-		mockRepo.On("GetStudentPhrases", userID).Return([]string{}, errors.New("get error"))
+		mockRepo.On("GetStudentPhrases", orgID, userID).Return([]string{}, errors.New("get error"))
 
-		_, err := service.GetStudentPhrases(userID)
+		_, err := service.GetStudentPhrases(orgID, userID)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -116,7 +455,8 @@ func TestGetStudentProgress(t *testing.T) {
 	mockRepo := new(MockPhraseStreamRepository)
 	phraseMockRepo := new(MockPhraseRepository)
 	audioPhraseMock := new(MockAudioPhraseRepository)
-	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo)
+	service := NewPhraseStreamService(mockRepo, audioPhraseMock, phraseMockRepo, new(MockVoiceRepository), new(MockSpeechSynthesizer))
+	orgID := uuid.New()
 
 	t.Run("success get student progress", func(t *testing.T) {
 		userID := uuid.New()
@@ -125,9 +465,9 @@ func TestGetStudentProgress(t *testing.T) {
 			{"phrase2", "pending", "inactive"},
 		}
 
-		mockRepo.On("GetStudentProgress", userID).Return(expectedProgress, nil)
+		mockRepo.On("GetStudentProgress", orgID, userID).Return(expectedProgress, nil)
 
-		progress, err := service.GetStudentProgress(userID)
+		progress, err := service.GetStudentProgress(orgID, userID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedProgress, progress)
@@ -137,9 +477,9 @@ func TestGetStudentProgress(t *testing.T) {
 	t.Run("fail get student progress", func(t *testing.T) {
 		userID := uuid.New()
 
-		mockRepo.On("GetStudentProgress", userID).Return([][]string{}, errors.New("get error"))
+		mockRepo.On("GetStudentProgress", orgID, userID).Return([][]string{}, errors.New("get error"))
 
-		_, err := service.GetStudentProgress(userID)
+		_, err := service.GetStudentProgress(orgID, userID)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
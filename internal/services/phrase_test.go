@@ -2,6 +2,7 @@ package services
 
 import (
 	"diplom/internal/domain"
+	"diplom/internal/ssml"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,8 +20,8 @@ func (m *MockPhraseRepository) Create(phrase *domain.Phrase) (uuid.UUID, error)
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
-func (m *MockPhraseRepository) GetByID(id uuid.UUID) (*domain.Phrase, error) {
-	args := m.Called(id)
+func (m *MockPhraseRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Phrase, error) {
+	args := m.Called(orgID, id)
 	return args.Get(0).(*domain.Phrase), args.Error(1)
 }
 
@@ -29,13 +30,13 @@ func (m *MockPhraseRepository) Update(phrase *domain.Phrase) error {
 	return args.Error(0)
 }
 
-func (m *MockPhraseRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockPhraseRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
 	return args.Error(0)
 }
 
-func (m *MockPhraseRepository) GetAll(textSearch string) ([]domain.Phrase, error) {
-	args := m.Called(textSearch)
+func (m *MockPhraseRepository) GetAll(orgID uuid.UUID, textSearch string) ([]domain.Phrase, error) {
+	args := m.Called(orgID, textSearch)
 	return args.Get(0).([]domain.Phrase), args.Error(1)
 }
 
@@ -73,12 +74,58 @@ func TestPhraseRepository_Create(t *testing.T) {
 	})
 }
 
+func TestCreatePhraseMarkup(t *testing.T) {
+	mockRepo := new(MockPhraseRepository)
+	service := NewPhraseService(mockRepo)
+
+	t.Run("simple markup becomes the plain text", func(t *testing.T) {
+		phrase := &domain.Phrase{Markup: "Cleared [pause 300ms] *runway two seven* [rate 0.8]for takeoff[/rate]", TypeID: uuid.New()}
+		mockRepo.On("Create", phrase).Return(uuid.New(), nil).Once()
+
+		_, err := service.CreatePhrase(phrase)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Cleared runway two seven for takeoff", phrase.Text)
+	})
+
+	t.Run("SSML in the text is kept as markup", func(t *testing.T) {
+		document := `<speak>Hold <break time="1s"/> <emphasis level="strong">short</emphasis></speak>`
+		phrase := &domain.Phrase{Text: document, TypeID: uuid.New()}
+		mockRepo.On("Create", phrase).Return(uuid.New(), nil).Once()
+
+		_, err := service.CreatePhrase(phrase)
+
+		assert.NoError(t, err)
+		assert.Equal(t, document, phrase.Markup)
+		assert.Equal(t, "Hold short", phrase.Text)
+	})
+
+	for name, markup := range map[string]string{
+		"unclosed emphasis":   "*runway two seven",
+		"unclosed rate":       "[rate 0.8]for takeoff",
+		"rate out of range":   "[rate 10]for takeoff[/rate]",
+		"long pause":          "Hold [pause 1m] short",
+		"unknown tag":         "Hold [loud] short",
+		"unsupported element": `<speak><audio src="http://example.com/a.mp3"/>Hold</speak>`,
+		"broken SSML":         "<speak>Hold <emphasis>short</speak>",
+		"no words":            "[pause 500ms]",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := service.CreatePhrase(&domain.Phrase{Markup: markup})
+
+			assert.ErrorIs(t, err, ssml.ErrInvalidMarkup)
+		})
+	}
+	mockRepo.AssertExpectations(t)
+}
+
 func TestPhraseRepository_GetByID(t *testing.T) {
 	mockTypeRepo := new(MockPhraseTypeRepository)
 
 	mockRepo := new(MockPhraseRepository)
 	mockRepo.pr = mockTypeRepo
 	service := NewPhraseService(mockRepo)
+	orgID := uuid.New()
 
 	t.Run("success get phrase by id", func(t *testing.T) {
 		id := uuid.New()
@@ -88,9 +135,9 @@ func TestPhraseRepository_GetByID(t *testing.T) {
 			TypeID: uuid.New(),
 		}
 
-		mockRepo.On("GetByID", id).Return(expectedPhrase, nil)
+		mockRepo.On("GetByID", orgID, id).Return(expectedPhrase, nil)
 
-		phrase, err := service.GetPhraseByID(id)
+		phrase, err := service.GetPhraseByID(orgID, id)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedPhrase, phrase)
@@ -99,9 +146,9 @@ func TestPhraseRepository_GetByID(t *testing.T) {
 
 	t.Run("fail get phrase by id", func(t *testing.T) {
 		id := uuid.New()
-		mockRepo.On("GetByID", id).Return(&domain.Phrase{}, errors.New("not found"))
+		mockRepo.On("GetByID", orgID, id).Return(&domain.Phrase{}, errors.New("not found"))
 
-		_, err := service.GetPhraseByID(id)
+		_, err := service.GetPhraseByID(orgID, id)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -147,13 +194,14 @@ func TestPhraseRepository_Delete(t *testing.T) {
 	mockRepo := new(MockPhraseRepository)
 	mockRepo.pr = mockTypeRepo
 	service := NewPhraseService(mockRepo)
+	orgID := uuid.New()
 
 	t.Run("success delete phrase", func(t *testing.T) {
 		id := uuid.New()
 
-		mockRepo.On("Delete", id).Return(nil)
+		mockRepo.On("Delete", orgID, id).Return(nil)
 
-		err := service.DeletePhrase(id)
+		err := service.DeletePhrase(orgID, id)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -161,9 +209,9 @@ func TestPhraseRepository_Delete(t *testing.T) {
 
 	t.Run("fail delete phrase", func(t *testing.T) {
 		id := uuid.New()
-		mockRepo.On("Delete", id).Return(errors.New("delete error"))
+		mockRepo.On("Delete", orgID, id).Return(errors.New("delete error"))
 
-		err := service.DeletePhrase(id)
+		err := service.DeletePhrase(orgID, id)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -176,6 +224,7 @@ func TestPhraseRepository_GetAll(t *testing.T) {
 	mockRepo := new(MockPhraseRepository)
 	mockRepo.pr = mockTypeRepo
 	service := NewPhraseService(mockRepo)
+	orgID := uuid.New()
 
 	t.Run("success get all phrases", func(t *testing.T) {
 		searchText := "test"
@@ -184,9 +233,9 @@ func TestPhraseRepository_GetAll(t *testing.T) {
 			{ID: uuid.New(), Text: "Test phrase 2", TypeID: uuid.New()},
 		}
 
-		mockRepo.On("GetAll", searchText).Return(expectedPhrases, nil)
+		mockRepo.On("GetAll", orgID, searchText).Return(expectedPhrases, nil)
 
-		phrases, err := service.GetAllPhrases(searchText)
+		phrases, err := service.GetAllPhrases(orgID, searchText)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedPhrases, phrases)
@@ -195,9 +244,9 @@ func TestPhraseRepository_GetAll(t *testing.T) {
 
 	t.Run("fail get all phrases", func(t *testing.T) {
 		searchText := ""
-		mockRepo.On("GetAll", searchText).Return([]domain.Phrase{}, errors.New("get error"))
+		mockRepo.On("GetAll", orgID, searchText).Return([]domain.Phrase{}, errors.New("get error"))
 
-		_, err := service.GetAllPhrases(searchText)
+		_, err := service.GetAllPhrases(orgID, searchText)
 
 		assert.Error(t, err)
 		mockRepo.AssertExpectations(t)
@@ -26,18 +26,18 @@ func (s *PhraseTypeService) CreatePhraseType(phraseType *domain.PhraseType) (uui
 	return s.repo.Create(phraseType)
 }
 
-func (s *PhraseTypeService) GetPhraseTypeByID(id uuid.UUID) (*domain.PhraseType, error) {
-	return s.repo.GetByID(id)
+func (s *PhraseTypeService) GetPhraseTypeByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseType, error) {
+	return s.repo.GetByID(orgID, id)
 }
 
 func (s *PhraseTypeService) UpdatePhraseType(phraseType *domain.PhraseType) error {
 	return s.repo.Update(phraseType)
 }
 
-func (s *PhraseTypeService) DeletePhraseType(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *PhraseTypeService) DeletePhraseType(orgID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(orgID, id)
 }
 
-func (s *PhraseTypeService) GetAllPhraseTypes() ([]domain.PhraseType, error) {
-	return s.repo.GetAll()
+func (s *PhraseTypeService) GetAllPhraseTypes(orgID uuid.UUID) ([]domain.PhraseType, error) {
+	return s.repo.GetAll(orgID)
 }
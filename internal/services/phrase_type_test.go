@@ -18,8 +18,8 @@ func (m *MockPhraseTypeRepository) Create(phraseType *domain.PhraseType) (uuid.U
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
 
-func (m *MockPhraseTypeRepository) GetByID(id uuid.UUID) (*domain.PhraseType, error) {
-	args := m.Called(id)
+func (m *MockPhraseTypeRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.PhraseType, error) {
+	args := m.Called(orgID, id)
 	return args.Get(0).(*domain.PhraseType), args.Error(1)
 }
 
@@ -28,13 +28,13 @@ func (m *MockPhraseTypeRepository) Update(phraseType *domain.PhraseType) error {
 	return args.Error(0)
 }
 
-func (m *MockPhraseTypeRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *MockPhraseTypeRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
 	return args.Error(0)
 }
 
-func (m *MockPhraseTypeRepository) GetAll() ([]domain.PhraseType, error) {
-	args := m.Called()
+func (m *MockPhraseTypeRepository) GetAll(orgID uuid.UUID) ([]domain.PhraseType, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]domain.PhraseType), args.Error(1)
 }
 
@@ -42,9 +42,10 @@ func TestPhraseTypeService_CreatePhraseType(t *testing.T) {
 	t.Run("successful creation", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		testID := uuid.New()
-		phraseType := &domain.PhraseType{Title: "Test Type"}
+		phraseType := &domain.PhraseType{Title: "Test Type", OrganizationID: orgID}
 
 		mockRepo.On("Create", phraseType).Return(testID, nil)
 
@@ -58,9 +59,10 @@ func TestPhraseTypeService_CreatePhraseType(t *testing.T) {
 	t.Run("creation error", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		expectedErr := errors.New("repository error")
-		phraseType := &domain.PhraseType{Title: "Test Type"}
+		phraseType := &domain.PhraseType{Title: "Test Type", OrganizationID: orgID}
 
 		mockRepo.On("Create", phraseType).Return(uuid.Nil, expectedErr)
 
@@ -77,6 +79,7 @@ func TestPhraseTypeService_GetPhraseTypeByID(t *testing.T) {
 	t.Run("successful get", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		testID := uuid.New()
 		expectedType := &domain.PhraseType{
@@ -84,9 +87,9 @@ func TestPhraseTypeService_GetPhraseTypeByID(t *testing.T) {
 			Title: "Test Type",
 		}
 
-		mockRepo.On("GetByID", testID).Return(expectedType, nil)
+		mockRepo.On("GetByID", orgID, testID).Return(expectedType, nil)
 
-		result, err := srv.GetPhraseTypeByID(testID)
+		result, err := srv.GetPhraseTypeByID(orgID, testID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedType, result)
@@ -98,10 +101,12 @@ func TestPhraseTypeService_UpdatePhraseType(t *testing.T) {
 	t.Run("successful update", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		phraseType := &domain.PhraseType{
-			ID:    uuid.New(),
-			Title: "Updated Type",
+			ID:             uuid.New(),
+			Title:          "Updated Type",
+			OrganizationID: orgID,
 		}
 
 		mockRepo.On("Update", phraseType).Return(nil)
@@ -115,11 +120,13 @@ func TestPhraseTypeService_UpdatePhraseType(t *testing.T) {
 	t.Run("update error", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		expectedErr := errors.New("update error")
 		phraseType := &domain.PhraseType{
-			ID:    uuid.New(),
-			Title: "Updated Type",
+			ID:             uuid.New(),
+			Title:          "Updated Type",
+			OrganizationID: orgID,
 		}
 
 		mockRepo.On("Update", phraseType).Return(expectedErr)
@@ -136,12 +143,13 @@ func TestPhraseTypeService_DeletePhraseType(t *testing.T) {
 	t.Run("successful deletion", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		testID := uuid.New()
 
-		mockRepo.On("Delete", testID).Return(nil)
+		mockRepo.On("Delete", orgID, testID).Return(nil)
 
-		err := srv.DeletePhraseType(testID)
+		err := srv.DeletePhraseType(orgID, testID)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -150,13 +158,14 @@ func TestPhraseTypeService_DeletePhraseType(t *testing.T) {
 	t.Run("delete error", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		testID := uuid.New()
 		expectedErr := errors.New("delete error")
 
-		mockRepo.On("Delete", testID).Return(expectedErr)
+		mockRepo.On("Delete", orgID, testID).Return(expectedErr)
 
-		err := srv.DeletePhraseType(testID)
+		err := srv.DeletePhraseType(orgID, testID)
 
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
@@ -168,15 +177,16 @@ func TestPhraseTypeService_GetAllPhraseTypes(t *testing.T) {
 	t.Run("successful get all", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		expectedTypes := []domain.PhraseType{
 			{ID: uuid.New(), Title: "Type 1"},
 			{ID: uuid.New(), Title: "Type 2"},
 		}
 
-		mockRepo.On("GetAll").Return(expectedTypes, nil)
+		mockRepo.On("GetAll", orgID).Return(expectedTypes, nil)
 
-		result, err := srv.GetAllPhraseTypes()
+		result, err := srv.GetAllPhraseTypes(orgID)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTypes, result)
@@ -186,12 +196,13 @@ func TestPhraseTypeService_GetAllPhraseTypes(t *testing.T) {
 	t.Run("get all error", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
 		expectedErr := errors.New("repository error")
 
-		mockRepo.On("GetAll").Return([]domain.PhraseType{}, expectedErr)
+		mockRepo.On("GetAll", orgID).Return([]domain.PhraseType{}, expectedErr)
 
-		result, err := srv.GetAllPhraseTypes()
+		result, err := srv.GetAllPhraseTypes(orgID)
 
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
@@ -202,10 +213,11 @@ func TestPhraseTypeService_GetAllPhraseTypes(t *testing.T) {
 	t.Run("empty list", func(t *testing.T) {
 		mockRepo := new(MockPhraseTypeRepository)
 		srv := NewPhraseTypeService(mockRepo)
+		orgID := uuid.New()
 
-		mockRepo.On("GetAll").Return([]domain.PhraseType{}, nil)
+		mockRepo.On("GetAll", orgID).Return([]domain.PhraseType{}, nil)
 
-		result, err := srv.GetAllPhraseTypes()
+		result, err := srv.GetAllPhraseTypes(orgID)
 
 		assert.NoError(t, err)
 		assert.Empty(t, result)
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"diplom/client"
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"diplom/internal/storage"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrRecognitionJobNotFound = errors.New("recognition job not found")
+	ErrPhraseStreamNotFound   = errors.New("phrase stream not found")
+)
+
+const (
+	defaultJobPollInterval = 2 * time.Second
+	// jobLease hides a claimed job from other workers while it runs. It must outlast a synchronous
+	// recognition with all its retries.
+	jobLease = 10 * time.Minute
+	// jobBatchSize is how many jobs a poll runs at most. They are claimed one at a time, so the lease of a
+	// job starts when the job does and not when the batch does.
+	jobBatchSize     = 10
+	maxJobRetries    = 5
+	maxJobRetryDelay = 5 * time.Minute
+	// answerAudioDir is the directory of uploaded answers in the audio store, with a directory per student.
+	answerAudioDir = "answers"
+)
+
+// AnswerGrader saves the answer of a finished recognition, StudentAnswerService implements it.
+type AnswerGrader interface {
+	GradeAnswer(job *domain.RecognitionJob, recognition *client.Recognition) (uuid.UUID, error)
+	JobAnswerID(job *domain.RecognitionJob) (uuid.UUID, error)
+}
+
+// RecognitionJobService recognizes recorded answers in the background. Recognizers with a long-running
+// operation get the audio submitted and are polled until it is done, the others are called directly
+// from the worker. The state of the jobs is kept in the database, so they survive a restart.
+type RecognitionJobService struct {
+	jobs         repository.RecognitionJobRepositoryInterface
+	streams      repository.PhraseStreamRepositoryInterface
+	scenarios    repository.ScenarioRepositoryInterface
+	recognizer   client.SpeechRecognizer
+	grader       AnswerGrader
+	audio        *storage.AudioStore
+	pollInterval time.Duration
+	now          func() time.Time
+}
+
+// NewRecognitionJobService creates the service; the recordings are kept in audio.
+func NewRecognitionJobService(jobs repository.RecognitionJobRepositoryInterface, streams repository.PhraseStreamRepositoryInterface,
+	scenarios repository.ScenarioRepositoryInterface, recognizer client.SpeechRecognizer, grader AnswerGrader,
+	audio *storage.AudioStore, options ...func(*RecognitionJobService)) *RecognitionJobService {
+	s := &RecognitionJobService{jobs: jobs, streams: streams, scenarios: scenarios, recognizer: recognizer, grader: grader,
+		audio: audio, pollInterval: defaultJobPollInterval, now: time.Now}
+	for _, o := range options {
+		o(s)
+	}
+	return s
+}
+
+// WithJobPollInterval sets how often the worker looks for due jobs and polls running operations.
+func WithJobPollInterval(interval time.Duration) func(*RecognitionJobService) {
+	return func(s *RecognitionJobService) {
+		if interval > 0 {
+			s.pollInterval = interval
+		}
+	}
+}
+
+// Submit creates a pending job for a recording of the student answering a phrase stream of their scenario
+// that the student has uploaded before. Other paths, like the uploads of other students, are rejected with
+// storage.ErrOutsideRoot.
+func (s *RecognitionJobService) Submit(orgID uuid.UUID, userID uuid.UUID, phraseStreamID uuid.UUID, pathToAudio string,
+	recordTime time.Time) (*domain.RecognitionJob, error) {
+	if err := s.checkStream(orgID, userID, phraseStreamID); err != nil {
+		return nil, err
+	}
+	path, err := s.audio.ResolveIn(answerDir(userID), pathToAudio)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(orgID, userID, phraseStreamID, path, recordTime)
+}
+
+// SubmitRecording saves an uploaded recording under a generated name in the directory of the student and
+// creates a pending job for it.
+func (s *RecognitionJobService) SubmitRecording(orgID uuid.UUID, userID uuid.UUID, phraseStreamID uuid.UUID, name string,
+	recording io.Reader, recordTime time.Time) (*domain.RecognitionJob, error) {
+	if err := s.checkStream(orgID, userID, phraseStreamID); err != nil {
+		return nil, err
+	}
+	path, err := s.audio.Save(answerDir(userID), name, recording)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(orgID, userID, phraseStreamID, path, recordTime)
+}
+
+// answerDir is the directory of the recordings the student uploaded.
+func answerDir(userID uuid.UUID) string {
+	return filepath.Join(answerAudioDir, userID.String())
+}
+
+// checkStream makes sure the phrase stream belongs to a scenario of the student. Streams of other students
+// are not found, like the ones of other organizations.
+func (s *RecognitionJobService) checkStream(orgID uuid.UUID, userID uuid.UUID, phraseStreamID uuid.UUID) error {
+	stream, err := s.streams.GetByID(orgID, phraseStreamID)
+	if err != nil {
+		return ErrPhraseStreamNotFound
+	}
+	scenario, err := s.scenarios.GetByID(orgID, stream.ScenarioID)
+	if err != nil || scenario.UserID != userID {
+		return ErrPhraseStreamNotFound
+	}
+	return nil
+}
+
+func (s *RecognitionJobService) create(orgID uuid.UUID, userID uuid.UUID, phraseStreamID uuid.UUID, pathToAudio string,
+	recordTime time.Time) (*domain.RecognitionJob, error) {
+	now := s.now()
+	job := &domain.RecognitionJob{
+		ID:             uuid.New(),
+		OrganizationID: orgID,
+		UserID:         userID,
+		PhraseStreamID: phraseStreamID,
+		PathToAudio:    pathToAudio,
+		RecordTime:     recordTime,
+		Status:         domain.RecognitionJobPending,
+		NextRunAt:      now,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.jobs.Create(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// GetJob returns a job of the student.
+func (s *RecognitionJobService) GetJob(orgID uuid.UUID, userID uuid.UUID, id uuid.UUID) (*domain.RecognitionJob, error) {
+	job, err := s.jobs.GetByID(orgID, id)
+	if err != nil || job.UserID != userID {
+		return nil, ErrRecognitionJobNotFound
+	}
+	return job, nil
+}
+
+// Run processes due jobs every poll interval until ctx is done.
+func (s *RecognitionJobService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.ProcessDue(ctx); err != nil {
+			log.Printf("can't process recognition jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue claims the jobs that are due one by one and advances each of them by one step.
+func (s *RecognitionJobService) ProcessDue(ctx context.Context) error {
+	for i := 0; i < jobBatchSize; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		jobs, err := s.jobs.ClaimDue(s.now(), jobLease, 1)
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		if err := s.process(ctx, &jobs[0]); err != nil {
+			log.Printf("can't save recognition job %s: %v", jobs[0].ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *RecognitionJobService) process(ctx context.Context, job *domain.RecognitionJob) error {
+	if job.AnswerID != nil {
+		// The answer is saved, only the status of the job was not.
+		return s.finish(job, domain.RecognitionJobDone, "")
+	}
+	var recognition *client.Recognition
+	var err error
+	if async, ok := s.recognizer.(client.AsyncRecognizer); ok {
+		if job.OperationID == "" {
+			job.OperationID, err = async.SubmitRecognition(ctx, job.PathToAudio)
+			if err != nil {
+				return s.retry(ctx, job, err)
+			}
+			return s.reschedule(job, s.pollInterval)
+		}
+		var done bool
+		recognition, done, err = async.RecognitionResult(ctx, job.OperationID)
+		if err != nil {
+			return s.retry(ctx, job, err)
+		}
+		if !done {
+			return s.reschedule(job, s.pollInterval)
+		}
+	} else {
+		recognition, err = client.Recognize(ctx, s.recognizer, job.PathToAudio)
+		if err != nil {
+			return s.retry(ctx, job, err)
+		}
+	}
+
+	answerID, err := s.grader.GradeAnswer(job, recognition)
+	if errors.Is(err, repository.ErrJobAlreadyGraded) {
+		// The lease ran out while another worker graded the job, its answer is kept.
+		answerID, err = s.grader.JobAnswerID(job)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return s.finish(job, domain.RecognitionJobFailed, fmt.Sprintf("can't grade the answer: %v", err))
+	}
+	job.AnswerID = &answerID
+	return s.finish(job, domain.RecognitionJobDone, "")
+}
+
+// retry runs the job again later when the provider is throttled or down and fails it otherwise.
+func (s *RecognitionJobService) retry(ctx context.Context, job *domain.RecognitionJob, err error) error {
+	if ctx.Err() != nil {
+		// The worker is stopping, the job runs again when its lease runs out.
+		return nil
+	}
+	transient := errors.Is(err, client.ErrQuotaExceeded) || errors.Is(err, client.ErrProviderUnavailable)
+	if !transient || job.Attempts >= maxJobRetries {
+		return s.finish(job, domain.RecognitionJobFailed, err.Error())
+	}
+	job.Attempts++
+	delay := s.pollInterval << job.Attempts
+	if delay > maxJobRetryDelay {
+		delay = maxJobRetryDelay
+	}
+	job.Error = err.Error()
+	return s.reschedule(job, delay)
+}
+
+func (s *RecognitionJobService) reschedule(job *domain.RecognitionJob, delay time.Duration) error {
+	job.UpdatedAt = s.now()
+	job.NextRunAt = job.UpdatedAt.Add(delay)
+	return s.jobs.Update(job)
+}
+
+func (s *RecognitionJobService) finish(job *domain.RecognitionJob, status string, message string) error {
+	job.Status = status
+	job.Error = message
+	job.UpdatedAt = s.now()
+	return s.jobs.Update(job)
+}
@@ -0,0 +1,367 @@
+package services
+
+import (
+	"context"
+	"diplom/client"
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"diplom/internal/storage"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type MockRecognitionJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecognitionJobRepository) Create(job *domain.RecognitionJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockRecognitionJobRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.RecognitionJob, error) {
+	args := m.Called(orgID, id)
+	return args.Get(0).(*domain.RecognitionJob), args.Error(1)
+}
+
+func (m *MockRecognitionJobRepository) ClaimDue(now time.Time, lease time.Duration, limit int) ([]domain.RecognitionJob, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]domain.RecognitionJob), args.Error(1)
+}
+
+func (m *MockRecognitionJobRepository) Update(job *domain.RecognitionJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+type MockScenarioRepository struct {
+	mock.Mock
+}
+
+func (m *MockScenarioRepository) Create(scenario *domain.Scenario) (uuid.UUID, error) {
+	args := m.Called(scenario)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockScenarioRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Scenario, error) {
+	args := m.Called(orgID, id)
+	return args.Get(0).(*domain.Scenario), args.Error(1)
+}
+
+func (m *MockScenarioRepository) Update(scenario *domain.Scenario) error {
+	args := m.Called(scenario)
+	return args.Error(0)
+}
+
+func (m *MockScenarioRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
+	return args.Error(0)
+}
+
+func (m *MockScenarioRepository) GetAll(orgID uuid.UUID) ([]domain.Scenario, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]domain.Scenario), args.Error(1)
+}
+
+type MockAnswerGrader struct {
+	mock.Mock
+}
+
+func (m *MockAnswerGrader) GradeAnswer(job *domain.RecognitionJob, recognition *client.Recognition) (uuid.UUID, error) {
+	args := m.Called(job, recognition)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockAnswerGrader) JobAnswerID(job *domain.RecognitionJob) (uuid.UUID, error) {
+	args := m.Called(job)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+type failingRecognizer struct {
+	err error
+}
+
+func (r failingRecognizer) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	return "", r.err
+}
+
+func TestRecognitionJobs(t *testing.T) {
+	store, err := storage.NewAudioStore(t.TempDir())
+	require.NoError(t, err)
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	orgID, userID := uuid.New(), uuid.New()
+	path, err := store.Save(answerDir(userID), "answer.wav", strings.NewReader("cleared for takeoff"))
+	require.NoError(t, err)
+	// The phrase streams of the tests belong to a scenario of the student.
+	scenarioID := uuid.New()
+	scenarios := new(MockScenarioRepository)
+	scenarios.On("GetByID", orgID, scenarioID).Return(&domain.Scenario{ID: scenarioID, UserID: userID}, nil)
+
+	newService := func(jobs *MockRecognitionJobRepository, recognizer client.SpeechRecognizer, grader AnswerGrader) *RecognitionJobService {
+		s := NewRecognitionJobService(jobs, new(MockPhraseStreamRepository), new(MockScenarioRepository), recognizer, grader, store, WithJobPollInterval(time.Second))
+		s.now = func() time.Time { return now }
+		return s
+	}
+	// claim makes ClaimDue hand out the jobs one at a time and then report that none are due.
+	claim := func(jobs *MockRecognitionJobRepository, claimed ...domain.RecognitionJob) {
+		for _, job := range claimed {
+			jobs.On("ClaimDue", now, jobLease, 1).Return([]domain.RecognitionJob{job}, nil).Once()
+		}
+		jobs.On("ClaimDue", now, jobLease, 1).Return([]domain.RecognitionJob(nil), nil).Once()
+	}
+	pendingJob := func() domain.RecognitionJob {
+		return domain.RecognitionJob{ID: uuid.New(), OrganizationID: orgID, UserID: userID, PhraseStreamID: uuid.New(),
+			PathToAudio: path, Status: domain.RecognitionJobPending, NextRunAt: now}
+	}
+
+	t.Run("submit creates a pending job", func(t *testing.T) {
+		jobs, streams := new(MockRecognitionJobRepository), new(MockPhraseStreamRepository)
+		streamID := uuid.New()
+		streams.On("GetByID", orgID, streamID).Return(&domain.PhraseStream{ID: streamID, ScenarioID: scenarioID}, nil)
+		jobs.On("Create", mock.AnythingOfType("*domain.RecognitionJob")).Return(nil)
+		s := NewRecognitionJobService(jobs, streams, scenarios, plainRecognizer{}, new(MockAnswerGrader), store)
+
+		job, err := s.Submit(orgID, userID, streamID, filepath.Join(answerDir(userID), filepath.Base(path)), now)
+
+		require.NoError(t, err)
+		assert.Equal(t, path, job.PathToAudio)
+		assert.Equal(t, domain.RecognitionJobPending, job.Status)
+		assert.Equal(t, streamID, job.PhraseStreamID)
+		assert.Equal(t, userID, job.UserID)
+		jobs.AssertExpectations(t)
+	})
+
+	t.Run("submit to an unknown phrase stream", func(t *testing.T) {
+		jobs, streams := new(MockRecognitionJobRepository), new(MockPhraseStreamRepository)
+		streamID := uuid.New()
+		streams.On("GetByID", orgID, streamID).Return((*domain.PhraseStream)(nil), errors.New("no rows in result set"))
+		s := NewRecognitionJobService(jobs, streams, scenarios, plainRecognizer{}, new(MockAnswerGrader), store)
+
+		_, err := s.Submit(orgID, userID, streamID, path, now)
+
+		assert.ErrorIs(t, err, ErrPhraseStreamNotFound)
+		jobs.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("submit to a phrase stream of another student", func(t *testing.T) {
+		jobs, streams := new(MockRecognitionJobRepository), new(MockPhraseStreamRepository)
+		streamID := uuid.New()
+		streams.On("GetByID", orgID, streamID).Return(&domain.PhraseStream{ID: streamID, ScenarioID: scenarioID}, nil)
+		s := NewRecognitionJobService(jobs, streams, scenarios, plainRecognizer{}, new(MockAnswerGrader), store)
+
+		_, err := s.Submit(orgID, uuid.New(), streamID, path, now)
+		assert.ErrorIs(t, err, ErrPhraseStreamNotFound)
+		_, err = s.SubmitRecording(orgID, uuid.New(), streamID, "answer.wav", strings.NewReader("RIFF"), now)
+		assert.ErrorIs(t, err, ErrPhraseStreamNotFound)
+		jobs.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("paths outside the uploads of the student are rejected", func(t *testing.T) {
+		jobs, streams := new(MockRecognitionJobRepository), new(MockPhraseStreamRepository)
+		streamID := uuid.New()
+		streams.On("GetByID", orgID, streamID).Return(&domain.PhraseStream{ID: streamID, ScenarioID: scenarioID}, nil)
+		outside := filepath.Join(t.TempDir(), "config.yaml")
+		require.NoError(t, os.WriteFile(outside, []byte("secret"), 0644))
+		classmate, err := store.Save(answerDir(uuid.New()), "answer.wav", strings.NewReader("RIFF"))
+		require.NoError(t, err)
+		s := NewRecognitionJobService(jobs, streams, scenarios, plainRecognizer{}, new(MockAnswerGrader), store)
+
+		for _, p := range []string{outside, "../config.yaml", "answers/../../config.yaml", "", classmate} {
+			_, err := s.Submit(orgID, userID, streamID, p, now)
+
+			assert.ErrorIs(t, err, storage.ErrOutsideRoot, p)
+		}
+		jobs.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("uploaded recording gets a generated name", func(t *testing.T) {
+		jobs, streams := new(MockRecognitionJobRepository), new(MockPhraseStreamRepository)
+		streamID := uuid.New()
+		streams.On("GetByID", orgID, streamID).Return(&domain.PhraseStream{ID: streamID, ScenarioID: scenarioID}, nil)
+		jobs.On("Create", mock.AnythingOfType("*domain.RecognitionJob")).Return(nil)
+		s := NewRecognitionJobService(jobs, streams, scenarios, plainRecognizer{}, new(MockAnswerGrader), store)
+
+		job, err := s.SubmitRecording(orgID, userID, streamID, "../../etc/passwd.wav", strings.NewReader("RIFF"), now)
+
+		require.NoError(t, err)
+		resolved, err := store.Resolve(job.PathToAudio)
+		require.NoError(t, err)
+		assert.Equal(t, ".wav", filepath.Ext(resolved))
+		assert.NotContains(t, resolved, "passwd")
+		_, err = store.ResolveIn(answerDir(userID), resolved)
+		assert.NoError(t, err)
+		data, err := os.ReadFile(resolved)
+		require.NoError(t, err)
+		assert.Equal(t, "RIFF", string(data))
+	})
+
+	t.Run("jobs of other students are not found", func(t *testing.T) {
+		jobs := new(MockRecognitionJobRepository)
+		job := pendingJob()
+		jobs.On("GetByID", orgID, job.ID).Return(&job, nil)
+		s := newService(jobs, plainRecognizer{}, new(MockAnswerGrader))
+
+		_, err := s.GetJob(orgID, uuid.New(), job.ID)
+
+		assert.ErrorIs(t, err, ErrRecognitionJobNotFound)
+	})
+
+	t.Run("synchronous recognizer grades the answer", func(t *testing.T) {
+		jobs, grader := new(MockRecognitionJobRepository), new(MockAnswerGrader)
+		answerID := uuid.New()
+		claim(jobs, pendingJob(), pendingJob())
+		grader.On("GradeAnswer", mock.Anything, &client.Recognition{Text: "cleared for takeoff"}).Return(answerID, nil)
+		var saved []domain.RecognitionJob
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			saved = append(saved, *args.Get(0).(*domain.RecognitionJob))
+		}).Return(nil)
+
+		require.NoError(t, newService(jobs, plainRecognizer{}, grader).ProcessDue(context.Background()))
+
+		require.Len(t, saved, 2)
+		assert.Equal(t, domain.RecognitionJobDone, saved[1].Status)
+		require.NotNil(t, saved[1].AnswerID)
+		assert.Equal(t, answerID, *saved[1].AnswerID)
+		grader.AssertExpectations(t)
+		jobs.AssertNumberOfCalls(t, "ClaimDue", 3)
+	})
+
+	t.Run("job graded by another worker is finished with its answer", func(t *testing.T) {
+		jobs, grader := new(MockRecognitionJobRepository), new(MockAnswerGrader)
+		answerID := uuid.New()
+		claim(jobs, pendingJob())
+		grader.On("GradeAnswer", mock.Anything, mock.Anything).Return(uuid.Nil, repository.ErrJobAlreadyGraded)
+		grader.On("JobAnswerID", mock.Anything).Return(answerID, nil)
+		var saved domain.RecognitionJob
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*domain.RecognitionJob)
+		}).Return(nil)
+
+		require.NoError(t, newService(jobs, plainRecognizer{}, grader).ProcessDue(context.Background()))
+
+		assert.Equal(t, domain.RecognitionJobDone, saved.Status)
+		require.NotNil(t, saved.AnswerID)
+		assert.Equal(t, answerID, *saved.AnswerID)
+	})
+
+	t.Run("job with a saved answer is finished without grading it again", func(t *testing.T) {
+		jobs, grader := new(MockRecognitionJobRepository), new(MockAnswerGrader)
+		job := pendingJob()
+		answerID := uuid.New()
+		job.AnswerID = &answerID
+		claim(jobs, job)
+		var saved domain.RecognitionJob
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*domain.RecognitionJob)
+		}).Return(nil)
+
+		require.NoError(t, newService(jobs, plainRecognizer{}, grader).ProcessDue(context.Background()))
+
+		assert.Equal(t, domain.RecognitionJobDone, saved.Status)
+		grader.AssertNotCalled(t, "GradeAnswer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("asynchronous recognizer is polled until the operation is done", func(t *testing.T) {
+		var polls int
+		var submitted map[string]any
+		var folder string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/stt/recognizeFileAsync":
+				folder = r.Header.Get("x-folder-id")
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&submitted))
+				w.Write([]byte(`{"id": "op-1", "done": false}`))
+			case "/operations/op-1":
+				polls++
+				fmt.Fprintf(w, `{"id": "op-1", "done": %t}`, polls > 1)
+			case "/stt/getRecognition":
+				assert.Equal(t, "op-1", r.URL.Query().Get("operationId"))
+				w.Write([]byte(`{"result": {"partial": {"alternatives": [{"text": "cleared"}]}}}
+{"result": {"final": {"alternatives": [{"text": "cleared for takeoff", "confidence": 0.9,
+	"words": [{"text": "cleared", "startTimeMs": "0", "endTimeMs": "400"}, {"text": "for", "startTimeMs": "500", "endTimeMs": "600"},
+	{"text": "takeoff", "startTimeMs": "700", "endTimeMs": 1200}]}]}}}
+`))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		defer server.Close()
+		recognizer, err := client.NewYandexSpeechClient(client.ProviderConfig{APIKey: "key", FolderID: "folder",
+			AsyncRecognitionURL: server.URL + "/stt/", OperationURL: server.URL + "/operations"})
+		require.NoError(t, err)
+
+		jobs, grader := new(MockRecognitionJobRepository), new(MockAnswerGrader)
+		job := pendingJob()
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			job = *args.Get(0).(*domain.RecognitionJob)
+		}).Return(nil)
+		grader.On("GradeAnswer", mock.Anything, mock.Anything).Return(uuid.New(), nil)
+		s := newService(jobs, recognizer, grader)
+
+		for step := 0; step < 3; step++ {
+			claim(jobs, job)
+			require.NoError(t, s.ProcessDue(context.Background()))
+			if step < 2 {
+				assert.Equal(t, domain.RecognitionJobPending, job.Status)
+				assert.Equal(t, now.Add(time.Second), job.NextRunAt)
+			}
+		}
+
+		assert.Equal(t, "folder", folder)
+		assert.NotEmpty(t, submitted["content"])
+		assert.Equal(t, "op-1", job.OperationID)
+		assert.Equal(t, domain.RecognitionJobDone, job.Status)
+		recognition := grader.Calls[0].Arguments.Get(1).(*client.Recognition)
+		assert.Equal(t, "cleared for takeoff", recognition.Text)
+		require.Len(t, recognition.Words, 3)
+		assert.Equal(t, client.RecognizedWord{Text: "takeoff", Start: 700 * time.Millisecond, End: 1200 * time.Millisecond,
+			Confidence: 0.9}, recognition.Words[2])
+	})
+
+	t.Run("throttled recognition is retried later", func(t *testing.T) {
+		jobs := new(MockRecognitionJobRepository)
+		job := pendingJob()
+		job.Attempts = 1
+		claim(jobs, job)
+		var saved domain.RecognitionJob
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*domain.RecognitionJob)
+		}).Return(nil)
+		recognizer := failingRecognizer{err: fmt.Errorf("yandex: %w", client.ErrQuotaExceeded)}
+
+		require.NoError(t, newService(jobs, recognizer, new(MockAnswerGrader)).ProcessDue(context.Background()))
+
+		assert.Equal(t, domain.RecognitionJobPending, saved.Status)
+		assert.Equal(t, 2, saved.Attempts)
+		assert.Equal(t, now.Add(4*time.Second), saved.NextRunAt)
+		assert.NotEmpty(t, saved.Error)
+	})
+
+	t.Run("rejected recognition fails the job", func(t *testing.T) {
+		jobs, grader := new(MockRecognitionJobRepository), new(MockAnswerGrader)
+		claim(jobs, pendingJob())
+		var saved domain.RecognitionJob
+		jobs.On("Update", mock.Anything).Run(func(args mock.Arguments) {
+			saved = *args.Get(0).(*domain.RecognitionJob)
+		}).Return(nil)
+		recognizer := failingRecognizer{err: fmt.Errorf("yandex: %w", client.ErrUnauthorized)}
+
+		require.NoError(t, newService(jobs, recognizer, grader).ProcessDue(context.Background()))
+
+		assert.Equal(t, domain.RecognitionJobFailed, saved.Status)
+		assert.Contains(t, saved.Error, client.ErrUnauthorized.Error())
+		grader.AssertNotCalled(t, "GradeAnswer", mock.Anything, mock.Anything)
+	})
+}
@@ -18,10 +18,10 @@ func (s *ScenarioService) CreateScenario(scenario *domain.Scenario) (uuid.UUID,
 	return s.repo.Create(scenario)
 }
 
-func (s *ScenarioService) GetScenario(id uuid.UUID) (*domain.Scenario, error) {
-	return s.repo.GetByID(id)
+func (s *ScenarioService) GetScenario(orgID uuid.UUID, id uuid.UUID) (*domain.Scenario, error) {
+	return s.repo.GetByID(orgID, id)
 }
 
-func (s *ScenarioService) DeleteScenario(id uuid.UUID) error {
-	return s.repo.Delete(id)
+func (s *ScenarioService) DeleteScenario(orgID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(orgID, id)
 }
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"diplom/client"
+	"diplom/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	stt "github.com/yandex-cloud/go-genproto/yandex/cloud/ai/stt/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mockRecognizer is a local SpeechKit: every audio chunk holds words, a partial hypothesis follows each
+// chunk and the final one the end of the audio.
+type mockRecognizer struct {
+	stt.UnimplementedRecognizerServer
+	options       *stt.StreamingOptions
+	authorization []string
+	err           error
+}
+
+func (m *mockRecognizer) RecognizeStreaming(stream grpc.BidiStreamingServer[stt.StreamingRequest, stt.StreamingResponse]) error {
+	md, _ := metadata.FromIncomingContext(stream.Context())
+	m.authorization = md.Get("authorization")
+	if m.err != nil {
+		return m.err
+	}
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	m.options = first.GetSessionOptions()
+	var words []*stt.Word
+	alternative := func() *stt.AlternativeUpdate {
+		texts := make([]string, len(words))
+		for i, w := range words {
+			texts[i] = w.Text
+		}
+		return &stt.AlternativeUpdate{Alternatives: []*stt.Alternative{{Words: words, Text: strings.Join(texts, " "),
+			EndTimeMs: int64(len(words)) * 500, Confidence: 0.9}}}
+	}
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return stream.Send(&stt.StreamingResponse{Event: &stt.StreamingResponse_Final{Final: alternative()}})
+		}
+		if err != nil {
+			return err
+		}
+		for _, text := range strings.Fields(string(request.GetChunk().GetData())) {
+			start := int64(len(words)) * 500
+			words = append(words, &stt.Word{Text: text, StartTimeMs: start, EndTimeMs: start + 400})
+		}
+		if err := stream.Send(&stt.StreamingResponse{Event: &stt.StreamingResponse_Partial{Partial: alternative()}}); err != nil {
+			return err
+		}
+	}
+}
+
+func startMockRecognizer(t *testing.T, recognizer *mockRecognizer) *client.YandexStreamingClient {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	stt.RegisterRecognizerServer(server, recognizer)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	speechKit, err := client.NewYandexStreamingClient(client.ProviderConfig{APIKey: "key",
+		StreamingEndpoint: listener.Addr().String(), StreamingInsecure: true, BreakerThreshold: 1})
+	require.NoError(t, err)
+	t.Cleanup(func() { speechKit.Close() })
+	return speechKit
+}
+
+func TestRecognizeStreaming(t *testing.T) {
+	t.Run("partial and final hypotheses", func(t *testing.T) {
+		recognizer := &mockRecognizer{}
+		speechKit := startMockRecognizer(t, recognizer)
+		audio := make(chan []byte)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stream, err := speechKit.RecognizeStreaming(ctx, client.StreamingOptions{SampleRate: 16000, Language: "en-US"}, audio)
+		require.NoError(t, err)
+		go func() {
+			for _, chunk := range []string{"cleared", "for takeoff"} {
+				audio <- []byte(chunk)
+			}
+			close(audio)
+		}()
+		var hypotheses []client.Hypothesis
+		for hypothesis := range stream.Hypotheses() {
+			hypotheses = append(hypotheses, hypothesis)
+		}
+
+		require.NoError(t, stream.Err())
+		require.Len(t, hypotheses, 3)
+		assert.Equal(t, "cleared", hypotheses[0].Text)
+		assert.False(t, hypotheses[0].Final)
+		assert.Equal(t, "cleared for takeoff", hypotheses[1].Text)
+		final := hypotheses[2]
+		assert.True(t, final.Final)
+		assert.Equal(t, "cleared for takeoff", final.Text)
+		assert.Equal(t, 0.9, final.Confidence)
+		assert.Equal(t, client.RecognizedWord{Text: "takeoff", Start: time.Second, End: 1400 * time.Millisecond}, final.Words[2])
+		assert.Equal(t, []string{"Api-Key key"}, recognizer.authorization)
+		raw := recognizer.options.GetRecognitionModel().GetAudioFormat().GetRawAudio()
+		require.NotNil(t, raw)
+		assert.Equal(t, int64(16000), raw.SampleRateHertz)
+		assert.Equal(t, []string{"en-US"}, recognizer.options.RecognitionModel.LanguageRestriction.LanguageCode)
+	})
+
+	t.Run("audio file", func(t *testing.T) {
+		recognizer := &mockRecognizer{}
+		speechKit := startMockRecognizer(t, recognizer)
+		path := filepath.Join(t.TempDir(), "answer.wav")
+		require.NoError(t, os.WriteFile(path, []byte("runway two seven"), 0644))
+
+		text, err := speechKit.RecognizeSpeech(context.Background(), path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "runway two seven", text)
+		assert.Equal(t, stt.ContainerAudio_WAV, recognizer.options.GetRecognitionModel().GetAudioFormat().GetContainerAudio().GetContainerAudioType())
+	})
+
+	t.Run("provider errors", func(t *testing.T) {
+		recognizer := &mockRecognizer{err: status.Error(codes.ResourceExhausted, "quota")}
+		speechKit := startMockRecognizer(t, recognizer)
+		path := filepath.Join(t.TempDir(), "answer.ogg")
+		require.NoError(t, os.WriteFile(path, []byte("runway"), 0644))
+
+		_, err := speechKit.RecognizeSpeech(context.Background(), path)
+		assert.ErrorIs(t, err, client.ErrQuotaExceeded)
+
+		recognizer.err = status.Error(codes.Unavailable, "down")
+		_, err = speechKit.RecognizeSpeech(context.Background(), path)
+		assert.ErrorIs(t, err, client.ErrProviderUnavailable)
+		// The breaker opened after one outage, the server isn't called again.
+		recognizer.err = nil
+		_, err = speechKit.RecognizeSpeech(context.Background(), path)
+		assert.ErrorIs(t, err, client.ErrProviderUnavailable)
+		assert.Nil(t, recognizer.options)
+	})
+
+	t.Run("cancelled session", func(t *testing.T) {
+		speechKit := startMockRecognizer(t, &mockRecognizer{})
+		ctx, cancel := context.WithCancel(context.Background())
+		audio := make(chan []byte)
+
+		stream, err := speechKit.RecognizeStreaming(ctx, client.StreamingOptions{Container: "ogg"}, audio)
+		require.NoError(t, err)
+		audio <- []byte("cleared")
+		<-stream.Hypotheses()
+		cancel()
+		for range stream.Hypotheses() {
+		}
+
+		assert.ErrorIs(t, stream.Err(), context.Canceled)
+	})
+}
+
+// plainRecognizer only returns text, like the REST API of SpeechKit.
+type plainRecognizer struct{}
+
+func (plainRecognizer) RecognizeSpeech(ctx context.Context, audioFilePath string) (string, error) {
+	return "cleared for takeoff", nil
+}
+
+func TestRecognizeWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answer.wav")
+	require.NoError(t, os.WriteFile(path, []byte("cleared for takeoff"), 0644))
+
+	t.Run("streaming words get the confidence of their hypothesis", func(t *testing.T) {
+		speechKit := startMockRecognizer(t, &mockRecognizer{})
+
+		recognition, err := client.Recognize(context.Background(), speechKit, path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "cleared for takeoff", recognition.Text)
+		require.Len(t, recognition.Words, 3)
+		assert.Equal(t, client.RecognizedWord{Text: "for", Start: 500 * time.Millisecond, End: 900 * time.Millisecond,
+			Confidence: 0.9}, recognition.Words[1])
+		assert.Equal(t, []domain.AnswerWord{{Word: "cleared", StartMs: 0, EndMs: 400, Confidence: 0.9},
+			{Word: "for", StartMs: 500, EndMs: 900, Confidence: 0.9}, {Word: "takeoff", StartMs: 1000, EndMs: 1400, Confidence: 0.9}},
+			answerWords(recognition.Words))
+	})
+
+	t.Run("fake recognizer places the words in order", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path+".txt", []byte("cleared for takeoff"), 0644))
+		defer os.Remove(path + ".txt")
+
+		recognition, err := client.Recognize(context.Background(), client.NewFakeSpeechClient(nil), path)
+
+		require.NoError(t, err)
+		require.Len(t, recognition.Words, 3)
+		for i, word := range recognition.Words {
+			assert.Equal(t, 1.0, word.Confidence)
+			assert.Less(t, word.Start, word.End)
+			if i > 0 {
+				assert.Less(t, recognition.Words[i-1].End, word.Start)
+			}
+		}
+	})
+
+	t.Run("recognizer without words", func(t *testing.T) {
+		recognition, err := client.Recognize(context.Background(), plainRecognizer{}, path)
+
+		require.NoError(t, err)
+		assert.Equal(t, "cleared for takeoff", recognition.Text)
+		assert.Empty(t, recognition.Words)
+		assert.Equal(t, []domain.AnswerWord{}, answerWords(recognition.Words))
+	})
+}
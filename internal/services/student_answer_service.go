@@ -4,6 +4,7 @@ import (
 	"diplom/client"
 	"diplom/internal/domain"
 	"diplom/internal/repository"
+	"diplom/internal/storage"
 	"github.com/google/uuid"
 	"math"
 	"strings"
@@ -27,31 +28,52 @@ type StudentAnswerService struct {
 
 	phraseStream *repository.PhraseStreamRepository
 	phrase       *repository.PhraseRepository
-	speechKit    *client.YandexSpeechClient
+	audio        *storage.AudioStore
+
+	onScenarioResult func(orgID uuid.UUID, result *domain.ScenarioResult)
 }
 
 func NewStudentAnswerService(answer *repository.AnswerRepository, audio *repository.AudioAnswerRepository,
-	phs *repository.PhraseStreamRepository, ph *repository.PhraseRepository) *StudentAnswerService {
-	return &StudentAnswerService{answerRepository: answer, audioAnswerRepository: audio,
-		phraseStream: phs, phrase: ph, speechKit: client.NewYandexSpeechClient()}
+	phs *repository.PhraseStreamRepository, ph *repository.PhraseRepository,
+	options ...func(*StudentAnswerService)) *StudentAnswerService {
+	s := &StudentAnswerService{answerRepository: answer, audioAnswerRepository: audio,
+		phraseStream: phs, phrase: ph}
+	for _, o := range options {
+		o(s)
+	}
+	return s
 }
 
-func (s *StudentAnswerService) CreateAnswer(answer *domain.Answer, audio *domain.AudioAnswer, phraseStreamID uuid.UUID) (uuid.UUID, bool, string, error) {
-	phraseStream, err := s.phraseStream.GetByID(phraseStreamID)
-	if err != nil {
-		return uuid.Nil, false, "", err
+// WithScenarioResultListener registers a function that gets the updated scenario result after every answer,
+// e.g. to pass the score back to an LMS.
+func WithScenarioResultListener(listener func(orgID uuid.UUID, result *domain.ScenarioResult)) func(*StudentAnswerService) {
+	return func(s *StudentAnswerService) {
+		s.onScenarioResult = listener
 	}
-	phrase, err := s.phrase.GetByID(phraseStream.PhraseID)
-	if err != nil {
-		return uuid.Nil, false, "", err
+}
+
+// WithAnswerAudio sets the directory of the recordings, their files are removed with the answers.
+func WithAnswerAudio(store *storage.AudioStore) func(*StudentAnswerService) {
+	return func(s *StudentAnswerService) {
+		s.audio = store
 	}
+}
 
-	text, err := s.speechKit.RecognizeSpeech(audio.PathToAudio)
-	similirity := CosineSimilarity(phrase.Text, text)
+// GradeAnswer saves the answer recognized by a finished job, with the recognized words when the
+// recognizer reports them, and grades it against the phrase. The recording, the answer and the grade are
+// saved together; a job that already has an answer is rejected with repository.ErrJobAlreadyGraded.
+func (s *StudentAnswerService) GradeAnswer(job *domain.RecognitionJob, recognition *client.Recognition) (uuid.UUID, error) {
+	orgID := job.OrganizationID
+	phraseStream, err := s.phraseStream.GetByID(orgID, job.PhraseStreamID)
 	if err != nil {
-		return uuid.Nil, false, "", err
+		return uuid.Nil, err
+	}
+	phrase, err := s.phrase.GetByID(orgID, phraseStream.PhraseID)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
+	similirity := CosineSimilarity(phrase.Text, recognition.Text)
 	var status string
 	var isCorrect bool
 	if similirity > 0.1 {
@@ -62,23 +84,35 @@ func (s *StudentAnswerService) CreateAnswer(answer *domain.Answer, audio *domain
 		status = "fail"
 	}
 
-	audioID, err := s.audioAnswerRepository.Create(audio)
-	if err != nil {
-		return uuid.Nil, false, "", err
+	audio := &domain.AudioAnswer{PathToAudio: job.PathToAudio, RecordTime: job.RecordTime, OrganizationID: orgID,
+		RecognitionJobID: &job.ID}
+	answer := &domain.Answer{
+		UserID:    job.UserID,
+		Text:      recognition.Text,
+		IsCorrect: isCorrect,
+		Words:     answerWords(recognition.Words),
 	}
-	answer.AudioAnswerID = audioID
-	answer.Text = text
-	answer.IsCorrect = isCorrect
-	answerID, err := s.answerRepository.Create(answer)
+	answerID, err := s.answerRepository.CreateGraded(orgID, audio, answer, job.PhraseStreamID, status)
 	if err != nil {
-		return uuid.Nil, false, "", err
+		return uuid.Nil, err
 	}
-
-	err = s.phraseStream.Update(phraseStreamID, answerID, status)
-	if err != nil {
-		return uuid.Nil, false, "", err
+	if s.onScenarioResult != nil {
+		// The answer is already saved, a failure to compute the result must not fail it.
+		if result, err := s.GetScenarioResult(orgID, phraseStream.ScenarioID); err == nil {
+			s.onScenarioResult(orgID, result)
+		}
 	}
-	return answerID, isCorrect, text, err
+	return answerID, nil
+}
+
+// JobAnswerID returns the answer a recognition job has saved.
+func (s *StudentAnswerService) JobAnswerID(job *domain.RecognitionJob) (uuid.UUID, error) {
+	return s.answerRepository.GetIDByRecognitionJob(job.ID)
+}
+
+// GetScenarioResult returns how many phrases of the scenario the student has answered correctly.
+func (s *StudentAnswerService) GetScenarioResult(orgID uuid.UUID, scenarioID uuid.UUID) (*domain.ScenarioResult, error) {
+	return s.phraseStream.GetScenarioResult(orgID, scenarioID)
 }
 
 func (s *StudentAnswerService) CreateAudioAnswer(answer *domain.AudioAnswer) (uuid.UUID, error) {
@@ -86,50 +120,72 @@ func (s *StudentAnswerService) CreateAudioAnswer(answer *domain.AudioAnswer) (uu
 	return uuid.Nil, nil
 }
 
-func (s *StudentAnswerService) GetAllAnswers() ([]domain.Answer, error) {
-	return s.answerRepository.GetAll()
+func (s *StudentAnswerService) GetAllAnswers(orgID uuid.UUID) ([]domain.Answer, error) {
+	return s.answerRepository.GetAll(orgID)
 }
 
-func (s *StudentAnswerService) GetAnswerByID(id uuid.UUID) (*domain.Answer, error) {
-	return s.answerRepository.GetByID(id)
+func (s *StudentAnswerService) GetAnswerByID(orgID uuid.UUID, id uuid.UUID) (*domain.Answer, error) {
+	return s.answerRepository.GetByID(orgID, id)
 }
 
-func (s *StudentAnswerService) GetAudioAnswerByID(id uuid.UUID) (*domain.AudioAnswer, error) {
-	//return s.audioAnswerRepository.GetByID(id), nil
-	return nil, nil
+func (s *StudentAnswerService) GetAudioAnswerByID(orgID uuid.UUID, id uuid.UUID) (*domain.AudioAnswer, error) {
+	return s.audioAnswerRepository.GetByID(orgID, id)
 }
 
-func (s *StudentAnswerService) GetAnswer(id uuid.UUID) (*domain.Answer, *domain.AudioAnswer, error) {
-	answer, err := s.GetAnswerByID(id)
+func (s *StudentAnswerService) GetAnswer(orgID uuid.UUID, id uuid.UUID) (*domain.Answer, *domain.AudioAnswer, error) {
+	answer, err := s.GetAnswerByID(orgID, id)
 	if err != nil {
 		return nil, nil, err
 	}
-	audio, err := s.GetAudioAnswerByID(answer.AudioAnswerID)
+	if answer.AudioAnswerID == uuid.Nil {
+		return answer, nil, nil
+	}
+	audio, err := s.GetAudioAnswerByID(orgID, answer.AudioAnswerID)
 	if err != nil {
 		return nil, nil, err
 	}
 	return answer, audio, nil
 }
 
-func (s *StudentAnswerService) UpdateAnswer(answer *domain.Answer) error {
-	return s.answerRepository.Update(answer)
+func (s *StudentAnswerService) UpdateAnswer(orgID uuid.UUID, answer *domain.Answer) error {
+	return s.answerRepository.Update(orgID, answer)
 }
 
-func (s *StudentAnswerService) DeleteAnswer(id uuid.UUID) error {
-	answer, err := s.GetAnswerByID(id)
+// DeleteAnswer removes the answer and its recording, including the audio file.
+func (s *StudentAnswerService) DeleteAnswer(orgID uuid.UUID, id uuid.UUID) error {
+	path, err := s.answerRepository.Delete(orgID, id)
 	if err != nil {
 		return err
 	}
-	err = s.DeleteAudioAnswer(answer.AudioAnswerID)
+	if path == "" {
+		return nil
+	}
+	return removeFiles(s.audio, []string{path})
+}
+
+// DeleteAudioAnswer removes a recording and its audio file.
+func (s *StudentAnswerService) DeleteAudioAnswer(orgID uuid.UUID, id uuid.UUID) error {
+	audio, err := s.audioAnswerRepository.GetByID(orgID, id)
 	if err != nil {
 		return err
 	}
-	return s.answerRepository.Delete(id)
+	if err := s.audioAnswerRepository.Delete(orgID, id); err != nil {
+		return err
+	}
+	return removeFiles(s.audio, []string{audio.PathToAudio})
 }
 
-func (s *StudentAnswerService) DeleteAudioAnswer(id uuid.UUID) error {
-	//return s.audioAnswerRepository.Delete(id)
-	return nil
+func answerWords(words []client.RecognizedWord) []domain.AnswerWord {
+	result := make([]domain.AnswerWord, len(words))
+	for i, word := range words {
+		result[i] = domain.AnswerWord{
+			Word:       word.Text,
+			StartMs:    word.Start.Milliseconds(),
+			EndMs:      word.End.Milliseconds(),
+			Confidence: word.Confidence,
+		}
+	}
+	return result
 }
 
 func tokenize(s string) []string {
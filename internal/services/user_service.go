@@ -1,34 +1,140 @@
 package services
 
 import (
+	"archive/zip"
+	"crypto/rand"
 	"diplom/internal/domain"
 	"diplom/internal/repository"
+	"diplom/internal/storage"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var (
+	ErrUnknownRole  = errors.New("unknown role")
+	ErrUserInactive = errors.New("user account is deactivated")
+	ErrUserNotFound = errors.New("user not found")
+
+	ErrInvalidAPIKey   = errors.New("invalid, expired or revoked API key")
+	ErrAPIKeyNotFound  = errors.New("API key not found")
+	ErrAPIKeyNoScopes  = errors.New("API key needs at least one scope")
+	ErrScopeNotGranted = errors.New("scope is not granted to the key owner")
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	// APIKeyPrefix marks personal API keys so they can be told apart from access tokens.
+	APIKeyPrefix = "dk_"
+	// apiKeyTouchInterval limits how often last_used_at is written for a busy key.
+	apiKeyTouchInterval = time.Minute
 )
 
 type UserService struct {
-	repo repository.UserRepositoryInterface
+	repo           repository.UserRepositoryInterface
+	passwordPolicy PasswordPolicy
+	audio          *storage.AudioStore
+	now            func() time.Time
+}
+
+func NewUserService(repo repository.UserRepositoryInterface, options ...func(*UserService)) *UserService {
+	u := &UserService{repo: repo, passwordPolicy: PasswordPolicy{MinLength: 8}, now: time.Now}
+	for _, o := range options {
+		o(u)
+	}
+	return u
+}
+
+func WithPasswordPolicy(policy PasswordPolicy) func(*UserService) {
+	return func(u *UserService) {
+		u.passwordPolicy = policy
+	}
+}
+
+// WithUserAudio sets the directory of the recordings that are exported and removed with the user. Files
+// outside of it are left alone.
+func WithUserAudio(store *storage.AudioStore) func(*UserService) {
+	return func(u *UserService) {
+		u.audio = store
+	}
+}
+
+// Register checks the password against the policy, hashes it and creates a student account in the organization.
+func (u *UserService) Register(orgID uuid.UUID, name string, login string, password string) (uuid.UUID, error) {
+	return u.createAccount(orgID, name, login, password, domain.RoleStudent)
 }
 
-func NewUserService(repo repository.UserRepositoryInterface) *UserService {
-	return &UserService{repo: repo}
+// CreateOrganizationAdmin creates an administrator account in the organization, e.g. the first one of a new tenant.
+func (u *UserService) CreateOrganizationAdmin(orgID uuid.UUID, name string, login string, password string) (uuid.UUID, error) {
+	return u.createAccount(orgID, name, login, password, domain.RoleAdmin)
+}
+
+func (u *UserService) createAccount(orgID uuid.UUID, name string, login string, password string, role domain.Role) (uuid.UUID, error) {
+	if err := u.passwordPolicy.Validate(password); err != nil {
+		return uuid.Nil, err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return u.CreateUser(&domain.User{
+		OrganizationID: orgID,
+		Name:           name,
+		Login:          login,
+		Password:       string(hashedPassword),
+		Role:           role,
+	})
 }
 
 func (u *UserService) CreateUser(user *domain.User) (uuid.UUID, error) {
+	if user.Role == "" {
+		user.Role = domain.RoleStudent
+	}
 	return u.repo.Create(user)
 }
 
 func (u *UserService) GetUserByID(id uuid.UUID) (*domain.User, error) {
 	return u.repo.GetByID(id)
 }
+
+// GetOrganizationUser returns the user only if they belong to the organization.
+func (u *UserService) GetOrganizationUser(orgID uuid.UUID, id uuid.UUID) (*domain.User, error) {
+	user, err := u.repo.GetByID(id)
+	if err != nil || user.OrganizationID != orgID {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
 func (u *UserService) IsAdmin(userID uuid.UUID) bool {
 	user, err := u.GetUserByID(userID)
 	if err != nil {
 		return false
 	}
 
-	return user.Role
+	return user.Role == domain.RoleAdmin || user.Role == domain.RoleSuperAdmin
+}
+
+func (u *UserService) SetRole(userID uuid.UUID, role domain.Role) error {
+	if !role.Valid() {
+		return ErrUnknownRole
+	}
+	user, err := u.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	user.Role = role
+	return u.repo.Update(user)
 }
 
 func (u *UserService) Login(login string, password string) (*domain.User, error) {
@@ -37,5 +143,255 @@ func (u *UserService) Login(login string, password string) (*domain.User, error)
 		return nil, err
 	}
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
-	return user, err
+	if err != nil {
+		return user, err
+	}
+	if !user.Active() {
+		return user, ErrUserInactive
+	}
+	return user, nil
+}
+
+// ListUsers returns the requested page of users of the organization and the total number of matches. Pages start at 1.
+func (u *UserService) ListUsers(orgID uuid.UUID, search string, role domain.Role, page int, pageSize int) (*domain.UserPage, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	users, total, err := u.repo.List(domain.UserFilter{
+		OrganizationID: orgID,
+		Search:         search,
+		Role:           role,
+		Limit:          pageSize,
+		Offset:         (page - 1) * pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserPage{Users: users, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+func (u *UserService) UpdateProfile(userID uuid.UUID, name string, login string) (*domain.User, error) {
+	user, err := u.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		user.Name = name
+	}
+	if login != "" {
+		user.Login = login
+	}
+	return user, u.repo.Update(user)
+}
+
+func (u *UserService) Deactivate(userID uuid.UUID) error {
+	now := u.now()
+	return u.repo.SetDeactivatedAt(userID, &now)
+}
+
+func (u *UserService) Reactivate(userID uuid.UUID) error {
+	return u.repo.SetDeactivatedAt(userID, nil)
+}
+
+// DeleteUser removes the user with all their scenarios, answers, recognition jobs and audio, including the
+// audio files on disk.
+func (u *UserService) DeleteUser(userID uuid.UUID) error {
+	paths, err := u.repo.DeleteWithContent(userID)
+	if err != nil {
+		return err
+	}
+	return removeFiles(u.audio, paths)
+}
+
+// ExportPersonalData writes a ZIP archive with the profile of the user, their scenarios, phrase streams,
+// recognized answers and the recordings of the answers, including the ones that are not recognized yet.
+func (u *UserService) ExportPersonalData(userID uuid.UUID, w io.Writer) error {
+	data, err := u.repo.GetPersonalData(userID)
+	if err != nil {
+		return err
+	}
+	archive := zip.NewWriter(w)
+	profile := struct {
+		ID             uuid.UUID   `json:"id"`
+		OrganizationID uuid.UUID   `json:"organization_id"`
+		Name           string      `json:"name"`
+		Login          string      `json:"login"`
+		Role           domain.Role `json:"role"`
+		DeactivatedAt  *time.Time  `json:"deactivated_at,omitempty"`
+	}{data.User.ID, data.User.OrganizationID, data.User.Name, data.User.Login, data.User.Role, data.User.DeactivatedAt}
+
+	type recording struct {
+		ID         uuid.UUID `json:"id"`
+		RecordTime time.Time `json:"record_time"`
+		// File is the name of the recording in the archive, empty if it is no longer on disk.
+		File string `json:"file"`
+	}
+	recordings := make([]recording, 0, len(data.AudioAnswers))
+	for _, audio := range data.AudioAnswers {
+		name := "audio/" + audio.ID.String() + filepath.Ext(audio.PathToAudio)
+		err := addFile(archive, u.audio, name, audio.PathToAudio)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, storage.ErrOutsideRoot) {
+			name = ""
+		} else if err != nil {
+			return err
+		}
+		recordings = append(recordings, recording{ID: audio.ID, RecordTime: audio.RecordTime, File: name})
+	}
+	type pendingRecording struct {
+		recording
+		PhraseStreamID uuid.UUID `json:"phrase_stream_id"`
+		Status         string    `json:"status"`
+	}
+	pending := make([]pendingRecording, 0, len(data.RecognitionJobs))
+	for _, job := range data.RecognitionJobs {
+		name := "audio/jobs/" + job.ID.String() + filepath.Ext(job.PathToAudio)
+		err := addFile(archive, u.audio, name, job.PathToAudio)
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, storage.ErrOutsideRoot) {
+			name = ""
+		} else if err != nil {
+			return err
+		}
+		pending = append(pending, pendingRecording{recording{ID: job.ID, RecordTime: job.RecordTime, File: name},
+			job.PhraseStreamID, job.Status})
+	}
+
+	files := []struct {
+		name  string
+		value interface{}
+	}{
+		{"profile.json", profile},
+		{"scenarios.json", data.Scenarios},
+		{"phrase_streams.json", data.PhraseStreams},
+		{"answers.json", data.Answers},
+		{"audio_answers.json", recordings},
+		{"recognition_jobs.json", pending},
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.value); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func addFile(archive *zip.Writer, store *storage.AudioStore, name string, path string) error {
+	f, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// removeFiles removes the audio files of deleted records. A path outside of the store was never written
+// by the server and is only logged.
+func removeFiles(store *storage.AudioStore, paths []string) error {
+	var errs []error
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		err := store.Remove(path)
+		if errors.Is(err, storage.ErrOutsideRoot) {
+			log.Printf("not removing audio file: %v", err)
+			continue
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CreateAPIKey issues a key for the owner. The raw key is returned once and only its hash is stored.
+func (u *UserService) CreateAPIKey(owner *domain.User, name string, scopes []domain.Permission, expiresAt *time.Time) (*domain.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrAPIKeyNoScopes
+	}
+	for _, scope := range scopes {
+		if !owner.HasPermission(scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+		}
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	rawKey := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	key := &domain.APIKey{
+		ID:        uuid.New(),
+		UserID:    owner.ID,
+		Name:      name,
+		Prefix:    rawKey[:len(APIKeyPrefix)+8],
+		KeyHash:   hashToken(rawKey),
+		Scopes:    scopes,
+		CreatedAt: u.now(),
+		ExpiresAt: expiresAt,
+	}
+	if err := u.repo.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+func (u *UserService) ListAPIKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	return u.repo.GetAPIKeys(userID)
+}
+
+func (u *UserService) RevokeAPIKey(userID uuid.UUID, keyID uuid.UUID) error {
+	found, err := u.repo.RevokeAPIKey(userID, keyID, u.now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AuthenticateAPIKey resolves the owner of a key. The returned user only holds the permissions
+// that are both in the key's scopes and granted by the owner's current role.
+func (u *UserService) AuthenticateAPIKey(rawKey string) (*domain.User, error) {
+	key, err := u.repo.GetAPIKeyByHash(hashToken(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := u.now()
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	user, err := u.repo.GetByID(key.UserID)
+	if err != nil || !user.Active() {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := u.repo.TouchAPIKey(key.ID, now); err != nil {
+			return nil, err
+		}
+	}
+	var permissions []domain.Permission
+	for _, scope := range key.Scopes {
+		if user.HasPermission(scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+	user.Permissions = permissions
+	return user, nil
 }
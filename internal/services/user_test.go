@@ -1,12 +1,23 @@
 package services
 
 import (
+	"archive/zip"
+	"bytes"
 	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"diplom/internal/storage"
+	"errors"
 	"github.com/google/uuid"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockUserRepository struct {
@@ -37,11 +48,76 @@ func (m *MockUserRepository) Delete(id uuid.UUID) error {
 	return nil
 }
 
+func (m *MockUserRepository) List(filter domain.UserFilter) ([]domain.User, int, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]domain.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) SetDeactivatedAt(id uuid.UUID, deactivatedAt *time.Time) error {
+	args := m.Called(id, deactivatedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteWithContent(id uuid.UUID) ([]string, error) {
+	args := m.Called(id)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) GetPersonalData(id uuid.UUID) (*domain.PersonalData, error) {
+	args := m.Called(id)
+	return args.Get(0).(*domain.PersonalData), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(id uuid.UUID, password string) error {
+	args := m.Called(id, password)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CreateAPIKey(key *domain.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetAPIKeyByHash(keyHash string) (*domain.APIKey, error) {
+	args := m.Called(keyHash)
+	return args.Get(0).(*domain.APIKey), args.Error(1)
+}
+
+func (m *MockUserRepository) GetAPIKeys(userID uuid.UUID) ([]domain.APIKey, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]domain.APIKey), args.Error(1)
+}
+
+func (m *MockUserRepository) RevokeAPIKey(userID uuid.UUID, id uuid.UUID, revokedAt time.Time) (bool, error) {
+	args := m.Called(userID, id, revokedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) TouchAPIKey(id uuid.UUID, usedAt time.Time) error {
+	args := m.Called(id, usedAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetByIdentity(issuer string, subject string) (*domain.User, error) {
+	args := m.Called(issuer, subject)
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) CreateWithIdentity(user *domain.User, issuer string, subject string) (uuid.UUID, error) {
+	args := m.Called(user, issuer, subject)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockUserRepository) LinkIdentity(userID uuid.UUID, issuer string, subject string) error {
+	args := m.Called(userID, issuer, subject)
+	return args.Error(0)
+}
+
 func TestRegisterUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	userService := NewUserService(mockRepo)
 	t.Run("success register user", func(t *testing.T) {
-		newUser := &domain.User{Name: "John Doe", Login: "john@example.com", Password: "password123", Role: false}
+		newUser := &domain.User{Name: "John Doe", Login: "john@example.com", Password: "password123", Role: domain.RoleStudent}
 		id, _ := uuid.Parse("5d6629cb-ea31-42c6-8214-1732ab8619ea")
 
 		mockRepo.On("Create", newUser).Return(id, nil)
@@ -54,6 +130,59 @@ func TestRegisterUser(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("weak password is rejected", func(t *testing.T) {
+		service := NewUserService(mockRepo, WithPasswordPolicy(PasswordPolicy{MinLength: 10, RequireDigit: true}))
+
+		_, err := service.Register(domain.DefaultOrganizationID, "John Doe", "weak@example.com", "password")
+
+		var policyErr *PasswordPolicyError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Len(t, policyErr.Violations, 2)
+		mockRepo.AssertNotCalled(t, "Create", mock.MatchedBy(func(u *domain.User) bool { return u.Login == "weak@example.com" }))
+	})
+
+	t.Run("duplicate login", func(t *testing.T) {
+		mockRepo.On("Create", mock.MatchedBy(func(u *domain.User) bool { return u.Login == "taken@example.com" })).
+			Return(uuid.Nil, repository.ErrLoginTaken)
+
+		_, err := userService.Register(domain.DefaultOrganizationID, "John Doe", "taken@example.com", "password123")
+
+		assert.ErrorIs(t, err, repository.ErrLoginTaken)
+	})
+
+	t.Run("account is created in the organization", func(t *testing.T) {
+		orgID := uuid.New()
+		mockRepo.On("Create", mock.MatchedBy(func(u *domain.User) bool { return u.Login == "school@example.com" })).
+			Return(uuid.New(), nil)
+
+		_, err := userService.Register(orgID, "John Doe", "school@example.com", "password123")
+
+		assert.NoError(t, err)
+		mockRepo.AssertCalled(t, "Create", mock.MatchedBy(func(u *domain.User) bool {
+			return u.Login == "school@example.com" && u.OrganizationID == orgID && u.Role == domain.RoleStudent
+		}))
+	})
+}
+
+func TestGetOrganizationUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	orgID := uuid.New()
+	user := &domain.User{ID: uuid.New(), Name: "John Doe", OrganizationID: orgID}
+	mockRepo.On("GetByID", user.ID).Return(user, nil)
+
+	t.Run("user of the organization", func(t *testing.T) {
+		result, err := userService.GetOrganizationUser(orgID, user.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, user, result)
+	})
+
+	t.Run("user of another organization is hidden", func(t *testing.T) {
+		_, err := userService.GetOrganizationUser(uuid.New(), user.ID)
+
+		assert.ErrorIs(t, err, ErrUserNotFound)
+	})
 }
 
 func TestLoginUser(t *testing.T) {
@@ -63,7 +192,7 @@ func TestLoginUser(t *testing.T) {
 	t.Run("success login user", func(t *testing.T) {
 		login := "john@example.com"
 		password := "1234"
-		expectedUser := &domain.User{ID: uuid.New(), Name: "John Doe", Login: login, Password: "$2a$10$q6O9J2b4BtFY224tmjC6.eAF6Keqz39/5Uu9aKtyKOpnNFLeeuCoC", Role: false}
+		expectedUser := &domain.User{ID: uuid.New(), Name: "John Doe", Login: login, Password: "$2a$10$q6O9J2b4BtFY224tmjC6.eAF6Keqz39/5Uu9aKtyKOpnNFLeeuCoC", Role: domain.RoleStudent}
 
 		mockRepo.On("Login", login).Return(expectedUser, nil)
 
@@ -80,7 +209,7 @@ func TestLoginUser(t *testing.T) {
 	t.Run("fail login user not found error", func(t *testing.T) {
 		login := "john@example.com"
 		password := "12345"
-		expectedUser := &domain.User{ID: uuid.New(), Name: "John Doe", Login: login, Password: "$2a$10$q6O9J2b4BtFY224tmjC6.eAF6Keqz39/5Uu9aKtyKOpnNFLeeuCoC", Role: false}
+		expectedUser := &domain.User{ID: uuid.New(), Name: "John Doe", Login: login, Password: "$2a$10$q6O9J2b4BtFY224tmjC6.eAF6Keqz39/5Uu9aKtyKOpnNFLeeuCoC", Role: domain.RoleStudent}
 
 		mockRepo.On("Login", login).Return(expectedUser, nil)
 
@@ -92,11 +221,39 @@ func TestLoginUser(t *testing.T) {
 	})
 }
 
+func TestLoginDeactivatedUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	deactivatedAt := time.Now()
+	login := "john@example.com"
+	user := &domain.User{ID: uuid.New(), Login: login, Password: "$2a$10$q6O9J2b4BtFY224tmjC6.eAF6Keqz39/5Uu9aKtyKOpnNFLeeuCoC", DeactivatedAt: &deactivatedAt}
+
+	mockRepo.On("Login", login).Return(user, nil)
+
+	_, err := userService.Login(login, "1234")
+
+	assert.ErrorIs(t, err, ErrUserInactive)
+}
+
+func TestDeactivateUser(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	userService.now = func() time.Time { return now }
+	userID := uuid.New()
+	mockRepo.On("SetDeactivatedAt", userID, &now).Return(nil)
+
+	err := userService.Deactivate(userID)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUserIsAdmin(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	userService := NewUserService(mockRepo)
 	t.Run("user is admin", func(t *testing.T) {
-		newUser := &domain.User{Name: "John Doe", Login: "john@example.com", Password: "password123", Role: true}
+		newUser := &domain.User{Name: "John Doe", Login: "john@example.com", Password: "password123", Role: domain.RoleAdmin}
 		id, _ := uuid.Parse("5d6629cb-ea31-42c6-8214-1732ab8619ea")
 
 		mockRepo.On("GetByID", id).Return(newUser, nil)
@@ -109,3 +266,266 @@ func TestUserIsAdmin(t *testing.T) {
 	})
 
 }
+
+func TestSetRole(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+
+	t.Run("assign teacher role", func(t *testing.T) {
+		id := uuid.New()
+		user := &domain.User{ID: id, Name: "John Doe", Login: "john@example.com", Role: domain.RoleStudent}
+
+		mockRepo.On("GetByID", id).Return(user, nil)
+
+		err := userService.SetRole(id, domain.RoleTeacher)
+
+		assert.NoError(t, err)
+		assert.Equal(t, domain.RoleTeacher, user.Role)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		err := userService.SetRole(uuid.New(), domain.Role("pilot"))
+
+		assert.ErrorIs(t, err, ErrUnknownRole)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		id := uuid.New()
+
+		mockRepo.On("GetByID", id).Return((*domain.User)(nil), errors.New("not found"))
+
+		err := userService.SetRole(id, domain.RoleEditor)
+
+		assert.Error(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestListUsers(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	orgID := uuid.New()
+
+	t.Run("page and size are normalized", func(t *testing.T) {
+		users := []domain.User{{ID: uuid.New(), Name: "John Doe"}}
+		mockRepo.On("List", domain.UserFilter{OrganizationID: orgID, Search: "john", Limit: maxPageSize, Offset: 0}).Return(users, 1, nil)
+
+		page, err := userService.ListUsers(orgID, "john", "", 0, 1000)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, page.Page)
+		assert.Equal(t, maxPageSize, page.PageSize)
+		assert.Equal(t, 1, page.Total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("offset follows the page", func(t *testing.T) {
+		mockRepo.On("List", domain.UserFilter{OrganizationID: orgID, Role: domain.RoleStudent, Limit: 10, Offset: 20}).Return([]domain.User{}, 25, nil)
+
+		page, err := userService.ListUsers(orgID, "", domain.RoleStudent, 3, 10)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, page.Page)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	dir := t.TempDir()
+	store, err := storage.NewAudioStore(dir)
+	require.NoError(t, err)
+	userService := NewUserService(mockRepo, WithUserAudio(store))
+
+	t.Run("audio files are removed", func(t *testing.T) {
+		id := uuid.New()
+		answerAudio := filepath.Join(dir, "answer.wav")
+		assert.NoError(t, os.WriteFile(answerAudio, []byte("data"), 0644))
+		missing := filepath.Join(dir, "already-removed.wav")
+
+		mockRepo.On("DeleteWithContent", id).Return([]string{answerAudio, missing}, nil)
+
+		err := userService.DeleteUser(id)
+
+		assert.NoError(t, err)
+		assert.NoFileExists(t, answerAudio)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("files outside the audio directory are kept", func(t *testing.T) {
+		id := uuid.New()
+		config := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(config, []byte("secret"), 0644))
+
+		mockRepo.On("DeleteWithContent", id).Return([]string{config, filepath.Join(dir, "..", filepath.Base(config))}, nil)
+
+		err := userService.DeleteUser(id)
+
+		assert.NoError(t, err)
+		assert.FileExists(t, config)
+	})
+
+	t.Run("repository error keeps files", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("DeleteWithContent", id).Return([]string(nil), errors.New("delete error"))
+
+		err := userService.DeleteUser(id)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestExportPersonalData(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	dir := t.TempDir()
+	store, err := storage.NewAudioStore(dir)
+	require.NoError(t, err)
+	userService := NewUserService(mockRepo, WithUserAudio(store))
+
+	t.Run("archive contains data and recordings", func(t *testing.T) {
+		id := uuid.New()
+		recorded := filepath.Join(dir, "answer.wav")
+		assert.NoError(t, os.WriteFile(recorded, []byte("RIFF"), 0644))
+		audio := domain.AudioAnswer{ID: uuid.New(), PathToAudio: recorded}
+		lost := domain.AudioAnswer{ID: uuid.New(), PathToAudio: filepath.Join(dir, "lost.wav")}
+		config := filepath.Join(t.TempDir(), "config.yaml")
+		assert.NoError(t, os.WriteFile(config, []byte("secret"), 0644))
+		outside := domain.AudioAnswer{ID: uuid.New(), PathToAudio: config}
+		uploaded := filepath.Join(dir, "pending.ogg")
+		assert.NoError(t, os.WriteFile(uploaded, []byte("OggS"), 0644))
+		job := domain.RecognitionJob{ID: uuid.New(), PathToAudio: uploaded, Status: domain.RecognitionJobFailed}
+
+		mockRepo.On("GetPersonalData", id).Return(&domain.PersonalData{
+			User:            &domain.User{ID: id, Login: "student", Password: "$2a$10$hash"},
+			Scenarios:       []domain.Scenario{{ID: uuid.New(), UserID: id}},
+			PhraseStreams:   []domain.PhraseStream{},
+			Answers:         []domain.Answer{{ID: uuid.New(), UserID: id, AudioAnswerID: audio.ID, Text: "hello"}},
+			AudioAnswers:    []domain.AudioAnswer{audio, lost, outside},
+			RecognitionJobs: []domain.RecognitionJob{job},
+		}, nil)
+
+		var buf bytes.Buffer
+		err := userService.ExportPersonalData(id, &buf)
+
+		require.NoError(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		files := map[string]string{}
+		for _, f := range archive.File {
+			r, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(r)
+			require.NoError(t, err)
+			files[f.Name] = string(content)
+		}
+		assert.Len(t, files, 8)
+		assert.Equal(t, "RIFF", files["audio/"+audio.ID.String()+".wav"])
+		assert.Equal(t, "OggS", files["audio/jobs/"+job.ID.String()+".ogg"])
+		assert.Contains(t, files["recognition_jobs.json"], `"status": "failed"`)
+		assert.Contains(t, files["profile.json"], `"login": "student"`)
+		assert.NotContains(t, files["profile.json"], "hash")
+		assert.Contains(t, files["answers.json"], "hello")
+		assert.Contains(t, files["audio_answers.json"], `"file": ""`)
+		assert.NotContains(t, files, "audio/"+outside.ID.String()+".yaml")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("GetPersonalData", id).Return((*domain.PersonalData)(nil), errors.New("query error"))
+
+		err := userService.ExportPersonalData(id, io.Discard)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	owner := &domain.User{ID: uuid.New(), Role: domain.RoleEditor,
+		Permissions: []domain.Permission{domain.PermissionPhrasesRead, domain.PermissionPhrasesWrite}}
+
+	t.Run("key is stored hashed", func(t *testing.T) {
+		var stored *domain.APIKey
+		mockRepo.On("CreateAPIKey", mock.AnythingOfType("*domain.APIKey")).
+			Run(func(args mock.Arguments) { stored = args.Get(0).(*domain.APIKey) }).Return(nil).Once()
+
+		key, rawKey, err := userService.CreateAPIKey(owner, "lms sync", []domain.Permission{domain.PermissionPhrasesRead}, nil)
+
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(rawKey, APIKeyPrefix))
+		assert.True(t, strings.HasPrefix(rawKey, key.Prefix))
+		assert.Equal(t, hashToken(rawKey), stored.KeyHash)
+		assert.NotContains(t, stored.KeyHash, rawKey)
+	})
+
+	t.Run("scope outside the owner's role", func(t *testing.T) {
+		_, _, err := userService.CreateAPIKey(owner, "too much", []domain.Permission{domain.PermissionUsersManage}, nil)
+
+		assert.ErrorIs(t, err, ErrScopeNotGranted)
+	})
+
+	t.Run("no scopes", func(t *testing.T) {
+		_, _, err := userService.CreateAPIKey(owner, "empty", nil, nil)
+
+		assert.ErrorIs(t, err, ErrAPIKeyNoScopes)
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo)
+	userService.now = func() time.Time { return now }
+	owner := &domain.User{ID: uuid.New(), Role: domain.RoleStudent,
+		Permissions: []domain.Permission{domain.PermissionScenariosPractice}}
+
+	t.Run("permissions are limited to the key scopes", func(t *testing.T) {
+		user := *owner
+		key := &domain.APIKey{ID: uuid.New(), UserID: owner.ID,
+			Scopes: []domain.Permission{domain.PermissionScenariosPractice, domain.PermissionPhrasesWrite}}
+		mockRepo.On("GetAPIKeyByHash", hashToken("dk_valid")).Return(key, nil)
+		mockRepo.On("GetByID", owner.ID).Return(&user, nil).Once()
+		mockRepo.On("TouchAPIKey", key.ID, now).Return(nil).Once()
+
+		result, err := userService.AuthenticateAPIKey("dk_valid")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []domain.Permission{domain.PermissionScenariosPractice}, result.Permissions)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("recently used key is not touched again", func(t *testing.T) {
+		user := *owner
+		lastUsed := now.Add(-10 * time.Second)
+		key := &domain.APIKey{ID: uuid.New(), UserID: owner.ID, LastUsedAt: &lastUsed,
+			Scopes: []domain.Permission{domain.PermissionScenariosPractice}}
+		mockRepo.On("GetAPIKeyByHash", hashToken("dk_recent")).Return(key, nil)
+		mockRepo.On("GetByID", owner.ID).Return(&user, nil).Once()
+
+		_, err := userService.AuthenticateAPIKey("dk_recent")
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "TouchAPIKey", key.ID, now)
+	})
+
+	t.Run("revoked key", func(t *testing.T) {
+		revokedAt := now.Add(-time.Hour)
+		key := &domain.APIKey{ID: uuid.New(), UserID: owner.ID, RevokedAt: &revokedAt}
+		mockRepo.On("GetAPIKeyByHash", hashToken("dk_revoked")).Return(key, nil)
+
+		_, err := userService.AuthenticateAPIKey("dk_revoked")
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		mockRepo.On("GetAPIKeyByHash", hashToken("dk_unknown")).Return((*domain.APIKey)(nil), errors.New("no rows"))
+
+		_, err := userService.AuthenticateAPIKey("dk_unknown")
+
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	})
+}
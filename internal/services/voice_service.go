@@ -0,0 +1,73 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"diplom/internal/repository"
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+)
+
+var (
+	ErrVoiceNotFound = errors.New("voice not found")
+	ErrInvalidVoice  = errors.New("accent, voice and language are required and speed must be between 0.1 and 3")
+)
+
+const (
+	minVoiceSpeed = 0.1
+	maxVoiceSpeed = 3
+)
+
+// VoiceService manages the voice catalog phrase accents are resolved through.
+type VoiceService struct {
+	repo repository.VoiceRepositoryInterface
+}
+
+func NewVoiceService(repo repository.VoiceRepositoryInterface) *VoiceService {
+	return &VoiceService{repo: repo}
+}
+
+func (s *VoiceService) CreateVoice(voice *domain.Voice) (uuid.UUID, error) {
+	if err := normalizeVoice(voice); err != nil {
+		return uuid.Nil, err
+	}
+	return s.repo.Create(voice)
+}
+
+func (s *VoiceService) GetVoice(orgID uuid.UUID, id uuid.UUID) (*domain.Voice, error) {
+	voice, err := s.repo.GetByID(orgID, id)
+	if err != nil {
+		return nil, ErrVoiceNotFound
+	}
+	return voice, nil
+}
+
+func (s *VoiceService) UpdateVoice(voice *domain.Voice) error {
+	if err := normalizeVoice(voice); err != nil {
+		return err
+	}
+	return s.repo.Update(voice)
+}
+
+func (s *VoiceService) DeleteVoice(orgID uuid.UUID, id uuid.UUID) error {
+	return s.repo.Delete(orgID, id)
+}
+
+// ListVoices returns the voice catalog of the organization ordered by accent.
+func (s *VoiceService) ListVoices(orgID uuid.UUID) ([]domain.Voice, error) {
+	return s.repo.GetAll(orgID)
+}
+
+func normalizeVoice(voice *domain.Voice) error {
+	voice.Accent = strings.ToLower(strings.TrimSpace(voice.Accent))
+	voice.Voice = strings.TrimSpace(voice.Voice)
+	voice.Language = strings.TrimSpace(voice.Language)
+	voice.Emotion = strings.TrimSpace(voice.Emotion)
+	if voice.Speed == 0 {
+		voice.Speed = 1
+	}
+	if voice.Accent == "" || voice.Voice == "" || voice.Language == "" || voice.Speed < minVoiceSpeed || voice.Speed > maxVoiceSpeed {
+		return ErrInvalidVoice
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"diplom/internal/domain"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+type MockVoiceRepository struct {
+	mock.Mock
+}
+
+func (m *MockVoiceRepository) Create(voice *domain.Voice) (uuid.UUID, error) {
+	args := m.Called(voice)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockVoiceRepository) GetByID(orgID uuid.UUID, id uuid.UUID) (*domain.Voice, error) {
+	args := m.Called(orgID, id)
+	return args.Get(0).(*domain.Voice), args.Error(1)
+}
+
+func (m *MockVoiceRepository) GetByAccent(orgID uuid.UUID, accent string) (*domain.Voice, error) {
+	args := m.Called(orgID, accent)
+	return args.Get(0).(*domain.Voice), args.Error(1)
+}
+
+func (m *MockVoiceRepository) Update(voice *domain.Voice) error {
+	args := m.Called(voice)
+	return args.Error(0)
+}
+
+func (m *MockVoiceRepository) Delete(orgID uuid.UUID, id uuid.UUID) error {
+	args := m.Called(orgID, id)
+	return args.Error(0)
+}
+
+func (m *MockVoiceRepository) GetAll(orgID uuid.UUID) ([]domain.Voice, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]domain.Voice), args.Error(1)
+}
+
+func TestCreateVoice(t *testing.T) {
+	mockRepo := new(MockVoiceRepository)
+	service := NewVoiceService(mockRepo)
+
+	t.Run("accent is normalized and speed defaults to normal", func(t *testing.T) {
+		id := uuid.New()
+		voice := &domain.Voice{Accent: " British ", Voice: "jane", Language: "en-GB"}
+		mockRepo.On("Create", voice).Return(id, nil)
+
+		created, err := service.CreateVoice(voice)
+
+		assert.NoError(t, err)
+		assert.Equal(t, id, created)
+		assert.Equal(t, "british", voice.Accent)
+		assert.Equal(t, 1.0, voice.Speed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("invalid voices are rejected", func(t *testing.T) {
+		for _, voice := range []*domain.Voice{
+			{Accent: " ", Voice: "jane", Language: "en-GB"},
+			{Accent: "british", Language: "en-GB"},
+			{Accent: "british", Voice: "jane"},
+			{Accent: "british", Voice: "jane", Language: "en-GB", Speed: 5},
+		} {
+			_, err := service.CreateVoice(voice)
+
+			assert.ErrorIs(t, err, ErrInvalidVoice)
+		}
+		mockRepo.AssertNumberOfCalls(t, "Create", 1)
+	})
+}
+
+func TestGetVoice(t *testing.T) {
+	mockRepo := new(MockVoiceRepository)
+	service := NewVoiceService(mockRepo)
+	orgID := uuid.New()
+
+	t.Run("voice of another organization is not found", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("GetByID", orgID, id).Return((*domain.Voice)(nil), errors.New("no rows"))
+
+		_, err := service.GetVoice(orgID, id)
+
+		assert.ErrorIs(t, err, ErrVoiceNotFound)
+	})
+}
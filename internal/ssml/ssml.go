@@ -0,0 +1,232 @@
+// Package ssml validates the speech markup of phrases and turns it into SSML for the speech provider.
+//
+// A phrase is authored either as an SSML document wrapped in <speak> or in a simple markup:
+//
+//	[pause 500ms]          a pause, in ms or s, up to 5s
+//	*runway two seven*     emphasis
+//	[rate 0.8]...[/rate]   speech rate relative to normal, from 0.25 to 4
+//
+// The rest is spoken as is. Grading compares answers with the plain text of the phrase, the words
+// without any markup.
+package ssml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var ErrInvalidMarkup = errors.New("invalid phrase markup")
+
+const (
+	maxPause = 5 * time.Second
+	minRate  = 0.25
+	maxRate  = 4
+)
+
+// Speech is a validated phrase markup.
+type Speech struct {
+	ssml  string
+	plain string
+}
+
+// SSML returns the markup as an SSML document.
+func (s *Speech) SSML() string {
+	return s.ssml
+}
+
+// PlainText returns the words of the phrase without markup.
+func (s *Speech) PlainText() string {
+	return s.plain
+}
+
+// IsSSML reports whether text is an SSML document rather than plain text.
+func IsSSML(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "<speak")
+}
+
+// Parse validates markup written either as SSML or in the simple markup.
+func Parse(markup string) (*Speech, error) {
+	markup = strings.TrimSpace(markup)
+	if markup == "" {
+		return nil, fmt.Errorf("%w: markup is empty", ErrInvalidMarkup)
+	}
+	if IsSSML(markup) {
+		return parseSSML(markup)
+	}
+	document, err := convert(markup)
+	if err != nil {
+		return nil, err
+	}
+	return parseSSML(document)
+}
+
+var (
+	tokenPattern = regexp.MustCompile(`\[pause ([^\]]*)\]|\[rate ([^\]]*)\]|\[/rate\]|\*|[^\[*]+|\[`)
+)
+
+// convert turns the simple markup into SSML.
+func convert(markup string) (string, error) {
+	var b strings.Builder
+	b.WriteString("<speak>")
+	emphasis := false
+	rates := 0
+	for _, loc := range tokenPattern.FindAllStringSubmatchIndex(markup, -1) {
+		token := make([]string, len(loc)/2)
+		for i := range token {
+			if loc[2*i] >= 0 {
+				token[i] = markup[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		switch {
+		case strings.HasPrefix(token[0], "[pause "):
+			pause, err := parsePause(token[1])
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&b, `<break time="%dms"/>`, pause.Milliseconds())
+		case strings.HasPrefix(token[0], "[rate "):
+			rate, err := strconv.ParseFloat(strings.TrimSpace(token[2]), 64)
+			if err != nil || rate < minRate || rate > maxRate {
+				return "", fmt.Errorf("%w: rate %q must be a number from %v to %v", ErrInvalidMarkup, token[2], minRate, maxRate)
+			}
+			fmt.Fprintf(&b, `<prosody rate="%d%%">`, int(rate*100+0.5))
+			rates++
+		case token[0] == "[/rate]":
+			if rates == 0 {
+				return "", fmt.Errorf("%w: [/rate] without [rate]", ErrInvalidMarkup)
+			}
+			b.WriteString("</prosody>")
+			rates--
+		case token[0] == "*":
+			if emphasis {
+				b.WriteString("</emphasis>")
+			} else {
+				b.WriteString(`<emphasis level="strong">`)
+			}
+			emphasis = !emphasis
+		case token[0] == "[":
+			return "", fmt.Errorf("%w: unknown tag near %q", ErrInvalidMarkup, excerpt(markup, loc[0]))
+		default:
+			xml.EscapeText(&b, []byte(token[0]))
+		}
+	}
+	if emphasis {
+		return "", fmt.Errorf("%w: unclosed *", ErrInvalidMarkup)
+	}
+	if rates > 0 {
+		return "", fmt.Errorf("%w: unclosed [rate]", ErrInvalidMarkup)
+	}
+	b.WriteString("</speak>")
+	return b.String(), nil
+}
+
+// parseSSML checks that the document only uses the elements the providers support and collects its text.
+func parseSSML(document string) (*Speech, error) {
+	decoder := xml.NewDecoder(strings.NewReader(document))
+	var words strings.Builder
+	var open []string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMarkup, err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(open) == 0 && t.Name.Local != "speak" {
+				return nil, fmt.Errorf("%w: the document must be wrapped in <speak>", ErrInvalidMarkup)
+			}
+			if err := checkElement(t); err != nil {
+				return nil, err
+			}
+			open = append(open, t.Name.Local)
+			if t.Name.Local == "p" || t.Name.Local == "s" {
+				words.WriteString(" ")
+			}
+		case xml.EndElement:
+			open = open[:len(open)-1]
+		case xml.CharData:
+			if len(open) == 0 {
+				if strings.TrimSpace(string(t)) != "" {
+					return nil, fmt.Errorf("%w: text outside of <speak>", ErrInvalidMarkup)
+				}
+				continue
+			}
+			words.Write(t)
+		}
+	}
+	plain := strings.Join(strings.Fields(words.String()), " ")
+	if plain == "" {
+		return nil, fmt.Errorf("%w: the phrase has no words", ErrInvalidMarkup)
+	}
+	return &Speech{ssml: document, plain: plain}, nil
+}
+
+func checkElement(element xml.StartElement) error {
+	attribute := func(name string) string {
+		for _, a := range element.Attr {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	switch element.Name.Local {
+	case "speak", "p", "s":
+		return nil
+	case "break":
+		if time := attribute("time"); time != "" {
+			_, err := parsePause(time)
+			return err
+		}
+		return nil
+	case "emphasis":
+		switch attribute("level") {
+		case "", "strong", "moderate", "reduced", "none":
+			return nil
+		}
+		return fmt.Errorf("%w: unknown emphasis level %q", ErrInvalidMarkup, attribute("level"))
+	case "prosody":
+		rate := attribute("rate")
+		switch rate {
+		case "", "x-slow", "slow", "medium", "fast", "x-fast", "default":
+			return nil
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(rate, "%"), 64)
+		if err != nil || !strings.HasSuffix(rate, "%") || percent < minRate*100 || percent > maxRate*100 {
+			return fmt.Errorf("%w: prosody rate %q must be a percentage from %v%% to %v%%", ErrInvalidMarkup, rate,
+				minRate*100, maxRate*100)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported element <%s>", ErrInvalidMarkup, element.Name.Local)
+}
+
+func parsePause(value string) (time.Duration, error) {
+	pause, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || pause <= 0 || pause > maxPause {
+		return 0, fmt.Errorf("%w: pause %q must be a duration up to %v, e.g. 500ms", ErrInvalidMarkup, value, maxPause)
+	}
+	return pause, nil
+}
+
+// excerpt returns up to 20 bytes of the markup from offset, without cutting a character in two.
+func excerpt(markup string, offset int) string {
+	end := offset + 20
+	if end >= len(markup) {
+		return markup[offset:]
+	}
+	for end > offset && !utf8.RuneStart(markup[end]) {
+		end--
+	}
+	return markup[offset:end]
+}
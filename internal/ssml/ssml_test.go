@@ -0,0 +1,83 @@
+package ssml
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	valid := []struct {
+		name   string
+		markup string
+		ssml   string
+		plain  string
+	}{
+		{"plain text", "Cleared for takeoff", "<speak>Cleared for takeoff</speak>", "Cleared for takeoff"},
+		{"pause and emphasis", "Hold [pause 500ms] *short* of runway",
+			`<speak>Hold <break time="500ms"/> <emphasis level="strong">short</emphasis> of runway</speak>`, "Hold short of runway"},
+		{"rate and escaped text", "[rate 0.8]Line up[/rate] & wait",
+			`<speak><prosody rate="80%">Line up</prosody> &amp; wait</speak>`, "Line up & wait"},
+		{"SSML paragraphs", "<speak><p>Roger</p><s>wilco</s></speak>", "<speak><p>Roger</p><s>wilco</s></speak>", "Roger wilco"},
+		{"SSML prosody and break", `<speak>Climb <prosody rate="150%">now</prosody><break time="1s"/></speak>`,
+			`<speak>Climb <prosody rate="150%">now</prosody><break time="1s"/></speak>`, "Climb now"},
+	}
+	for _, tc := range valid {
+		t.Run(tc.name, func(t *testing.T) {
+			speech, err := Parse(tc.markup)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.ssml, speech.SSML())
+			assert.Equal(t, tc.plain, speech.PlainText())
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		markup string
+		reason string
+	}{
+		{"empty", "  ", "markup is empty"},
+		{"pause too long", "[pause 10s] go", `pause "10s" must be a duration`},
+		{"pause without unit", "[pause soon] go", `pause "soon" must be a duration`},
+		{"rate out of range", "[rate 5]fast[/rate]", `rate "5" must be a number`},
+		{"closing rate first", "[/rate] go", "[/rate] without [rate]"},
+		{"unclosed emphasis", "*go", "unclosed *"},
+		{"unclosed rate", "[rate 1]go", "unclosed [rate]"},
+		{"unknown tag", "[oops] go", `unknown tag near "[oops] go"`},
+		{"unsupported element", `<speak><audio src="x"/>hi</speak>`, "unsupported element <audio>"},
+		{"emphasis level", `<speak><emphasis level="loud">hi</emphasis></speak>`, `unknown emphasis level "loud"`},
+		{"prosody rate", `<speak><prosody rate="500%">hi</prosody></speak>`, `prosody rate "500%"`},
+		{"SSML pause", `<speak>hi<break time="1h"/></speak>`, `pause "1h"`},
+		{"broken XML", "<speak>hi", "unexpected EOF"},
+		{"no words", `<speak><break time="1s"/></speak>`, "the phrase has no words"},
+		{"text after speak", "<speak>hi</speak> there", "text outside of <speak>"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(tc.markup)
+
+			require.ErrorIs(t, err, ErrInvalidMarkup)
+			assert.Contains(t, err.Error(), tc.reason)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	t.Run("points at the unknown tag, not the first one", func(t *testing.T) {
+		_, err := Parse("[pause 1s] Roger [oops] and a long tail of text")
+
+		require.ErrorIs(t, err, ErrInvalidMarkup)
+		assert.Contains(t, err.Error(), `near "[oops] and a long ta"`)
+	})
+
+	t.Run("does not cut a character in two", func(t *testing.T) {
+		markup := "Roger [" + strings.Repeat("é", 13)
+		offset := strings.Index(markup, "[")
+
+		assert.Equal(t, "["+strings.Repeat("é", 9), excerpt(markup, offset))
+		assert.Equal(t, "é", excerpt(markup, len(markup)-2))
+	})
+}
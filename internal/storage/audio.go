@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrOutsideRoot means a path points outside the audio directory of the server.
+var ErrOutsideRoot = errors.New("audio path is outside the audio directory")
+
+var audioExtensions = map[string]bool{".wav": true, ".mp3": true, ".ogg": true}
+
+// AudioStore keeps the recordings under one directory owned by the server. Paths stored in the database
+// come from clients, so every file is resolved against the root before it is opened or removed. A nil
+// store has no root and refuses every path.
+type AudioStore struct {
+	root string
+}
+
+// NewAudioStore creates the root directory if it doesn't exist.
+func NewAudioStore(root string) (*AudioStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return &AudioStore{root: abs}, nil
+}
+
+// Save writes the audio to a new file with a generated name in dir under the root and returns its path.
+// The extension of name is kept if it is one of the supported containers.
+func (s *AudioStore) Save(dir string, name string, audio io.Reader) (string, error) {
+	if s == nil {
+		return "", ErrOutsideRoot
+	}
+	path, err := s.NewPath(dir, filepath.Ext(name))
+	if err != nil {
+		return "", err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(f, audio); err != nil {
+		f.Close()
+		os.Remove(path)
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// NewPath returns the path of a new file with a generated name in dir under the root, for the server to
+// write itself. The directory is created; the extension is kept if it is one of the supported containers.
+func (s *AudioStore) NewPath(dir string, ext string) (string, error) {
+	if s == nil {
+		return "", ErrOutsideRoot
+	}
+	ext = strings.ToLower(ext)
+	if !audioExtensions[ext] {
+		ext = ""
+	}
+	path, err := s.Resolve(filepath.Join(dir, uuid.NewString()+ext))
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Resolve returns the absolute path of a file under the root. A relative path is taken from the root.
+// Paths that leave the root, also through a symbolic link, are rejected.
+func (s *AudioStore) Resolve(path string) (string, error) {
+	if s == nil || path == "" {
+		return "", ErrOutsideRoot
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.root, path)
+	}
+	path = filepath.Clean(path)
+	if !s.contains(path) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+	for _, p := range []string{path, filepath.Dir(path)} {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil && resolved != s.root && !s.contains(resolved) {
+			return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+		}
+	}
+	return path, nil
+}
+
+// ResolveIn resolves the path like Resolve and also requires the file to be in dir under the root.
+func (s *AudioStore) ResolveIn(dir string, path string) (string, error) {
+	path, err := s.Resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if !(&AudioStore{root: filepath.Join(s.root, dir)}).contains(path) {
+		return "", fmt.Errorf("%w: %s", ErrOutsideRoot, path)
+	}
+	return path, nil
+}
+
+// Open opens a file under the root for reading.
+func (s *AudioStore) Open(path string) (*os.File, error) {
+	path, err := s.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Remove removes a file under the root.
+func (s *AudioStore) Remove(path string) error {
+	path, err := s.Resolve(path)
+	if err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *AudioStore) contains(path string) bool {
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil {
+		return false
+	}
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1 @@
+drop table if exists diplom.answer_words;
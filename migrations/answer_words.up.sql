@@ -0,0 +1,9 @@
+CREATE TABLE if not exists diplom.answer_words (
+                         answer_id UUID NOT NULL REFERENCES diplom.answers(id) ON DELETE CASCADE,
+                         position INT NOT NULL,
+                         word TEXT NOT NULL,
+                         start_ms BIGINT NOT NULL,
+                         end_ms BIGINT NOT NULL,
+                         confidence DOUBLE PRECISION NOT NULL,
+                         PRIMARY KEY (answer_id, position)
+);
@@ -0,0 +1 @@
+drop table if exists diplom.api_keys;
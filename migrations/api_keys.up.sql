@@ -0,0 +1,14 @@
+CREATE TABLE if not exists diplom.api_keys (
+                          id UUID PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                          name TEXT NOT NULL,
+                          prefix TEXT NOT NULL,
+                          key_hash TEXT NOT NULL UNIQUE,
+                          scopes TEXT[] NOT NULL,
+                          created_at TIMESTAMP NOT NULL,
+                          last_used_at TIMESTAMP,
+                          expires_at TIMESTAMP,
+                          revoked_at TIMESTAMP
+);
+
+CREATE INDEX if not exists api_keys_user_id_idx ON diplom.api_keys (user_id);
@@ -0,0 +1 @@
+ALTER TABLE diplom.audio_answers DROP COLUMN if exists recognition_job_id;
@@ -0,0 +1,4 @@
+-- A recognition job saves at most one answer, even when two workers run it.
+ALTER TABLE diplom.audio_answers ADD COLUMN if not exists recognition_job_id UUID;
+
+CREATE UNIQUE INDEX if not exists audio_answers_recognition_job_id_key ON diplom.audio_answers (recognition_job_id);
@@ -0,0 +1,2 @@
+DELETE FROM diplom.permissions WHERE name = 'audit:read';
+drop table if exists diplom.audit_log;
@@ -0,0 +1,24 @@
+CREATE TABLE if not exists diplom.audit_log (
+                          id UUID PRIMARY KEY,
+                          actor_id UUID,
+                          actor_login TEXT NOT NULL,
+                          action TEXT NOT NULL,
+                          entity_type TEXT NOT NULL,
+                          entity_id UUID,
+                          before JSONB,
+                          after JSONB,
+                          ip TEXT,
+                          created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX if not exists audit_log_created_at_idx ON diplom.audit_log (created_at);
+CREATE INDEX if not exists audit_log_entity_idx ON diplom.audit_log (entity_type, entity_id);
+CREATE INDEX if not exists audit_log_actor_id_idx ON diplom.audit_log (actor_id);
+
+INSERT INTO diplom.permissions (name, description) VALUES
+    ('audit:read', 'View the audit log of administrative actions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission) VALUES
+    ('admin', 'audit:read')
+ON CONFLICT DO NOTHING;
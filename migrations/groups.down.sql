@@ -0,0 +1,3 @@
+DELETE FROM diplom.permissions WHERE name = 'groups:manage';
+drop table if exists diplom.group_members;
+drop table if exists diplom.groups;
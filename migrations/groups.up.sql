@@ -0,0 +1,24 @@
+CREATE TABLE if not exists diplom.groups (
+                        id UUID PRIMARY KEY,
+                        title TEXT NOT NULL,
+                        teacher_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                        created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE if not exists diplom.group_members (
+                        group_id UUID REFERENCES diplom.groups(id) ON DELETE CASCADE,
+                        user_id UUID REFERENCES diplom.users(id) ON DELETE CASCADE,
+                        enrolled_at TIMESTAMP NOT NULL,
+                        PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX if not exists groups_teacher_id_idx ON diplom.groups (teacher_id);
+
+INSERT INTO diplom.permissions (name, description) VALUES
+    ('groups:manage', 'Create groups and enroll students')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission) VALUES
+    ('teacher', 'groups:manage'),
+    ('admin', 'groups:manage')
+ON CONFLICT DO NOTHING;
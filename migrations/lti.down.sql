@@ -0,0 +1,2 @@
+drop table if exists diplom.lti_launches;
+drop table if exists diplom.lti_courses;
@@ -0,0 +1,20 @@
+CREATE TABLE if not exists diplom.lti_courses (
+                          issuer TEXT NOT NULL,
+                          context_id TEXT NOT NULL,
+                          group_id UUID NOT NULL REFERENCES diplom.groups(id) ON DELETE CASCADE,
+                          PRIMARY KEY (issuer, context_id)
+);
+
+CREATE TABLE if not exists diplom.lti_launches (
+                          user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                          issuer TEXT NOT NULL,
+                          resource_link_id TEXT NOT NULL,
+                          deployment_id TEXT NOT NULL,
+                          context_id TEXT NOT NULL,
+                          subject TEXT NOT NULL,
+                          line_item TEXT NOT NULL,
+                          launched_at TIMESTAMP NOT NULL,
+                          PRIMARY KEY (user_id, issuer, resource_link_id)
+);
+
+CREATE INDEX if not exists lti_launches_context_idx ON diplom.lti_launches (issuer, context_id);
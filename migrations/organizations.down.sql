@@ -0,0 +1,11 @@
+UPDATE diplom.users SET role = 'admin' WHERE role = 'superadmin';
+DELETE FROM diplom.roles WHERE name = 'superadmin';
+DELETE FROM diplom.permissions WHERE name = 'organizations:manage';
+ALTER TABLE diplom.audit_log DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.audio_answers DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.audio_phrases DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.scenarios DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.phrases DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.phrase_types DROP COLUMN if exists organization_id;
+ALTER TABLE diplom.users DROP COLUMN if exists organization_id;
+drop table if exists diplom.organizations;
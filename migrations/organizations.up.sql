@@ -0,0 +1,55 @@
+CREATE TABLE if not exists diplom.organizations (
+                          id UUID PRIMARY KEY,
+                          name TEXT NOT NULL,
+                          slug TEXT NOT NULL,
+                          created_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX if not exists organizations_slug_key ON diplom.organizations (slug);
+
+-- Everything created before tenants existed belongs to the default organization.
+INSERT INTO diplom.organizations (id, name, slug, created_at) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'Default', 'default', now())
+ON CONFLICT (id) DO NOTHING;
+
+ALTER TABLE diplom.users ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.users ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.phrase_types ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.phrase_types ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.phrases ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.phrases ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.scenarios ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.scenarios ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.audio_phrases ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.audio_phrases ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.audio_answers ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES diplom.organizations(id);
+ALTER TABLE diplom.audio_answers ALTER COLUMN organization_id DROP DEFAULT;
+ALTER TABLE diplom.audit_log ADD COLUMN if not exists organization_id UUID NOT NULL
+    DEFAULT '00000000-0000-0000-0000-000000000001';
+ALTER TABLE diplom.audit_log ALTER COLUMN organization_id DROP DEFAULT;
+
+CREATE INDEX if not exists users_organization_id_idx ON diplom.users (organization_id);
+CREATE INDEX if not exists phrase_types_organization_id_idx ON diplom.phrase_types (organization_id);
+CREATE INDEX if not exists phrases_organization_id_idx ON diplom.phrases (organization_id);
+CREATE INDEX if not exists scenarios_organization_id_idx ON diplom.scenarios (organization_id);
+CREATE INDEX if not exists audio_phrases_organization_id_idx ON diplom.audio_phrases (organization_id);
+CREATE INDEX if not exists audio_answers_organization_id_idx ON diplom.audio_answers (organization_id);
+CREATE INDEX if not exists audit_log_organization_id_idx ON diplom.audit_log (organization_id, created_at);
+
+INSERT INTO diplom.roles (name, title) VALUES
+    ('superadmin', 'Super administrator')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.permissions (name, description) VALUES
+    ('organizations:manage', 'Create and manage organizations')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission)
+SELECT 'superadmin', name FROM diplom.permissions
+ON CONFLICT DO NOTHING;
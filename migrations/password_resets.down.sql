@@ -0,0 +1 @@
+drop table if exists diplom.password_resets;
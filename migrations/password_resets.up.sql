@@ -0,0 +1,10 @@
+CREATE TABLE if not exists diplom.password_resets (
+                          id UUID PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                          token_hash TEXT NOT NULL UNIQUE,
+                          created_at TIMESTAMP NOT NULL,
+                          expires_at TIMESTAMP NOT NULL,
+                          used_at TIMESTAMP
+);
+
+CREATE INDEX if not exists password_resets_user_id_idx ON diplom.password_resets (user_id);
@@ -0,0 +1 @@
+ALTER TABLE diplom.phrases DROP COLUMN if exists markup;
@@ -0,0 +1 @@
+ALTER TABLE diplom.phrases ADD COLUMN if not exists markup TEXT NOT NULL DEFAULT '';
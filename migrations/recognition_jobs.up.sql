@@ -0,0 +1,18 @@
+CREATE TABLE if not exists diplom.recognition_jobs (
+                         id UUID PRIMARY KEY,
+                         organization_id UUID NOT NULL REFERENCES diplom.organizations(id) ON DELETE CASCADE,
+                         user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                         phrase_stream_id UUID NOT NULL REFERENCES diplom.phrase_streams(id) ON DELETE CASCADE,
+                         path_to_audio TEXT NOT NULL,
+                         record_time TIMESTAMP NOT NULL,
+                         status TEXT NOT NULL,
+                         operation_id TEXT NOT NULL DEFAULT '',
+                         attempts INT NOT NULL DEFAULT 0,
+                         answer_id UUID REFERENCES diplom.answers(id) ON DELETE SET NULL,
+                         error TEXT NOT NULL DEFAULT '',
+                         next_run_at TIMESTAMP NOT NULL,
+                         created_at TIMESTAMP NOT NULL,
+                         updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX if not exists recognition_jobs_pending_idx ON diplom.recognition_jobs (next_run_at) WHERE status = 'pending';
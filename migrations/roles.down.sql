@@ -0,0 +1,8 @@
+ALTER TABLE diplom.users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE diplom.users SET is_admin = (role = 'admin');
+ALTER TABLE diplom.users DROP COLUMN role;
+ALTER TABLE diplom.users RENAME COLUMN is_admin TO role;
+ALTER TABLE diplom.users ALTER COLUMN role DROP DEFAULT;
+drop table if exists diplom.role_permissions;
+drop table if exists diplom.permissions;
+drop table if exists diplom.roles;
@@ -0,0 +1,57 @@
+CREATE TABLE if not exists diplom.roles (
+                           name TEXT PRIMARY KEY,
+                           title TEXT NOT NULL
+);
+
+CREATE TABLE if not exists diplom.permissions (
+                           name TEXT PRIMARY KEY,
+                           description TEXT NOT NULL
+);
+
+CREATE TABLE if not exists diplom.role_permissions (
+                           role TEXT REFERENCES diplom.roles(name) ON DELETE CASCADE,
+                           permission TEXT REFERENCES diplom.permissions(name) ON DELETE CASCADE,
+                           PRIMARY KEY (role, permission)
+);
+
+INSERT INTO diplom.roles (name, title) VALUES
+    ('student', 'Student'),
+    ('teacher', 'Teacher'),
+    ('editor', 'Content editor'),
+    ('admin', 'Administrator')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.permissions (name, description) VALUES
+    ('phrases:read', 'View phrases'),
+    ('phrases:write', 'Create, update and delete phrases'),
+    ('phrase_types:read', 'View phrase types'),
+    ('phrase_types:write', 'Create, update and delete phrase types'),
+    ('answers:read_all', 'View answers of every student'),
+    ('answers:read_students', 'View answers of own students'),
+    ('answers:delete', 'Delete student answers'),
+    ('users:manage', 'Manage users and their roles'),
+    ('scenarios:practice', 'Run scenarios and submit answers')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission) VALUES
+    ('student', 'scenarios:practice'),
+    ('teacher', 'scenarios:practice'),
+    ('teacher', 'phrases:read'),
+    ('teacher', 'phrase_types:read'),
+    ('teacher', 'answers:read_students'),
+    ('editor', 'phrases:read'),
+    ('editor', 'phrases:write'),
+    ('editor', 'phrase_types:read'),
+    ('editor', 'phrase_types:write')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission)
+SELECT 'admin', name FROM diplom.permissions
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE diplom.users RENAME COLUMN role TO is_admin;
+ALTER TABLE diplom.users ADD COLUMN role TEXT REFERENCES diplom.roles(name);
+UPDATE diplom.users SET role = CASE WHEN is_admin THEN 'admin' ELSE 'student' END;
+ALTER TABLE diplom.users ALTER COLUMN role SET NOT NULL;
+ALTER TABLE diplom.users ALTER COLUMN role SET DEFAULT 'student';
+ALTER TABLE diplom.users DROP COLUMN is_admin;
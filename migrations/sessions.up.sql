@@ -0,0 +1,13 @@
+CREATE TABLE if not exists diplom.sessions (
+                          id UUID PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                          refresh_token_hash TEXT NOT NULL,
+                          user_agent TEXT,
+                          ip TEXT,
+                          created_at TIMESTAMP NOT NULL,
+                          last_used_at TIMESTAMP NOT NULL,
+                          expires_at TIMESTAMP NOT NULL,
+                          revoked_at TIMESTAMP
+);
+
+CREATE INDEX if not exists sessions_user_id_idx ON diplom.sessions (user_id);
@@ -0,0 +1,9 @@
+CREATE TABLE if not exists diplom.user_identities (
+                          issuer TEXT NOT NULL,
+                          subject TEXT NOT NULL,
+                          user_id UUID NOT NULL REFERENCES diplom.users(id) ON DELETE CASCADE,
+                          created_at TIMESTAMP NOT NULL,
+                          PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX if not exists user_identities_user_id_idx ON diplom.user_identities (user_id);
@@ -0,0 +1 @@
+ALTER TABLE diplom.users DROP COLUMN if exists deactivated_at;
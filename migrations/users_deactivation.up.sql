@@ -0,0 +1 @@
+ALTER TABLE diplom.users ADD COLUMN if not exists deactivated_at TIMESTAMP;
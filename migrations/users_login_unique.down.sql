@@ -0,0 +1 @@
+drop index if exists diplom.users_login_key;
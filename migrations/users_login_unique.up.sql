@@ -0,0 +1,5 @@
+-- Logins were not unique before, so rename all duplicates but one before adding the index.
+UPDATE diplom.users u SET login = u.login || '-' || left(u.id::text, 8)
+WHERE EXISTS (SELECT 1 FROM diplom.users d WHERE d.login = u.login AND d.id < u.id);
+
+CREATE UNIQUE INDEX if not exists users_login_key ON diplom.users (login);
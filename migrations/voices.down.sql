@@ -0,0 +1,2 @@
+DELETE FROM diplom.permissions WHERE name = 'voices:manage';
+drop table if exists diplom.voices;
@@ -0,0 +1,24 @@
+CREATE TABLE if not exists diplom.voices (
+                          id UUID PRIMARY KEY,
+                          organization_id UUID NOT NULL REFERENCES diplom.organizations(id) ON DELETE CASCADE,
+                          accent TEXT NOT NULL,
+                          voice TEXT NOT NULL,
+                          language TEXT NOT NULL,
+                          emotion TEXT NOT NULL DEFAULT '',
+                          speed DOUBLE PRECISION NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX if not exists voices_organization_accent_key ON diplom.voices (organization_id, accent);
+
+INSERT INTO diplom.voices (id, organization_id, accent, voice, language, emotion, speed) VALUES
+    ('00000000-0000-0000-0000-000000000101', '00000000-0000-0000-0000-000000000001', 'american', 'john', 'en-US', '', 1)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO diplom.permissions (name, description) VALUES
+    ('voices:manage', 'Manage the voice catalog used for phrase synthesis')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO diplom.role_permissions (role, permission) VALUES
+    ('admin', 'voices:manage'),
+    ('superadmin', 'voices:manage')
+ON CONFLICT DO NOTHING;